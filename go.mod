@@ -3,25 +3,24 @@ module github.com/bryanl/sheaf
 go 1.13
 
 require (
-	github.com/Microsoft/hcsshim v0.8.7 // indirect
 	github.com/containerd/continuity v0.0.0-20200107194136-26c1120b8d41
-	github.com/docker/cli v0.0.0-20200130152716-5d0cf8839492 // indirect
+	github.com/docker/cli v0.0.0-20200130152716-5d0cf8839492
 	github.com/docker/distribution v2.7.1-0.20190205005809-0d3efadf0154+incompatible // indirect
-	github.com/docker/docker v1.4.2-0.20200203170920-46ec8731fbce
+	github.com/docker/docker v1.4.2-0.20200203170920-46ec8731fbce // indirect
 	github.com/golang/mock v1.2.0
 	github.com/golang/protobuf v1.3.3 // indirect
 	github.com/google/go-cmp v0.4.0 // indirect
 	github.com/google/go-containerregistry v0.0.0-20191015185424-71da34e4d9b3
+	github.com/klauspost/compress v1.11.13
 	github.com/konsorten/go-windows-terminal-sequences v1.0.2 // indirect
 	github.com/onsi/ginkgo v1.11.0 // indirect
 	github.com/onsi/gomega v1.8.1 // indirect
 	github.com/opencontainers/image-spec v1.0.1
-	github.com/opencontainers/runc v0.1.1 // indirect
+	github.com/pelletier/go-toml v1.2.0
 	github.com/pivotal/go-ape v0.0.0-20200224111603-3ada71e48e45
 	github.com/pivotal/image-relocation v0.0.0-20200316165451-4b79291c2166
 	github.com/pkg/errors v0.9.1 // indirect
-	github.com/sirupsen/logrus v1.4.2 // indirect
-	github.com/spf13/cobra v0.0.5
+	github.com/spf13/cobra v1.0.0
 	github.com/spf13/viper v1.6.1
 	github.com/stretchr/testify v1.5.1
 	github.com/vmware-labs/yaml-jsonpath v0.0.0-20200624151422-ed2c9c62177a
@@ -32,7 +31,8 @@ require (
 	golang.org/x/tools v0.0.0-20200204192400-7124308813f3 // indirect
 	gopkg.in/yaml.v3 v3.0.0-20200313102051-9f266ea9e77c
 	honnef.co/go/tools v0.0.1-2020.1.3 // indirect
-	k8s.io/client-go v0.0.0-20200131194156-19522ff28802
+	k8s.io/client-go v0.17.0
 	k8s.io/klog v1.0.0
-	sigs.k8s.io/yaml v1.1.0
+	sigs.k8s.io/kustomize/api v0.6.5
+	sigs.k8s.io/yaml v1.2.0
 )
@@ -117,6 +117,7 @@ func DefaultBundleStubs(t *testing.T, controller *gomock.Controller, config shea
 	imageList, err := images.New("image")
 	require.NoError(t, err)
 	bundle.EXPECT().Images().Return(imageList, nil).AnyTimes()
+	bundle.EXPECT().Lock().Return(sheaf.BundleLock{}, nil).AnyTimes()
 
 	return bundle
 }
@@ -9,46 +9,122 @@ package archiver
 import (
 	"fmt"
 	"io"
-	"os"
+	"time"
 
 	"github.com/bryanl/sheaf/internal/goutil"
 	"github.com/bryanl/sheaf/pkg/sheaf"
 )
 
-// Archiver is a targz archiver.
-type Archiver struct{}
+// DefaultModTime is the modification time of the entries in archives if
+// none is configured.
+var DefaultModTime = time.Unix(0, 0).UTC()
+
+// Option is a functional option for configuring Archiver.
+type Option func(a *Archiver)
+
+// WithModTime sets the modification time of the entries in archives. It is
+// truncated to seconds.
+func WithModTime(t time.Time) Option {
+	return func(a *Archiver) {
+		a.modTime = t.UTC().Truncate(time.Second)
+	}
+}
+
+// WithFormat sets the format of archives. Archives in any format can be
+// unarchived.
+func WithFormat(format Format) Option {
+	return func(a *Archiver) {
+		a.format = format
+	}
+}
+
+// Archiver is a tar archiver. Archives are gzipped unless another format is
+// configured, and are reproducible: archiving the same files always creates
+// the same bytes.
+type Archiver struct {
+	modTime time.Time
+	format  Format
+}
 
 var _ sheaf.Archiver = &Archiver{}
 
 // New creates an instance of Archiver.
-func New() *Archiver {
-	a := Archiver{}
+func New(options ...Option) *Archiver {
+	a := Archiver{
+		modTime: DefaultModTime,
+		format:  Gzip,
+	}
+
+	for _, option := range options {
+		option(&a)
+	}
 
 	return &a
 }
 
-// Unarchive unarchives a reader to a directory
+// Unarchive unarchives a reader to a directory. The archive format is
+// detected from its content.
 func (a Archiver) Unarchive(r io.Reader, dest string) error {
-	return untargz(r, dest)
+	tr, err := decompress(r)
+	if err != nil {
+		return fmt.Errorf("read archive: %w", err)
+	}
+
+	defer goutil.Close(tr)
+
+	return readTar(tr, dest, nil)
 }
 
 // Archive archives a directory to a writer.
 func (a Archiver) Archive(src string, w io.Writer) error {
-	return targz(src, w)
+	cw, err := a.format.compress(w)
+	if err != nil {
+		return fmt.Errorf("create %s writer: %w", a.format, err)
+	}
+
+	if err := writeTar(src, cw, a.modTime); err != nil {
+		return err
+	}
+
+	if err := cw.Close(); err != nil {
+		return fmt.Errorf("write tar ball: %w", err)
+	}
+
+	return nil
+}
+
+// Extension returns the filename extension of archives.
+func (a Archiver) Extension() string {
+	return a.format.Extension()
 }
 
-// UnarchivePath unarchives a targz file to a directory.
+// UnarchivePath unarchives an archive file to a directory. An archive split
+// into volumes can be unarchived from its index or its first volume.
 func (a Archiver) UnarchivePath(src string, dest string) error {
-	f, err := os.Open(src)
+	return unarchivePath(src, dest, nil)
+}
+
+// UnarchiveMetadataPath unarchives everything in an archive file except its
+// image layers to a directory. The archive is streamed, and the image layout
+// blobs that are kept are the manifests, indexes, and configs.
+func (a Archiver) UnarchiveMetadataPath(src string, dest string) error {
+	return unarchivePath(src, dest, metadataFilter)
+}
+
+func unarchivePath(src, dest string, filter entryFilter) error {
+	f, err := Open(src)
 	if err != nil {
-		return fmt.Errorf("unable to open archive %q: %q", src, err)
+		return fmt.Errorf("unable to open archive %q: %w", src, err)
 	}
 
 	defer goutil.Close(f)
 
-	if err := a.Unarchive(f, dest); err != nil {
-		return err
+	tr, err := decompress(f)
+	if err != nil {
+		return fmt.Errorf("read archive: %w", err)
 	}
 
-	return nil
+	defer goutil.Close(tr)
+
+	return readTar(tr, dest, filter)
 }
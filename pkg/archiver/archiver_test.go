@@ -0,0 +1,164 @@
+/*
+ * Copyright 2020 Sheaf Authors
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package archiver
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestArchiver_Archive(t *testing.T) {
+	stage := func(t *testing.T, modTime time.Time, fileMode os.FileMode) string {
+		dir, err := ioutil.TempDir("", "sheaf-test")
+		require.NoError(t, err)
+
+		files := map[string]string{
+			"bundle.json":                         `{"name":"bundle"}`,
+			"app/manifests/deploy.yaml":           "kind: Deployment\n",
+			"artifacts/layout/oci-layout":         `{"imageLayoutVersion":"1.0.0"}`,
+			"artifacts/layout/blobs/sha256/aaaaa": "blob",
+		}
+		for name, content := range files {
+			path := filepath.Join(dir, filepath.FromSlash(name))
+			require.NoError(t, os.MkdirAll(filepath.Dir(path), 0700))
+			require.NoError(t, ioutil.WriteFile(path, []byte(content), fileMode))
+			require.NoError(t, os.Chtimes(path, modTime, modTime))
+		}
+
+		return dir
+	}
+
+	archive := func(t *testing.T, a *Archiver, dir string) []byte {
+		var buf bytes.Buffer
+		require.NoError(t, a.Archive(dir, &buf))
+		return buf.Bytes()
+	}
+
+	for _, format := range Formats {
+		t.Run(string(format)+" archives are reproducible", func(t *testing.T) {
+			dir1 := stage(t, time.Now(), 0600)
+			defer func() {
+				require.NoError(t, os.RemoveAll(dir1))
+			}()
+
+			dir2 := stage(t, time.Now().Add(-time.Hour), 0640)
+			defer func() {
+				require.NoError(t, os.RemoveAll(dir2))
+			}()
+
+			a := New(WithFormat(format))
+			require.Equal(t, archive(t, a, dir1), archive(t, a, dir2))
+		})
+
+		t.Run(string(format)+" archives can be unarchived", func(t *testing.T) {
+			dir := stage(t, time.Now(), 0600)
+			defer func() {
+				require.NoError(t, os.RemoveAll(dir))
+			}()
+
+			dest, err := ioutil.TempDir("", "sheaf-test")
+			require.NoError(t, err)
+			defer func() {
+				require.NoError(t, os.RemoveAll(dest))
+			}()
+
+			// The format is detected, so any archiver can unarchive it.
+			require.NoError(t, New().Unarchive(bytes.NewReader(archive(t, New(WithFormat(format)), dir)), dest))
+
+			data, err := ioutil.ReadFile(filepath.Join(dest, "app", "manifests", "deploy.yaml"))
+			require.NoError(t, err)
+			require.Equal(t, "kind: Deployment\n", string(data))
+		})
+	}
+
+	t.Run("entries are normalized", func(t *testing.T) {
+		dir := stage(t, time.Now(), 0600)
+		defer func() {
+			require.NoError(t, os.RemoveAll(dir))
+		}()
+
+		modTime := time.Unix(1580000000, 0).UTC()
+		data := archive(t, New(WithModTime(modTime)), dir)
+
+		zr, err := gzip.NewReader(bytes.NewReader(data))
+		require.NoError(t, err)
+		require.True(t, zr.ModTime.IsZero())
+		require.Empty(t, zr.Name)
+
+		var names []string
+		tr := tar.NewReader(zr)
+		for {
+			header, err := tr.Next()
+			if err == io.EOF {
+				break
+			}
+			require.NoError(t, err)
+
+			names = append(names, header.Name)
+			require.Equal(t, modTime, header.ModTime.UTC())
+			require.Equal(t, 0, header.Uid)
+			require.Equal(t, 0, header.Gid)
+			require.Empty(t, header.Uname)
+			require.Empty(t, header.Gname)
+
+			if header.Typeflag == tar.TypeDir {
+				require.Equal(t, int64(0755), header.Mode)
+			} else {
+				require.Equal(t, int64(0644), header.Mode)
+			}
+		}
+
+		require.Equal(t, []string{
+			"app/",
+			"app/manifests/",
+			"app/manifests/deploy.yaml",
+			"artifacts/",
+			"artifacts/layout/",
+			"artifacts/layout/blobs/",
+			"artifacts/layout/blobs/sha256/",
+			"artifacts/layout/blobs/sha256/aaaaa",
+			"artifacts/layout/oci-layout",
+			"bundle.json",
+		}, names)
+	})
+}
+
+func TestParseFormat(t *testing.T) {
+	tests := []struct {
+		name          string
+		wanted        Format
+		wantExtension string
+		wantErr       bool
+	}{
+		{name: "gzip", wanted: Gzip, wantExtension: ".tgz"},
+		{name: "zstd", wanted: Zstd, wantExtension: ".tar.zst"},
+		{name: "tar", wanted: Tar, wantExtension: ".tar"},
+		{name: "zip", wantErr: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := ParseFormat(test.name)
+			if test.wantErr {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, test.wanted, got)
+			require.Equal(t, test.wantExtension, got.Extension())
+		})
+	}
+}
@@ -0,0 +1,119 @@
+/*
+ * Copyright 2020 Sheaf Authors
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package archiver
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// Format is an archive format.
+type Format string
+
+const (
+	// Gzip is a gzipped tar archive.
+	Gzip Format = "gzip"
+	// Zstd is a zstd compressed tar archive.
+	Zstd Format = "zstd"
+	// Tar is an uncompressed tar archive.
+	Tar Format = "tar"
+)
+
+// Formats are the supported archive formats.
+var Formats = []Format{Gzip, Zstd, Tar}
+
+var (
+	gzipMagic = []byte{0x1f, 0x8b}
+	zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+)
+
+// ParseFormat parses an archive format.
+func ParseFormat(s string) (Format, error) {
+	for _, format := range Formats {
+		if s == string(format) {
+			return format, nil
+		}
+	}
+
+	return "", fmt.Errorf("unknown archive format %q (valid formats are %s)", s, strings.Join(FormatNames(), ", "))
+}
+
+// FormatNames returns the names of the supported archive formats.
+func FormatNames() []string {
+	var names []string
+	for _, format := range Formats {
+		names = append(names, string(format))
+	}
+
+	return names
+}
+
+// Extension returns the filename extension of archives in a format.
+func (f Format) Extension() string {
+	switch f {
+	case Zstd:
+		return ".tar.zst"
+	case Tar:
+		return ".tar"
+	default:
+		return ".tgz"
+	}
+}
+
+// compress returns a writer that compresses a tar archive in a format to w.
+// The writer must be closed to flush the archive. Compressed archives are
+// reproducible: the gzip header has no name or timestamp, and zstd output
+// only depends on its input.
+func (f Format) compress(w io.Writer) (io.WriteCloser, error) {
+	switch f {
+	case Zstd:
+		return zstd.NewWriter(w)
+	case Tar:
+		return nopWriteCloser{w}, nil
+	default:
+		return gzip.NewWriter(w), nil
+	}
+}
+
+// decompress detects the format of an archive from its magic bytes and
+// returns a reader of the tar archive it contains.
+func decompress(r io.Reader) (io.ReadCloser, error) {
+	br := bufio.NewReader(r)
+
+	magic, err := br.Peek(len(zstdMagic))
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	switch {
+	case bytes.HasPrefix(magic, gzipMagic):
+		return gzip.NewReader(br)
+	case bytes.HasPrefix(magic, zstdMagic):
+		zr, err := zstd.NewReader(br)
+		if err != nil {
+			return nil, err
+		}
+		return zr.IOReadCloser(), nil
+	default:
+		return ioutil.NopCloser(br), nil
+	}
+}
+
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error {
+	return nil
+}
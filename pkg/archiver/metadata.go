@@ -0,0 +1,40 @@
+/*
+ * Copyright 2020 Sheaf Authors
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package archiver
+
+import (
+	"archive/tar"
+	"bufio"
+	"strings"
+)
+
+// MaxMetadataBlobSize is the size of the largest image layout blob that is
+// unarchived as metadata.
+const MaxMetadataBlobSize = 16 << 20
+
+// layoutBlobsPrefix is the prefix of the image layout blobs in an archive.
+const layoutBlobsPrefix = "artifacts/layout/blobs/"
+
+// metadataFilter keeps every file except image layers. Manifests, indexes,
+// and configs are JSON documents, so a blob is kept if it starts like one.
+// Layers are tar streams, usually compressed, and never do.
+func metadataFilter(header *tar.Header, r *bufio.Reader) (bool, error) {
+	if !strings.HasPrefix(header.Name, layoutBlobsPrefix) {
+		return true, nil
+	}
+
+	if header.Size == 0 || header.Size > MaxMetadataBlobSize {
+		return false, nil
+	}
+
+	b, err := r.Peek(1)
+	if err != nil {
+		return false, err
+	}
+
+	return b[0] == '{', nil
+}
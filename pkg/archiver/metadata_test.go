@@ -0,0 +1,73 @@
+/*
+ * Copyright 2020 Sheaf Authors
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package archiver
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestArchiver_UnarchiveMetadataPath(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sheaf-test")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, os.RemoveAll(dir))
+	}()
+
+	src := filepath.Join(dir, "src")
+	files := map[string]string{
+		"bundle.json":                          `{"name":"bundle"}`,
+		"app/manifests/deploy.yaml":            "kind: Deployment\n",
+		"artifacts/layout/index.json":          `{"schemaVersion":2}`,
+		"artifacts/layout/blobs/sha256/config": `{"architecture":"amd64"}`,
+		"artifacts/layout/blobs/sha256/layer":  "\x1f\x8blayer",
+		"artifacts/layout/blobs/sha256/empty":  "",
+	}
+	for name, content := range files {
+		path := filepath.Join(src, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0700))
+		require.NoError(t, ioutil.WriteFile(path, []byte(content), 0600))
+	}
+
+	for _, format := range Formats {
+		t.Run(string(format), func(t *testing.T) {
+			a := New(WithFormat(format))
+
+			archive := filepath.Join(dir, "archive"+a.Extension())
+			f, err := os.Create(archive)
+			require.NoError(t, err)
+			require.NoError(t, a.Archive(src, f))
+			require.NoError(t, f.Close())
+
+			dest := filepath.Join(dir, "dest-"+string(format))
+			require.NoError(t, a.UnarchiveMetadataPath(archive, dest))
+
+			for _, name := range []string{
+				"bundle.json",
+				"app/manifests/deploy.yaml",
+				"artifacts/layout/index.json",
+				"artifacts/layout/blobs/sha256/config",
+			} {
+				data, err := ioutil.ReadFile(filepath.Join(dest, filepath.FromSlash(name)))
+				require.NoError(t, err, name)
+				require.Equal(t, files[name], string(data))
+			}
+
+			for _, name := range []string{
+				"artifacts/layout/blobs/sha256/layer",
+				"artifacts/layout/blobs/sha256/empty",
+			} {
+				_, err := os.Stat(filepath.Join(dest, filepath.FromSlash(name)))
+				require.True(t, os.IsNotExist(err), name)
+			}
+		})
+	}
+}
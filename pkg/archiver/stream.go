@@ -0,0 +1,93 @@
+/*
+ * Copyright 2020 Sheaf Authors
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package archiver
+
+import (
+	"archive/tar"
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+
+	"github.com/bryanl/sheaf/internal/goutil"
+)
+
+// Files lists the regular files in an archive file by streaming it. It also
+// returns the digest of the archive.
+func Files(src string) ([]string, string, error) {
+	var names []string
+	digest, err := stream(src, func(r io.Reader) error {
+		tr := tar.NewReader(r)
+		for {
+			header, err := tr.Next()
+			if err == io.EOF {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+
+			if header.Typeflag == tar.TypeReg {
+				names = append(names, header.Name)
+			}
+		}
+	})
+	if err != nil {
+		return nil, "", err
+	}
+
+	return names, digest, nil
+}
+
+// ExtractFiles extracts the named regular files in an archive file to a
+// directory by streaming it. It also returns the digest of the archive.
+func ExtractFiles(src, dest string, names map[string]bool) (string, error) {
+	return stream(src, func(r io.Reader) error {
+		return readTar(r, dest, func(header *tar.Header, _ *bufio.Reader) (bool, error) {
+			return names[header.Name], nil
+		})
+	})
+}
+
+// stream reads the tar stream of an archive file and returns the SHA-256
+// digest of the archive. The digest of an archive split into volumes is the
+// digest of its joined volumes, so it is the same as if it wasn't split.
+func stream(src string, read func(r io.Reader) error) (string, error) {
+	f, err := Open(src)
+	if err != nil {
+		return "", fmt.Errorf("unable to open archive %q: %w", src, err)
+	}
+
+	defer goutil.Close(f)
+
+	h := sha256.New()
+	r := io.TeeReader(f, h)
+
+	tr, err := decompress(r)
+	if err != nil {
+		return "", fmt.Errorf("read archive: %w", err)
+	}
+
+	if err := read(tr); err != nil {
+		goutil.Close(tr)
+		return "", err
+	}
+
+	// The decompressor is closed before the rest of the archive is read so
+	// it isn't reading at the same time.
+	if err := tr.Close(); err != nil {
+		return "", fmt.Errorf("read archive: %w", err)
+	}
+
+	if _, err := io.Copy(ioutil.Discard, r); err != nil {
+		return "", fmt.Errorf("read archive: %w", err)
+	}
+
+	return "sha256:" + hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,89 @@
+/*
+ * Copyright 2020 Sheaf Authors
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package archiver
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStream(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sheaf-test")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, os.RemoveAll(dir))
+	}()
+
+	src := filepath.Join(dir, "src")
+	files := map[string]string{
+		"bundle.json":                         `{"name":"bundle"}`,
+		"artifacts/layout/blobs/sha256/aaaaa": "aaaaa",
+		"artifacts/layout/blobs/sha256/bbbbb": "bbbbb",
+	}
+	for name, content := range files {
+		path := filepath.Join(src, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0700))
+		require.NoError(t, ioutil.WriteFile(path, []byte(content), 0600))
+	}
+
+	for _, format := range Formats {
+		t.Run(string(format), func(t *testing.T) {
+			a := New(WithFormat(format))
+
+			archive := filepath.Join(dir, "archive"+a.Extension())
+			f, err := os.Create(archive)
+			require.NoError(t, err)
+			require.NoError(t, a.Archive(src, f))
+			require.NoError(t, f.Close())
+
+			data, err := ioutil.ReadFile(archive)
+			require.NoError(t, err)
+			sum := sha256.Sum256(data)
+			wantDigest := "sha256:" + hex.EncodeToString(sum[:])
+
+			names, digest, err := Files(archive)
+			require.NoError(t, err)
+			require.Equal(t, wantDigest, digest)
+			require.Equal(t, []string{
+				"artifacts/layout/blobs/sha256/aaaaa",
+				"artifacts/layout/blobs/sha256/bbbbb",
+				"bundle.json",
+			}, names)
+
+			dest := filepath.Join(dir, "dest-"+string(format))
+			digest, err = ExtractFiles(archive, dest, map[string]bool{
+				"artifacts/layout/blobs/sha256/bbbbb": true,
+			})
+			require.NoError(t, err)
+			require.Equal(t, wantDigest, digest)
+
+			require.FileExists(t, filepath.Join(dest, "artifacts", "layout", "blobs", "sha256", "bbbbb"))
+			require.NoFileExists(t, filepath.Join(dest, "artifacts", "layout", "blobs", "sha256", "aaaaa"))
+			require.NoFileExists(t, filepath.Join(dest, "bundle.json"))
+
+			// The digest of an archive split into volumes is the digest of the
+			// archive before it was split.
+			split := filepath.Join(dir, "split-"+string(format))
+			require.NoError(t, os.Mkdir(split, 0700))
+			vw, err := NewVolumeWriter(filepath.Join(split, "archive"+a.Extension()), 100)
+			require.NoError(t, err)
+			_, err = vw.Write(data)
+			require.NoError(t, err)
+			require.NoError(t, vw.Close())
+
+			_, digest, err = Files(VolumeIndexPath(filepath.Join(split, "archive"+a.Extension())))
+			require.NoError(t, err)
+			require.Equal(t, wantDigest, digest)
+		})
+	}
+}
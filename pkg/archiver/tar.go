@@ -0,0 +1,212 @@
+/*
+ * Copyright 2020 Sheaf Authors
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package archiver
+
+import (
+	"archive/tar"
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bryanl/sheaf/internal/goutil"
+)
+
+// writeTar writes a tar archive. Assume src is a directory. The archive is
+// reproducible: entries are sorted by name, every entry has the same
+// modification time, ownership is cleared, and modes are normalized.
+func writeTar(src string, w io.Writer, modTime time.Time) error {
+	fi, err := os.Stat(src)
+	if err != nil {
+		return err
+	}
+
+	if !fi.IsDir() {
+		return fmt.Errorf("%s is not a directory", src)
+	}
+
+	var names []string
+	err = filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if path == src {
+			return nil
+		}
+
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+
+		names = append(names, filepath.ToSlash(rel))
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("list files: %w", err)
+	}
+
+	sort.Strings(names)
+
+	tw := tar.NewWriter(w)
+
+	for _, name := range names {
+		if err := writeTarEntry(tw, src, name, modTime); err != nil {
+			return fmt.Errorf("write %s to tar ball: %w", name, err)
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return fmt.Errorf("write tar ball: %w", err)
+	}
+
+	return nil
+}
+
+// writeTarEntry writes a file, directory, or symlink to a tar archive with a
+// normalized header.
+func writeTarEntry(tw *tar.Writer, src, name string, modTime time.Time) error {
+	path := filepath.Join(src, filepath.FromSlash(name))
+
+	fi, err := os.Lstat(path)
+	if err != nil {
+		return err
+	}
+
+	header := &tar.Header{
+		Name:    name,
+		ModTime: modTime,
+	}
+
+	switch {
+	case fi.IsDir():
+		header.Typeflag = tar.TypeDir
+		header.Name += "/"
+		header.Mode = 0755
+	case fi.Mode()&os.ModeSymlink != 0:
+		target, err := os.Readlink(path)
+		if err != nil {
+			return err
+		}
+
+		header.Typeflag = tar.TypeSymlink
+		header.Linkname = target
+		header.Mode = 0777
+	case fi.Mode().IsRegular():
+		header.Typeflag = tar.TypeReg
+		header.Size = fi.Size()
+		header.Mode = 0644
+		if fi.Mode()&0111 != 0 {
+			header.Mode = 0755
+		}
+	default:
+		return fmt.Errorf("unsupported file mode %s", fi.Mode())
+	}
+
+	if err := tw.WriteHeader(header); err != nil {
+		return err
+	}
+
+	if header.Typeflag != tar.TypeReg {
+		return nil
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+
+	defer goutil.Close(f)
+
+	_, err = io.Copy(tw, f)
+	return err
+}
+
+// entryFilter decides whether a file in a tar archive is unarchived. It can
+// peek at the start of the file's content.
+type entryFilter func(header *tar.Header, r *bufio.Reader) (bool, error)
+
+// readTar unarchives a tar archive. Files are skipped if a filter is given
+// and rejects them.
+func readTar(src io.Reader, dst string, filter entryFilter) error {
+	tr := tar.NewReader(src)
+
+	// uncompress each element
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break // end of archiver
+		}
+		if err != nil {
+			return err
+		}
+
+		// validate name against path traversal
+		if !validRelPath(header.Name) {
+			return fmt.Errorf("tar contained invalid name error %q", header.Name)
+		}
+
+		// add dst + re-format slashes according to system
+		target := filepath.Join(dst, header.Name)
+		// if no join is needed, replace with ToSlash:
+		// target = filepath.ToSlash(header.Name)
+
+		// check the type
+		switch header.Typeflag {
+
+		// if its a dir and it doesn't exist create it (with 0755 permission)
+		case tar.TypeDir:
+			if _, err := os.Stat(target); err != nil {
+				if err := os.MkdirAll(target, 0755); err != nil {
+					return err
+				}
+			}
+		// if it's a file create it (with same permission)
+		case tar.TypeReg:
+			r := bufio.NewReader(tr)
+			if filter != nil {
+				keep, err := filter(header, r)
+				if err != nil {
+					return fmt.Errorf("read %s: %w", header.Name, err)
+				}
+				if !keep {
+					continue
+				}
+			}
+
+			fileToWrite, err := os.OpenFile(target, os.O_CREATE|os.O_RDWR, os.FileMode(header.Mode))
+			if err != nil {
+				return err
+			}
+			// copy over contents
+			if _, err := io.Copy(fileToWrite, r); err != nil {
+				return err
+			}
+			// manually close here after each file operation; deferring would cause each file close
+			// to wait until all operations have completed.
+
+			if err := fileToWrite.Close(); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// validRelPath checks for path traversal and correct forward slashes
+func validRelPath(p string) bool {
+	if p == "" || strings.Contains(p, `\`) || strings.HasPrefix(p, "/") || strings.Contains(p, "../") {
+		return false
+	}
+	return true
+}
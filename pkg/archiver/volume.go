@@ -0,0 +1,391 @@
+/*
+ * Copyright 2020 Sheaf Authors
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package archiver
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// VolumeIndexSuffix is the suffix of the index of an archive split into
+// volumes.
+const VolumeIndexSuffix = ".volumes.json"
+
+// volumeSuffix matches the suffix of a volume file.
+var volumeSuffix = regexp.MustCompile(`\.(\d{3,})$`)
+
+// VolumeIndex lists the volumes of an archive split into volumes.
+type VolumeIndex struct {
+	// Archive is the name of the archive.
+	Archive string `json:"archive"`
+	// Size is the size of the archive.
+	Size int64 `json:"size"`
+	// Volumes are the volumes in order.
+	Volumes []Volume `json:"volumes"`
+}
+
+// Volume is a volume of an archive.
+type Volume struct {
+	// Name is the file name of the volume.
+	Name string `json:"name"`
+	// Size is the size of the volume.
+	Size int64 `json:"size"`
+	// SHA256 is the hex encoded SHA-256 checksum of the volume.
+	SHA256 string `json:"sha256"`
+}
+
+// VolumeIndexPath returns the path of the index for an archive split into
+// volumes.
+func VolumeIndexPath(archive string) string {
+	return archive + VolumeIndexSuffix
+}
+
+// volumeName returns the file name of a volume. Volumes are numbered from 1.
+func volumeName(archive string, n int) string {
+	return fmt.Sprintf("%s.%03d", filepath.Base(archive), n)
+}
+
+// ParseSize parses a size such as 4G or 512Mi. K, M, G, and T are powers of
+// 1000, and Ki, Mi, Gi, and Ti are powers of 1024. A trailing B is allowed.
+func ParseSize(s string) (int64, error) {
+	units := []struct {
+		suffix     string
+		multiplier int64
+	}{
+		{"Ki", 1 << 10},
+		{"Mi", 1 << 20},
+		{"Gi", 1 << 30},
+		{"Ti", 1 << 40},
+		{"K", 1000},
+		{"M", 1000 * 1000},
+		{"G", 1000 * 1000 * 1000},
+		{"T", 1000 * 1000 * 1000 * 1000},
+	}
+
+	value := strings.TrimSuffix(strings.TrimSpace(s), "B")
+
+	multiplier := int64(1)
+	for _, unit := range units {
+		if strings.HasSuffix(value, unit.suffix) {
+			value = strings.TrimSuffix(value, unit.suffix)
+			multiplier = unit.multiplier
+			break
+		}
+	}
+
+	n, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("invalid size %q", s)
+	}
+
+	return n * multiplier, nil
+}
+
+// VolumeWriter writes an archive to numbered volumes of a fixed size. The
+// volumes of archive.tgz are archive.tgz.001, archive.tgz.002, and so on.
+// Closing the writer writes an index that lists each volume's checksum.
+type VolumeWriter struct {
+	archive string
+	size    int64
+
+	current *os.File
+	hash    hash.Hash
+	written int64
+	index   VolumeIndex
+	closed  bool
+}
+
+var _ io.WriteCloser = &VolumeWriter{}
+
+// NewVolumeWriter creates an instance of VolumeWriter that splits the
+// archive at a path into volumes of a size.
+func NewVolumeWriter(archive string, size int64) (*VolumeWriter, error) {
+	if size <= 0 {
+		return nil, fmt.Errorf("volume size must be positive")
+	}
+
+	return &VolumeWriter{
+		archive: archive,
+		size:    size,
+		index: VolumeIndex{
+			Archive: filepath.Base(archive),
+		},
+	}, nil
+}
+
+// Write writes to the current volume, starting a new volume when it is full.
+func (vw *VolumeWriter) Write(p []byte) (int, error) {
+	total := 0
+	for len(p) > 0 {
+		if vw.current == nil || vw.written == vw.size {
+			if err := vw.next(); err != nil {
+				return total, err
+			}
+		}
+
+		chunk := p
+		if remaining := vw.size - vw.written; int64(len(chunk)) > remaining {
+			chunk = chunk[:remaining]
+		}
+
+		n, err := vw.current.Write(chunk)
+		vw.hash.Write(chunk[:n])
+		vw.written += int64(n)
+		vw.index.Size += int64(n)
+		total += n
+		if err != nil {
+			return total, err
+		}
+
+		p = p[n:]
+	}
+
+	return total, nil
+}
+
+// Close finishes the last volume and writes the index.
+func (vw *VolumeWriter) Close() error {
+	if vw.closed {
+		return nil
+	}
+	vw.closed = true
+
+	// An empty archive still has a volume.
+	if vw.current == nil {
+		if err := vw.next(); err != nil {
+			return err
+		}
+	}
+
+	if err := vw.finish(); err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(vw.index, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(VolumeIndexPath(vw.archive), data, 0644)
+}
+
+// Volumes returns the volumes written.
+func (vw *VolumeWriter) Volumes() []Volume {
+	return vw.index.Volumes
+}
+
+// next finishes the current volume and starts the next one.
+func (vw *VolumeWriter) next() error {
+	if vw.current != nil {
+		if err := vw.finish(); err != nil {
+			return err
+		}
+	}
+
+	name := volumeName(vw.archive, len(vw.index.Volumes)+1)
+	f, err := os.Create(filepath.Join(filepath.Dir(vw.archive), name))
+	if err != nil {
+		return fmt.Errorf("create archive volume: %w", err)
+	}
+
+	vw.current = f
+	vw.hash = sha256.New()
+	vw.written = 0
+	vw.index.Volumes = append(vw.index.Volumes, Volume{Name: name})
+
+	return nil
+}
+
+// finish closes the current volume and records its size and checksum.
+func (vw *VolumeWriter) finish() error {
+	volume := &vw.index.Volumes[len(vw.index.Volumes)-1]
+	volume.Size = vw.written
+	volume.SHA256 = hex.EncodeToString(vw.hash.Sum(nil))
+
+	if err := vw.current.Close(); err != nil {
+		return fmt.Errorf("close archive volume %s: %w", volume.Name, err)
+	}
+
+	return nil
+}
+
+// RemoveVolumes removes the index and volumes of an archive split into
+// volumes.
+func RemoveVolumes(archive string) error {
+	paths, err := filepath.Glob(archive + ".[0-9][0-9][0-9]*")
+	if err != nil {
+		return err
+	}
+
+	var volumes []string
+	for _, path := range paths {
+		if volumeSuffix.ReplaceAllString(path, "") == archive {
+			volumes = append(volumes, path)
+		}
+	}
+
+	for _, path := range append(volumes, VolumeIndexPath(archive)) {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Open opens an archive for reading. An archive split into volumes can be
+// opened by its index or its first volume; its volumes are read in order as
+// one stream and are checked against the sizes and checksums in the index.
+func Open(path string) (io.ReadCloser, error) {
+	indexPath := ""
+	switch {
+	case strings.HasSuffix(path, VolumeIndexSuffix):
+		indexPath = path
+	case volumeSuffix.MatchString(path):
+		archive := volumeSuffix.ReplaceAllString(path, "")
+		if _, err := os.Stat(VolumeIndexPath(archive)); err == nil {
+			if n, _ := strconv.Atoi(volumeSuffix.FindStringSubmatch(path)[1]); n != 1 {
+				return nil, fmt.Errorf("%s is not the first volume of %s", path, filepath.Base(archive))
+			}
+			indexPath = VolumeIndexPath(archive)
+		}
+	}
+
+	if indexPath == "" {
+		return os.Open(path)
+	}
+
+	return openVolumes(indexPath)
+}
+
+// openVolumes opens the volumes listed by an index. Missing volumes and
+// volumes with the wrong size are reported before anything is read.
+func openVolumes(indexPath string) (io.ReadCloser, error) {
+	data, err := ioutil.ReadFile(indexPath)
+	if err != nil {
+		return nil, fmt.Errorf("read archive volume index: %w", err)
+	}
+
+	var index VolumeIndex
+	if err := json.Unmarshal(data, &index); err != nil {
+		return nil, fmt.Errorf("decode archive volume index %s: %w", indexPath, err)
+	}
+
+	if len(index.Volumes) == 0 {
+		return nil, fmt.Errorf("archive volume index %s lists no volumes", indexPath)
+	}
+
+	dir := filepath.Dir(indexPath)
+
+	var problems []string
+	for _, volume := range index.Volumes {
+		fi, err := os.Stat(filepath.Join(dir, volume.Name))
+		switch {
+		case os.IsNotExist(err):
+			problems = append(problems, fmt.Sprintf("volume %s is missing", volume.Name))
+		case err != nil:
+			return nil, err
+		case fi.Size() != volume.Size:
+			problems = append(problems, fmt.Sprintf("volume %s is corrupt: size is %d bytes, expected %d",
+				volume.Name, fi.Size(), volume.Size))
+		}
+	}
+
+	if len(problems) > 0 {
+		return nil, fmt.Errorf("archive %s is incomplete: %s", index.Archive, strings.Join(problems, "; "))
+	}
+
+	return &volumeReader{dir: dir, volumes: index.Volumes}, nil
+}
+
+// volumeReader reads volumes in order as one stream. Each volume's checksum
+// is checked when it has been read.
+type volumeReader struct {
+	dir     string
+	volumes []Volume
+
+	current *os.File
+	hash    hash.Hash
+}
+
+var _ io.ReadCloser = &volumeReader{}
+
+func (vr *volumeReader) Read(p []byte) (int, error) {
+	for {
+		if vr.current == nil {
+			if len(vr.volumes) == 0 {
+				return 0, io.EOF
+			}
+
+			f, err := os.Open(filepath.Join(vr.dir, vr.volumes[0].Name))
+			if err != nil {
+				if os.IsNotExist(err) {
+					return 0, fmt.Errorf("volume %s is missing", vr.volumes[0].Name)
+				}
+				return 0, err
+			}
+
+			vr.current = f
+			vr.hash = sha256.New()
+		}
+
+		n, err := vr.current.Read(p)
+		vr.hash.Write(p[:n])
+
+		if err == io.EOF {
+			if err := vr.finish(); err != nil {
+				return n, err
+			}
+
+			if n == 0 {
+				continue
+			}
+
+			return n, nil
+		}
+
+		return n, err
+	}
+}
+
+// finish closes the current volume and checks its checksum.
+func (vr *volumeReader) finish() error {
+	volume := vr.volumes[0]
+
+	err := vr.current.Close()
+	vr.current = nil
+	vr.volumes = vr.volumes[1:]
+	if err != nil {
+		return err
+	}
+
+	if sum := hex.EncodeToString(vr.hash.Sum(nil)); sum != volume.SHA256 {
+		return fmt.Errorf("volume %s is corrupt: checksum is %s, expected %s", volume.Name, sum, volume.SHA256)
+	}
+
+	return nil
+}
+
+func (vr *volumeReader) Close() error {
+	if vr.current == nil {
+		return nil
+	}
+
+	err := vr.current.Close()
+	vr.current = nil
+	return err
+}
@@ -0,0 +1,172 @@
+/*
+ * Copyright 2020 Sheaf Authors
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package archiver
+
+import (
+	"bytes"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSize(t *testing.T) {
+	tests := []struct {
+		size    string
+		wanted  int64
+		wantErr bool
+	}{
+		{size: "1024", wanted: 1024},
+		{size: "4G", wanted: 4000000000},
+		{size: "4GB", wanted: 4000000000},
+		{size: "512Mi", wanted: 512 << 20},
+		{size: "2Ki", wanted: 2048},
+		{size: "1T", wanted: 1000000000000},
+		{size: "0", wantErr: true},
+		{size: "-1G", wantErr: true},
+		{size: "1.5G", wantErr: true},
+		{size: "G", wantErr: true},
+	}
+	for _, test := range tests {
+		t.Run(test.size, func(t *testing.T) {
+			got, err := ParseSize(test.size)
+			if test.wantErr {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, test.wanted, got)
+		})
+	}
+}
+
+func TestVolumes(t *testing.T) {
+	data := make([]byte, 2500)
+	_, err := rand.Read(data)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		open    string
+		mutate  func(t *testing.T, dir string)
+		wantErr string
+	}{
+		{
+			name: "open index",
+			open: "archive.tgz.volumes.json",
+		},
+		{
+			name: "open first volume",
+			open: "archive.tgz.001",
+		},
+		{
+			name:    "open other volume",
+			open:    "archive.tgz.002",
+			wantErr: "is not the first volume",
+		},
+		{
+			name: "missing volume",
+			open: "archive.tgz.001",
+			mutate: func(t *testing.T, dir string) {
+				require.NoError(t, os.Remove(filepath.Join(dir, "archive.tgz.002")))
+			},
+			wantErr: "volume archive.tgz.002 is missing",
+		},
+		{
+			name: "truncated volume",
+			open: "archive.tgz.001",
+			mutate: func(t *testing.T, dir string) {
+				require.NoError(t, os.Truncate(filepath.Join(dir, "archive.tgz.003"), 10))
+			},
+			wantErr: "volume archive.tgz.003 is corrupt: size is 10 bytes, expected 500",
+		},
+		{
+			name: "corrupt volume",
+			open: "archive.tgz.001",
+			mutate: func(t *testing.T, dir string) {
+				path := filepath.Join(dir, "archive.tgz.002")
+				volume, err := ioutil.ReadFile(path)
+				require.NoError(t, err)
+				volume[0]++
+				require.NoError(t, ioutil.WriteFile(path, volume, 0600))
+			},
+			wantErr: "volume archive.tgz.002 is corrupt: checksum is",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dir, err := ioutil.TempDir("", "sheaf-test")
+			require.NoError(t, err)
+
+			defer func() {
+				require.NoError(t, os.RemoveAll(dir))
+			}()
+
+			archive := filepath.Join(dir, "archive.tgz")
+
+			vw, err := NewVolumeWriter(archive, 1000)
+			require.NoError(t, err)
+			_, err = vw.Write(data[:1500])
+			require.NoError(t, err)
+			_, err = vw.Write(data[1500:])
+			require.NoError(t, err)
+			require.NoError(t, vw.Close())
+
+			volumes := vw.Volumes()
+			require.Len(t, volumes, 3)
+			require.Equal(t, "archive.tgz.003", volumes[2].Name)
+			require.Equal(t, int64(500), volumes[2].Size)
+
+			if test.mutate != nil {
+				test.mutate(t, dir)
+			}
+
+			r, err := Open(filepath.Join(dir, test.open))
+			if err == nil {
+				var got []byte
+				got, err = ioutil.ReadAll(r)
+				require.NoError(t, r.Close())
+
+				if err == nil {
+					require.True(t, bytes.Equal(data, got))
+				}
+			}
+
+			if test.wantErr != "" {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), test.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestRemoveVolumes(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sheaf-test")
+	require.NoError(t, err)
+
+	defer func() {
+		require.NoError(t, os.RemoveAll(dir))
+	}()
+
+	for _, name := range []string{"archive.tgz.001", "archive.tgz.002", "archive.tgz.volumes.json", "other.tgz.001"} {
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dir, name), nil, 0600))
+	}
+
+	require.NoError(t, RemoveVolumes(filepath.Join(dir, "archive.tgz")))
+
+	files, err := ioutil.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	require.Equal(t, "other.tgz.001", files[0].Name())
+}
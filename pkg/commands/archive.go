@@ -21,11 +21,15 @@ func NewArchiveCommand() *cobra.Command {
 	}
 
 	cmd.AddCommand(
+		archive.NewApplyDeltaCommand(),
+		archive.NewDiffCommand(),
 		archive.NewListImages(),
 		archive.NewPackCommand(),
 		archive.NewPushCommand(),
+		archive.NewServeCommand(),
 		archive.NewStageCommand(),
-		archive.NewShowManifests())
+		archive.NewShowManifests(),
+		archive.NewVerifyCommand())
 
 	return cmd
 }
@@ -0,0 +1,45 @@
+/*
+ * Copyright 2020 Sheaf Authors
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package archive
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/bryanl/sheaf/pkg/option"
+	"github.com/bryanl/sheaf/pkg/sheaf"
+)
+
+// NewApplyDeltaCommand creates an apply delta command.
+func NewApplyDeltaCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "apply-delta",
+		Short: "Apply a delta archive to its base archive",
+		Long: `Apply a delta archive to the base archive it was packed against, and write the
+complete archive to a destination directory.
+
+A delta archive, created by "archive pack --base", holds only the blobs missing from its
+base archive. The base archive is streamed and the blobs the delta takes from it are
+added. The command fails if the base archive is not the one the delta was packed against.
+
+If --key is set, the delta archive must have a valid signature from the private key
+matching the public key. The complete archive keeps the signature.`,
+		Args: cobra.NoArgs,
+	}
+
+	setupApplyDelta(cmd)
+	return cmd
+}
+
+func setupApplyDelta(cmd *cobra.Command) {
+	g := option.NewGenerator(cmd, sheaf.ArchiveApplyDelta, "archive-apply-delta")
+	g.WithBundlePath()
+	g.WithArchive()
+	g.WithBase("base archive the delta archive was packed against")
+	g.WithDestination()
+	g.WithForce()
+	g.WithSignatureVerifier("public key (PEM) used to verify the delta archive signature")
+}
@@ -0,0 +1,42 @@
+/*
+ * Copyright 2020 Sheaf Authors
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package archive
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/bryanl/sheaf/pkg/option"
+	"github.com/bryanl/sheaf/pkg/sheaf"
+)
+
+// NewDiffCommand creates a diff command.
+func NewDiffCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "diff <old archive> <new archive>",
+		Short: "Show differences between two archives",
+		Long: `Show the differences between two archives.
+
+Images are compared by name and digest and reported as added, removed, or changed.
+Changes to bundle.json, such as the version, images, exclusions, and user defined
+images, are reported by field. Manifests are compared by resource: resources are
+matched by apiVersion, kind, namespace, and name, and each changed field of a
+changed resource is reported.
+
+The archives are streamed, so their image layers are never extracted.`,
+		Args: cobra.ExactArgs(2),
+	}
+
+	setupDiff(cmd)
+	return cmd
+}
+
+func setupDiff(cmd *cobra.Command) {
+	g := option.NewGenerator(cmd, sheaf.ArchiveDiff, "archive-diff")
+	g.WithBundlePath()
+	g.WithArchiveArgs()
+	g.WithOutputFormat()
+}
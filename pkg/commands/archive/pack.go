@@ -28,7 +28,15 @@ func NewPackCommand() *cobra.Command {
 func setupPack(cmd *cobra.Command) {
 	g := option.NewGenerator(cmd, sheaf.ArchivePack, "archive-pack")
 	g.WithBundlePath()
+	g.WithBundlePacker()
+	g.WithStagingConcurrency()
+	g.WithPlatforms()
+	g.WithSigningKey()
+	g.WithArchiveFormat()
+	g.WithSplitSize()
+	g.WithBase("base archive; blobs in it are left out, creating a delta archive that requires it")
+	g.WithRegistryAuth()
 	g.WithDestination()
 	g.WithForce()
-
+	g.WithLocked()
 }
@@ -32,5 +32,6 @@ func setupPush(cmd *cobra.Command) {
 	g.WithArchive()
 	g.WithReference()
 	g.WithInsecureRegistry()
+	g.WithRegistryAuth()
 
 }
@@ -29,8 +29,15 @@ func setupRelocate(cmd *cobra.Command) {
 	g := option.NewGenerator(cmd, sheaf.ArchiveRelocate, "archive-relocate")
 	g.WithBundlePath()
 	g.WithArchive()
+	g.WithBase("base archive of a delta archive")
 	g.WithInsecureRegistry()
+	g.WithRegistryAuth()
 	g.WithPrefix()
+	g.WithMappingStrategy()
+	g.WithMapping("write the relocation mapping to a file (JSON, or YAML with a .yaml extension)")
 	g.WithDryRun()
-
+	g.WithConcurrency()
+	g.WithRelocationOutput()
+	g.WithRelocationPlatform()
+	g.WithSignatureVerifier("public key (PEM); if set, images are relocated only if the archive has a valid signature")
 }
@@ -0,0 +1,39 @@
+/*
+ * Copyright 2020 Sheaf Authors
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package archive
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/bryanl/sheaf/pkg/option"
+	"github.com/bryanl/sheaf/pkg/sheaf"
+)
+
+// NewServeCommand creates a serve command.
+func NewServeCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "serve",
+		Short: "Serve images in archive as a read-only registry",
+		Long: `Serve images in an archive over the OCI distribution pull API until interrupted.
+
+Images can be pulled by their repository path or their fully qualified repository
+name. For example, if the archive contains nginx:1.17, it can be pulled from a server
+listening on localhost:5000 as localhost:5000/library/nginx:1.17 or
+localhost:5000/docker.io/library/nginx:1.17.`,
+		Args: cobra.NoArgs,
+	}
+
+	setupServe(cmd)
+	return cmd
+}
+
+func setupServe(cmd *cobra.Command) {
+	g := option.NewGenerator(cmd, sheaf.ArchiveServe, "archive-serve")
+	g.WithBundlePath()
+	g.WithArchive()
+	g.WithRegistryServer()
+}
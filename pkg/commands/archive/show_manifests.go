@@ -30,4 +30,6 @@ func setupShowManifest(cmd *cobra.Command) {
 	g.WithBundlePath()
 	g.WithArchive()
 	g.WithPrefix()
+	g.WithMappingStrategy()
+	g.WithMapping("relocation mapping file (JSON or YAML) to use instead of a prefix")
 }
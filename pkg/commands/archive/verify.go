@@ -0,0 +1,44 @@
+/*
+ * Copyright 2020 Sheaf Authors
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package archive
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/bryanl/sheaf/pkg/option"
+	"github.com/bryanl/sheaf/pkg/sheaf"
+)
+
+// NewVerifyCommand creates a verify command.
+func NewVerifyCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "verify",
+		Short: "Verify images and manifests in archive",
+		Long: `Verify that the images and manifests in an archive have not been corrupted or modified
+since it was packed.
+
+Every blob in the archive's image layout is checked against its digest, and every image
+in the bundle must be in the layout with all of its blobs. Manifests are checked against
+the checksums recorded in checksums.sha256 when the archive was packed. A pass or fail
+result is reported for each image and manifest, and the command fails if any check fails.
+
+If --key is set, the archive must also have a valid signature from the private key
+matching the public key.`,
+		Args: cobra.NoArgs,
+	}
+
+	setupVerify(cmd)
+	return cmd
+}
+
+func setupVerify(cmd *cobra.Command) {
+	g := option.NewGenerator(cmd, sheaf.ArchiveVerify, "archive-verify")
+	g.WithBundlePath()
+	g.WithArchive()
+	g.WithArchiveVerifier()
+	g.WithSignatureVerifier("public key (PEM) used to verify the archive signature")
+}
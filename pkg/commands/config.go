@@ -21,6 +21,12 @@ func NewConfigCommand() *cobra.Command {
 	}
 
 	cmd.AddCommand(
+		config.NewAddImageCommand(),
+		config.NewRemoveImageCommand(),
+		config.NewAddExclusionCommand(),
+		config.NewRemoveExclusionCommand(),
+		config.NewSetMappingStrategyCommand(),
+		config.NewCatalogCommand(),
 		config.NewSetUserDefinedImage(),
 		config.NewDeleteUserDefinedImage(),
 		config.NewGetCommand(),
@@ -0,0 +1,38 @@
+/*
+ * Copyright 2020 Sheaf Authors
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package config
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/bryanl/sheaf/pkg/option"
+	"github.com/bryanl/sheaf/pkg/sheaf"
+)
+
+// NewAddExclusionCommand creates a add exclusion command.
+func NewAddExclusionCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "add-exclusion",
+		Short: "Exclude images from bundle",
+		Long: `Exclude images from bundle.json. Excluded images are not packed or relocated,
+and their references are left unchanged when manifests are shown with a prefix.
+
+Images can be excluded by exact reference (--image), by repository glob
+(--repository), or by registry host (--registry). Repository globs are matched
+against fully qualified repository names, e.g. docker.io/library/*.`,
+		Args: cobra.NoArgs,
+	}
+
+	setupAddExclusion(cmd)
+	return cmd
+}
+
+func setupAddExclusion(cmd *cobra.Command) {
+	g := option.NewGenerator(cmd, sheaf.ConfigAddExclusion, "config-add-exclusion")
+	g.WithBundlePath()
+	g.WithImageExclusions()
+}
@@ -0,0 +1,33 @@
+/*
+ * Copyright 2020 Sheaf Authors
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package config
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/bryanl/sheaf/pkg/option"
+	"github.com/bryanl/sheaf/pkg/sheaf"
+)
+
+// NewAddImageCommand creates a add image command.
+func NewAddImageCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "add-image",
+		Short: "Add images to bundle",
+		Long:  `Add images to bundle.json. Images in bundle.json are packed and relocated with images found in manifests.`,
+		Args:  cobra.NoArgs,
+	}
+
+	setupAddImage(cmd)
+	return cmd
+}
+
+func setupAddImage(cmd *cobra.Command) {
+	g := option.NewGenerator(cmd, sheaf.ConfigAddImage, "config-add-image")
+	g.WithBundlePath()
+	g.WithImages()
+}
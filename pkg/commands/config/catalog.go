@@ -0,0 +1,73 @@
+/*
+ * Copyright 2020 Sheaf Authors
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package config
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/bryanl/sheaf/pkg/option"
+	"github.com/bryanl/sheaf/pkg/sheaf"
+)
+
+// NewCatalogCommand creates a catalog command.
+func NewCatalogCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "catalog",
+		Short: "Manage the built-in user defined image catalog",
+		Long: `sheaf ships a catalog of user defined images for popular custom resources. Every catalog
+entry is enabled by default. Disabled entries are recorded in the bundle configuration.`,
+		SilenceUsage: true,
+	}
+
+	cmd.AddCommand(
+		newCatalogListCommand(),
+		newCatalogEnableCommand(),
+		newCatalogDisableCommand())
+
+	return cmd
+}
+
+func newCatalogListCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List catalog entries",
+		Args:  cobra.NoArgs,
+	}
+
+	g := option.NewGenerator(cmd, sheaf.ConfigCatalogList, "config-catalog-list")
+	g.WithBundlePath()
+
+	return cmd
+}
+
+func newCatalogEnableCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "enable",
+		Short: "Enable catalog entries in bundle",
+		Args:  cobra.NoArgs,
+	}
+
+	g := option.NewGenerator(cmd, sheaf.ConfigCatalogEnable, "config-catalog-enable")
+	g.WithBundlePath()
+	g.WithCatalogEntryNames()
+
+	return cmd
+}
+
+func newCatalogDisableCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "disable",
+		Short: "Disable catalog entries in bundle",
+		Args:  cobra.NoArgs,
+	}
+
+	g := option.NewGenerator(cmd, sheaf.ConfigCatalogDisable, "config-catalog-disable")
+	g.WithBundlePath()
+	g.WithCatalogEntryNames()
+
+	return cmd
+}
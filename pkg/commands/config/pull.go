@@ -28,6 +28,7 @@ func NewPullCommand() *cobra.Command {
 func setupPull(cmd *cobra.Command) {
 	g := option.NewGenerator(cmd, sheaf.ConfigPull, "config-pull")
 	g.WithInsecureRegistry()
+	g.WithRegistryAuth()
 	g.WithReference()
 	g.WithDestination()
 }
@@ -28,6 +28,7 @@ func NewPushCommand() *cobra.Command {
 func setupPush(cmd *cobra.Command) {
 	g := option.NewGenerator(cmd, sheaf.ConfigPush, "config-push")
 	g.WithInsecureRegistry()
+	g.WithRegistryAuth()
 	g.WithReference()
 	g.WithBundlePath()
 }
@@ -0,0 +1,33 @@
+/*
+ * Copyright 2020 Sheaf Authors
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package config
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/bryanl/sheaf/pkg/option"
+	"github.com/bryanl/sheaf/pkg/sheaf"
+)
+
+// NewRemoveExclusionCommand creates a remove exclusion command.
+func NewRemoveExclusionCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "remove-exclusion",
+		Short: "Remove image exclusions from bundle",
+		Long:  `Remove image exclusions from bundle.json.`,
+		Args:  cobra.NoArgs,
+	}
+
+	setupRemoveExclusion(cmd)
+	return cmd
+}
+
+func setupRemoveExclusion(cmd *cobra.Command) {
+	g := option.NewGenerator(cmd, sheaf.ConfigRemoveExclusion, "config-remove-exclusion")
+	g.WithBundlePath()
+	g.WithImageExclusions()
+}
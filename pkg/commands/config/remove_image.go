@@ -0,0 +1,33 @@
+/*
+ * Copyright 2020 Sheaf Authors
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package config
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/bryanl/sheaf/pkg/option"
+	"github.com/bryanl/sheaf/pkg/sheaf"
+)
+
+// NewRemoveImageCommand creates a remove image command.
+func NewRemoveImageCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "remove-image",
+		Short: "Remove images from bundle",
+		Long:  `Remove images from bundle.json. Images found in manifests are not affected.`,
+		Args:  cobra.NoArgs,
+	}
+
+	setupRemoveImage(cmd)
+	return cmd
+}
+
+func setupRemoveImage(cmd *cobra.Command) {
+	g := option.NewGenerator(cmd, sheaf.ConfigRemoveImage, "config-remove-image")
+	g.WithBundlePath()
+	g.WithImages()
+}
@@ -0,0 +1,46 @@
+/*
+ * Copyright 2020 Sheaf Authors
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package config
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/bryanl/sheaf/pkg/option"
+	"github.com/bryanl/sheaf/pkg/sheaf"
+)
+
+// NewSetMappingStrategyCommand creates a set mapping strategy command.
+func NewSetMappingStrategyCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "set-mapping-strategy",
+		Short: "Set repository mapping strategy in bundle",
+		Long: `Set the strategy used to name repositories when images are relocated to a prefix.
+
+Strategies:
+
+  flatten-hash   prefix/<flattened path>-<hash> (default)
+  preserve-path  prefix/<registry host>/<repository path>
+  basename-only  prefix/<last element of repository path>
+  template       repository name created from --mapping-template
+
+Templates use Go template syntax and can reference {{.Prefix}}, {{.Host}},
+{{.Path}}, and {{.Basename}}. Tags and digests of the original image are
+always preserved.
+
+The strategy can be overridden with --mapping-strategy when relocating.`,
+		Args: cobra.NoArgs,
+	}
+
+	setupSetMappingStrategy(cmd)
+	return cmd
+}
+
+func setupSetMappingStrategy(cmd *cobra.Command) {
+	g := option.NewGenerator(cmd, sheaf.ConfigSetMappingStrategy, "config-set-mapping-strategy")
+	g.WithBundlePath()
+	g.WithMappingStrategy()
+}
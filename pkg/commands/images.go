@@ -0,0 +1,27 @@
+/*
+ * Copyright 2020 Sheaf Authors
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package commands
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/bryanl/sheaf/pkg/commands/images"
+)
+
+// NewImagesCommand creates an images command.
+func NewImagesCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:          "images",
+		Short:        "Perform operations on bundle images",
+		SilenceUsage: true,
+	}
+
+	cmd.AddCommand(
+		images.NewLockCommand())
+
+	return cmd
+}
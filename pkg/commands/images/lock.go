@@ -0,0 +1,35 @@
+/*
+ * Copyright 2020 Sheaf Authors
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package images
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/bryanl/sheaf/pkg/option"
+	"github.com/bryanl/sheaf/pkg/sheaf"
+)
+
+// NewLockCommand creates an "images lock" command.
+func NewLockCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "lock",
+		Short: "lock bundle images to digests",
+		Long: `Resolve every image in a bundle to a digest and write the results to bundle.lock.json.
+Packing and showing manifests use the locked digests.`,
+		Args: cobra.NoArgs,
+	}
+
+	setupLock(cmd)
+	return cmd
+}
+
+func setupLock(cmd *cobra.Command) {
+	g := option.NewGenerator(cmd, sheaf.ImagesLock, "images-lock")
+	g.WithBundlePath()
+	g.WithInsecureRegistry()
+	g.WithRegistryAuth()
+}
@@ -22,7 +22,8 @@ func NewManifestCommand() *cobra.Command {
 
 	cmd.AddCommand(
 		manifest.NewShowCommand(),
-		manifest.NewAddCommand())
+		manifest.NewAddCommand(),
+		manifest.NewRefreshCommand())
 
 	return cmd
 }
@@ -18,7 +18,16 @@ func NewAddCommand() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "add",
 		Short: "add manifest to bundle",
-		Args:  cobra.NoArgs,
+		Long: `Add manifests to a bundle from files, directories, or URLs.
+
+A local Helm chart directory or packaged chart can be added with --chart. The chart is rendered
+offline with "helm template" into the bundle manifests. The chart source and values are recorded
+in bundle.json so the chart can be rendered again.
+
+A local kustomization directory can be added with --kustomization. The kustomization is built
+into the bundle manifests and its path is recorded in bundle.json. Use "sheaf manifest refresh"
+to render recorded charts and kustomizations again.`,
+		Args: cobra.NoArgs,
 	}
 
 	setupAdd(cmd)
@@ -29,5 +38,7 @@ func setupAdd(cmd *cobra.Command) {
 	g := option.NewGenerator(cmd, sheaf.ManifestAdd, "manifest-add")
 	g.WithBundlePath()
 	g.WithFilePaths()
+	g.WithHelmChart()
+	g.WithKustomization()
 	g.WithForce()
 }
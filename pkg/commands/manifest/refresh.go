@@ -0,0 +1,33 @@
+/*
+ * Copyright 2020 Sheaf Authors
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package manifest
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/bryanl/sheaf/pkg/option"
+	"github.com/bryanl/sheaf/pkg/sheaf"
+)
+
+// NewRefreshCommand creates a "manifest refresh" command.
+func NewRefreshCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "refresh",
+		Short: "render manifest sources again",
+		Long: `Render the Helm charts and kustomizations recorded in bundle.json again. Rendered manifests
+are overwritten.`,
+		Args: cobra.NoArgs,
+	}
+
+	setupRefresh(cmd)
+	return cmd
+}
+
+func setupRefresh(cmd *cobra.Command) {
+	g := option.NewGenerator(cmd, sheaf.ManifestRefresh, "manifest-refresh")
+	g.WithBundlePath()
+}
@@ -31,4 +31,7 @@ func setupShow(cmd *cobra.Command) {
 	g := option.NewGenerator(cmd, sheaf.ManifestShow, "manifest-shwo")
 	g.WithBundlePath()
 	g.WithPrefix()
+	g.WithMappingStrategy()
+	g.WithMapping("relocation mapping file (JSON or YAML) to use instead of a prefix")
+	g.WithLocked()
 }
@@ -22,6 +22,7 @@ func NewRootCommand() *cobra.Command {
 		NewInitCommand(),
 		NewArchiveCommand(),
 		NewManifestCommand(),
+		NewImagesCommand(),
 		NewConfigCommand())
 
 	return cmd
@@ -0,0 +1,370 @@
+/*
+ * Copyright 2020 Sheaf Authors
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package fs
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+	"github.com/pivotal/image-relocation/pkg/image"
+
+	"github.com/bryanl/sheaf/pkg/sheaf"
+)
+
+// ArchiveVerifier verifies exploded archives on a filesystem.
+type ArchiveVerifier struct{}
+
+var _ sheaf.ArchiveVerifier = &ArchiveVerifier{}
+
+// NewArchiveVerifier creates an instance of ArchiveVerifier.
+func NewArchiveVerifier() *ArchiveVerifier {
+	return &ArchiveVerifier{}
+}
+
+// Verify verifies an exploded archive. Every blob in the image layout is
+// checked against its digest, every image in the bundle must be in the
+// layout index with all of its blobs, and every manifest file is checked
+// against the checksum recorded when the archive was packed. The layers a
+// delta archive takes from its base archive are not required; they are
+// checked against the base archive's digest when the delta is applied.
+func (v *ArchiveVerifier) Verify(b sheaf.Bundle) ([]sheaf.VerificationResult, error) {
+	root := layoutRootPath(b.Path())
+
+	blobs, err := verifyBlobs(root)
+	if err != nil {
+		return nil, fmt.Errorf("verify blobs: %w", err)
+	}
+
+	if err := addBaseBlobs(b.Path(), blobs); err != nil {
+		return nil, err
+	}
+
+	imageResults, referenced, err := verifyImages(b, root, blobs)
+	if err != nil {
+		return nil, err
+	}
+
+	// Corrupt blobs that no image references are reported with the layout.
+	layoutResult := sheaf.VerificationResult{
+		Kind: sheaf.LayoutVerification,
+		Name: "artifacts/layout",
+	}
+	for _, h := range sortedHashes(blobs) {
+		if problem := blobs[h]; problem != "" && !referenced[h] {
+			layoutResult.Problems = append(layoutResult.Problems, problem)
+		}
+	}
+
+	manifestResults, err := verifyManifests(b.Path())
+	if err != nil {
+		return nil, err
+	}
+
+	results := append([]sheaf.VerificationResult{layoutResult}, imageResults...)
+	return append(results, manifestResults...), nil
+}
+
+// verifyBlobs checks the blobs in a layout against their digests. It returns
+// the blobs found with a description of the problem for blobs that don't
+// match their digest.
+func verifyBlobs(root string) (map[v1.Hash]string, error) {
+	blobs := map[v1.Hash]string{}
+
+	blobsPath := filepath.Join(root, "blobs")
+	err := filepath.Walk(blobsPath, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) && path == blobsPath {
+				return nil
+			}
+			return err
+		}
+
+		if info.IsDir() {
+			return nil
+		}
+
+		rel, err := filepath.Rel(blobsPath, path)
+		if err != nil {
+			return err
+		}
+
+		h, err := v1.NewHash(filepath.Dir(rel) + ":" + filepath.Base(rel))
+		if err != nil {
+			return fmt.Errorf("blob %s has an invalid name: %w", rel, err)
+		}
+
+		if h.Algorithm != "sha256" {
+			blobs[h] = fmt.Sprintf("blob %s uses an unsupported digest algorithm", h)
+			return nil
+		}
+
+		sum, err := fileChecksum(path)
+		if err != nil {
+			return err
+		}
+
+		if sum != h.Hex {
+			blobs[h] = fmt.Sprintf("blob %s does not match its digest (sha256:%s)", h, sum)
+		} else {
+			blobs[h] = ""
+		}
+
+		return nil
+	})
+
+	return blobs, err
+}
+
+// addBaseBlobs adds the layers a delta archive takes from its base archive
+// to the verified blobs if they are not in the archive.
+func addBaseBlobs(root string, blobs map[v1.Hash]string) error {
+	delta, err := readDelta(root)
+	if err != nil || delta == nil {
+		return err
+	}
+
+	for _, digest := range delta.Blobs {
+		h, err := v1.NewHash(digest)
+		if err != nil {
+			return fmt.Errorf("delta blob %q is invalid: %w", digest, err)
+		}
+
+		if _, ok := blobs[h]; !ok {
+			blobs[h] = ""
+		}
+	}
+
+	return nil
+}
+
+// verifyImages checks that every image in a bundle is in the layout index
+// and that its blobs are present and intact. It returns the blobs referenced
+// by the images.
+func verifyImages(b sheaf.Bundle, root string, blobs map[v1.Hash]string) ([]sheaf.VerificationResult, map[v1.Hash]bool, error) {
+	imageList, err := b.Images()
+	if err != nil {
+		return nil, nil, fmt.Errorf("get images from bundle: %w", err)
+	}
+
+	// Excluded images are not packed, and locked images are packed with
+	// their locked digests.
+	exclusions := sheaf.ImageExclusions(b.Config().GetImageExclusions())
+	imageList, _, err = exclusions.Filter(imageList)
+	if err != nil {
+		return nil, nil, fmt.Errorf("exclude images: %w", err)
+	}
+
+	lock, err := b.Lock()
+	if err != nil {
+		return nil, nil, fmt.Errorf("load bundle lock: %w", err)
+	}
+
+	imageList, err = lock.PinSet(imageList)
+	if err != nil {
+		return nil, nil, fmt.Errorf("pin images: %w", err)
+	}
+
+	descriptors, indexErr := layoutDescriptors(root)
+
+	referenced := map[v1.Hash]bool{}
+	var results []sheaf.VerificationResult
+	for _, n := range imageList.Slice() {
+		result := sheaf.VerificationResult{
+			Kind: sheaf.ImageVerification,
+			Name: n.String(),
+		}
+
+		if indexErr != nil {
+			result.Problems = append(result.Problems, fmt.Sprintf("read layout index: %v", indexErr))
+			results = append(results, result)
+			continue
+		}
+
+		desc, ok := findDescriptor(descriptors, n)
+		if !ok {
+			result.Problems = append(result.Problems, "image is not in the layout index")
+			results = append(results, result)
+			continue
+		}
+
+		result.Problems = verifyDescriptor(root, desc, blobs, referenced)
+		results = append(results, result)
+	}
+
+	return results, referenced, nil
+}
+
+// layoutDescriptors returns the descriptors in a layout index. The index is
+// read directly so that a corrupt layout can still be reported.
+func layoutDescriptors(root string) ([]v1.Descriptor, error) {
+	data, err := ioutil.ReadFile(filepath.Join(root, "index.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	var index v1.IndexManifest
+	if err := json.Unmarshal(data, &index); err != nil {
+		return nil, err
+	}
+
+	return index.Manifests, nil
+}
+
+// findDescriptor returns the descriptor in a layout index for an image name.
+func findDescriptor(descriptors []v1.Descriptor, n image.Name) (v1.Descriptor, bool) {
+	for _, desc := range descriptors {
+		if found, ok := descriptorName(desc); ok && found == n {
+			return desc, true
+		}
+	}
+
+	return v1.Descriptor{}, false
+}
+
+// verifyDescriptor checks that the blobs of a manifest or index, and of the
+// manifests it references, are present and intact.
+func verifyDescriptor(root string, desc v1.Descriptor, blobs map[v1.Hash]string, referenced map[v1.Hash]bool) []string {
+	problems := verifyBlob(desc.Digest, blobs, referenced)
+	if len(problems) > 0 {
+		return problems
+	}
+
+	data, err := ioutil.ReadFile(filepath.Join(root, "blobs", desc.Digest.Algorithm, desc.Digest.Hex))
+	if err != nil {
+		return []string{err.Error()}
+	}
+
+	switch desc.MediaType {
+	case types.OCIImageIndex, types.DockerManifestList:
+		var index v1.IndexManifest
+		if err := json.Unmarshal(data, &index); err != nil {
+			return []string{fmt.Sprintf("decode index %s: %v", desc.Digest, err)}
+		}
+
+		for _, child := range index.Manifests {
+			problems = append(problems, verifyDescriptor(root, child, blobs, referenced)...)
+		}
+	default:
+		var manifest v1.Manifest
+		if err := json.Unmarshal(data, &manifest); err != nil {
+			return []string{fmt.Sprintf("decode manifest %s: %v", desc.Digest, err)}
+		}
+
+		problems = append(problems, verifyBlob(manifest.Config.Digest, blobs, referenced)...)
+		for _, layer := range manifest.Layers {
+			// Foreign layers are not distributed with images.
+			if layer.MediaType == types.DockerForeignLayer {
+				continue
+			}
+
+			problems = append(problems, verifyBlob(layer.Digest, blobs, referenced)...)
+		}
+	}
+
+	return problems
+}
+
+// verifyBlob checks that a blob is present and intact.
+func verifyBlob(h v1.Hash, blobs map[v1.Hash]string, referenced map[v1.Hash]bool) []string {
+	referenced[h] = true
+
+	problem, ok := blobs[h]
+	if !ok {
+		return []string{fmt.Sprintf("blob %s is missing", h)}
+	}
+
+	if problem != "" {
+		return []string{problem}
+	}
+
+	return nil
+}
+
+// verifyManifests checks the manifest files in an archive against the
+// checksums recorded when it was packed.
+func verifyManifests(root string) ([]sheaf.VerificationResult, error) {
+	files, err := manifestFiles(root)
+	if err != nil {
+		return nil, fmt.Errorf("list manifests: %w", err)
+	}
+
+	checksums, err := readManifestChecksums(root)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("read manifest checksums: %w", err)
+	}
+	noChecksums := err != nil
+
+	found := map[string]bool{}
+	var results []sheaf.VerificationResult
+	for _, file := range files {
+		found[file] = true
+
+		result := sheaf.VerificationResult{
+			Kind: sheaf.ManifestVerification,
+			Name: file,
+		}
+
+		want, ok := checksums[file]
+		switch {
+		case noChecksums:
+			result.Problems = append(result.Problems,
+				fmt.Sprintf("archive has no %s; it was packed without manifest checksums", ManifestChecksumsFile))
+		case !ok:
+			result.Problems = append(result.Problems, "no checksum was recorded when the archive was packed")
+		default:
+			got, err := fileChecksum(filepath.Join(root, filepath.FromSlash(file)))
+			if err != nil {
+				return nil, err
+			}
+
+			if got != want {
+				result.Problems = append(result.Problems,
+					fmt.Sprintf("checksum %s does not match the recorded checksum %s", got, want))
+			}
+		}
+
+		results = append(results, result)
+	}
+
+	var missing []string
+	for file := range checksums {
+		if !found[file] {
+			missing = append(missing, file)
+		}
+	}
+	sort.Strings(missing)
+
+	for _, file := range missing {
+		results = append(results, sheaf.VerificationResult{
+			Kind:     sheaf.ManifestVerification,
+			Name:     file,
+			Problems: []string{"manifest is missing"},
+		})
+	}
+
+	return results, nil
+}
+
+// sortedHashes returns the hashes of blobs in a stable order.
+func sortedHashes(blobs map[v1.Hash]string) []v1.Hash {
+	var list []v1.Hash
+	for h := range blobs {
+		list = append(list, h)
+	}
+
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].String() < list[j].String()
+	})
+
+	return list
+}
@@ -0,0 +1,231 @@
+/*
+ * Copyright 2020 Sheaf Authors
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package fs
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/layout"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/pivotal/image-relocation/pkg/images"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bryanl/sheaf/pkg/mocks"
+	"github.com/bryanl/sheaf/pkg/sheaf"
+)
+
+func TestArchiveVerifier_Verify(t *testing.T) {
+	const (
+		nginx    = "docker.io/library/nginx:1.17"
+		app      = "gcr.io/project/app:v1"
+		manifest = "app/manifests/deploy.yaml"
+
+		// corruptSum is the checksum of "corrupt".
+		corruptSum = "11d510e067d2cdcd7559bd86d27a2f4c20babd43670346b97af99b522c1f0075"
+		// manifestSum is the checksum of the packed manifest.
+		manifestSum = "2e15259aa2d978f7affbf2000945c972ebf0beed0e2dcb7b0c490ee33871f120"
+	)
+
+	blobPath := func(root string, h v1.Hash) string {
+		return filepath.Join(layoutRootPath(root), "blobs", h.Algorithm, h.Hex)
+	}
+
+	tests := []struct {
+		name   string
+		images []string
+		mutate func(t *testing.T, root string, layer v1.Hash)
+		wanted func(layer v1.Hash) map[string][]string
+	}{
+		{
+			name:   "in general",
+			images: []string{nginx, app},
+			wanted: func(v1.Hash) map[string][]string {
+				return map[string][]string{
+					"layout artifacts/layout": nil,
+					"image " + nginx:          nil,
+					"image " + app:            nil,
+					"manifest " + manifest:    nil,
+				}
+			},
+		},
+		{
+			name:   "corrupt image blob",
+			images: []string{nginx, app},
+			mutate: func(t *testing.T, root string, layer v1.Hash) {
+				require.NoError(t, ioutil.WriteFile(blobPath(root, layer), []byte("corrupt"), 0600))
+			},
+			wanted: func(layer v1.Hash) map[string][]string {
+				return map[string][]string{
+					"layout artifacts/layout": nil,
+					"image " + nginx:          {"blob " + layer.String() + " does not match its digest (sha256:" + corruptSum + ")"},
+					"image " + app:            nil,
+					"manifest " + manifest:    nil,
+				}
+			},
+		},
+		{
+			name:   "missing image blob",
+			images: []string{nginx, app},
+			mutate: func(t *testing.T, root string, layer v1.Hash) {
+				require.NoError(t, os.Remove(blobPath(root, layer)))
+			},
+			wanted: func(layer v1.Hash) map[string][]string {
+				return map[string][]string{
+					"layout artifacts/layout": nil,
+					"image " + nginx:          {"blob " + layer.String() + " is missing"},
+					"image " + app:            nil,
+					"manifest " + manifest:    nil,
+				}
+			},
+		},
+		{
+			name:   "corrupt blob not in bundle images",
+			images: []string{app},
+			mutate: func(t *testing.T, root string, layer v1.Hash) {
+				require.NoError(t, ioutil.WriteFile(blobPath(root, layer), []byte("corrupt"), 0600))
+			},
+			wanted: func(layer v1.Hash) map[string][]string {
+				return map[string][]string{
+					"layout artifacts/layout": {"blob " + layer.String() + " does not match its digest (sha256:" + corruptSum + ")"},
+					"image " + app:            nil,
+					"manifest " + manifest:    nil,
+				}
+			},
+		},
+		{
+			name:   "image not in layout",
+			images: []string{nginx, app, "docker.io/library/redis:5"},
+			wanted: func(v1.Hash) map[string][]string {
+				return map[string][]string{
+					"layout artifacts/layout":         nil,
+					"image " + nginx:                  nil,
+					"image " + app:                    nil,
+					"image docker.io/library/redis:5": {"image is not in the layout index"},
+					"manifest " + manifest:            nil,
+				}
+			},
+		},
+		{
+			name:   "modified manifest",
+			images: []string{nginx, app},
+			mutate: func(t *testing.T, root string, _ v1.Hash) {
+				require.NoError(t, ioutil.WriteFile(filepath.Join(root, manifest), []byte("corrupt"), 0600))
+			},
+			wanted: func(v1.Hash) map[string][]string {
+				return map[string][]string{
+					"layout artifacts/layout": nil,
+					"image " + nginx:          nil,
+					"image " + app:            nil,
+					"manifest " + manifest:    {"checksum " + corruptSum + " does not match the recorded checksum " + manifestSum},
+				}
+			},
+		},
+		{
+			name:   "added and removed manifests",
+			images: []string{nginx, app},
+			mutate: func(t *testing.T, root string, _ v1.Hash) {
+				require.NoError(t, os.Remove(filepath.Join(root, manifest)))
+				require.NoError(t, ioutil.WriteFile(filepath.Join(manifestsPath(root), "added.yaml"), []byte("added"), 0600))
+			},
+			wanted: func(v1.Hash) map[string][]string {
+				return map[string][]string{
+					"layout artifacts/layout":           nil,
+					"image " + nginx:                    nil,
+					"image " + app:                      nil,
+					"manifest app/manifests/added.yaml": {"no checksum was recorded when the archive was packed"},
+					"manifest " + manifest:              {"manifest is missing"},
+				}
+			},
+		},
+		{
+			name:   "no manifest checksums",
+			images: []string{nginx, app},
+			mutate: func(t *testing.T, root string, _ v1.Hash) {
+				require.NoError(t, os.Remove(filepath.Join(root, ManifestChecksumsFile)))
+			},
+			wanted: func(v1.Hash) map[string][]string {
+				return map[string][]string{
+					"layout artifacts/layout": nil,
+					"image " + nginx:          nil,
+					"image " + app:            nil,
+					"manifest " + manifest:    {"archive has no checksums.sha256; it was packed without manifest checksums"},
+				}
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			root, err := ioutil.TempDir("", "sheaf-test")
+			require.NoError(t, err)
+
+			defer func() {
+				require.NoError(t, os.RemoveAll(root))
+			}()
+
+			p, err := layout.Write(layoutRootPath(root), empty.Index)
+			require.NoError(t, err)
+
+			img, err := random.Image(256, 2)
+			require.NoError(t, err)
+			require.NoError(t, p.AppendImage(img, layout.WithAnnotations(map[string]string{
+				refNameAnnotation: nginx,
+			})))
+
+			idx, err := random.Index(256, 1, 2)
+			require.NoError(t, err)
+			require.NoError(t, p.AppendIndex(idx, layout.WithAnnotations(map[string]string{
+				refNameAnnotation: app,
+			})))
+
+			require.NoError(t, os.MkdirAll(manifestsPath(root), 0700))
+			require.NoError(t, ioutil.WriteFile(filepath.Join(root, manifest), []byte("kind: Deployment\n"), 0600))
+			require.NoError(t, writeManifestChecksums(root))
+
+			layers, err := img.Layers()
+			require.NoError(t, err)
+			layer, err := layers[0].Digest()
+			require.NoError(t, err)
+
+			if test.mutate != nil {
+				test.mutate(t, root, layer)
+			}
+
+			controller := gomock.NewController(t)
+			defer controller.Finish()
+
+			imageList, err := images.New(test.images...)
+			require.NoError(t, err)
+
+			config := mocks.NewMockBundleConfig(controller)
+			config.EXPECT().GetImageExclusions().Return(nil)
+
+			b := mocks.NewMockBundle(controller)
+			b.EXPECT().Path().Return(root).AnyTimes()
+			b.EXPECT().Images().Return(imageList, nil)
+			b.EXPECT().Config().Return(config)
+			b.EXPECT().Lock().Return(sheaf.BundleLock{}, nil)
+
+			v := NewArchiveVerifier()
+
+			results, err := v.Verify(b)
+			require.NoError(t, err)
+
+			got := map[string][]string{}
+			for _, result := range results {
+				got[result.Kind+" "+result.Name] = result.Problems
+			}
+
+			require.Equal(t, test.wanted(layer), got)
+		})
+	}
+}
@@ -10,6 +10,8 @@ import (
 	"io/ioutil"
 	"path/filepath"
 
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+
 	"github.com/bryanl/sheaf/pkg/sheaf"
 )
 
@@ -37,6 +39,16 @@ func (s *ArtifactsService) Index() ([]byte, error) {
 	return ioutil.ReadFile(index)
 }
 
+// Blob returns the contents of a blob in the artifact layout.
+func (s *ArtifactsService) Blob(digest string) ([]byte, error) {
+	h, err := v1.NewHash(digest)
+	if err != nil {
+		return nil, err
+	}
+
+	return ioutil.ReadFile(filepath.Join(layoutRootPath(s.bundle.Path()), "blobs", h.Algorithm, h.Hex))
+}
+
 // Image is an image service for interacting with images in the artifacts.
 func (s *ArtifactsService) Image() sheaf.ImageService {
 	return NewImageService(s)
@@ -0,0 +1,94 @@
+/*
+ * Copyright 2020 Sheaf Authors
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package fs
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"sync"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/v1/remote/transport"
+)
+
+// repositoryPathRE matches the repository in a distribution API request path.
+var repositoryPathRE = regexp.MustCompile(`^/v2/(.+?)/(manifests|blobs|tags)/`)
+
+// authTransport authenticates registry requests with a keychain. The layout
+// registry client only authenticates with the default docker keychain, so
+// repository requests are sent through a transport that is authenticated for
+// the repository instead. Other requests, e.g. pings and token requests, are
+// sent unchanged.
+type authTransport struct {
+	keychain authn.Keychain
+	inner    http.RoundTripper
+
+	mu         sync.Mutex
+	transports map[string]http.RoundTripper
+}
+
+var _ http.RoundTripper = &authTransport{}
+
+func newAuthTransport(keychain authn.Keychain, inner http.RoundTripper) *authTransport {
+	return &authTransport{
+		keychain:   keychain,
+		inner:      inner,
+		transports: map[string]http.RoundTripper{},
+	}
+}
+
+// RoundTrip sends a request.
+func (t *authTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	match := repositoryPathRE.FindStringSubmatch(r.URL.Path)
+	if match == nil {
+		return t.inner.RoundTrip(r)
+	}
+
+	rt, err := t.repositoryTransport(r.URL.Scheme, r.URL.Host, match[1])
+	if err != nil {
+		return nil, fmt.Errorf("authenticate with %s: %w", r.URL.Host, err)
+	}
+
+	return rt.RoundTrip(r)
+}
+
+// repositoryTransport returns a transport authenticated to push and pull a
+// repository.
+func (t *authTransport) repositoryTransport(scheme, host, repository string) (http.RoundTripper, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	key := host + "/" + repository
+	if rt, ok := t.transports[key]; ok {
+		return rt, nil
+	}
+
+	var nameOptions []name.Option
+	if scheme == "http" {
+		nameOptions = append(nameOptions, name.Insecure)
+	}
+
+	repo, err := name.NewRepository(key, nameOptions...)
+	if err != nil {
+		return nil, err
+	}
+
+	auth, err := t.keychain.Resolve(repo.Registry)
+	if err != nil {
+		return nil, err
+	}
+
+	rt, err := transport.New(repo.Registry, auth, t.inner, []string{repo.Scope(transport.PushScope)})
+	if err != nil {
+		return nil, err
+	}
+
+	t.transports[key] = rt
+	return rt, nil
+}
@@ -0,0 +1,92 @@
+/*
+ * Copyright 2020 Sheaf Authors
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package fs
+
+import (
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/registry"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/pivotal/image-relocation/pkg/image"
+	"github.com/stretchr/testify/require"
+
+	sheafremote "github.com/bryanl/sheaf/pkg/remote"
+)
+
+func TestDefaultLayoutFactoryKeychain(t *testing.T) {
+	handler := registry.New(registry.Logger(log.New(ioutil.Discard, "", 0)))
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, password, ok := r.BasicAuth(); !ok || user != "user" || password != "password" {
+			w.Header().Set("WWW-Authenticate", `Basic realm="sheaf"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		handler.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+
+	u, err := url.Parse(server.URL)
+	require.NoError(t, err)
+
+	dir, err := ioutil.TempDir("", "sheaf-test")
+	require.NoError(t, err)
+
+	defer func() {
+		require.NoError(t, os.RemoveAll(dir))
+	}()
+
+	// Keep the default keychain from reading the user's docker config.
+	defer os.Setenv("DOCKER_CONFIG", os.Getenv("DOCKER_CONFIG"))
+	require.NoError(t, os.Setenv("DOCKER_CONFIG", dir))
+
+	img, err := random.Image(256, 1)
+	require.NoError(t, err)
+
+	ref, err := name.ParseReference(fmt.Sprintf("%s/app:1.0", u.Host))
+	require.NoError(t, err)
+	require.NoError(t, remote.Write(ref, img, remote.WithAuth(&authn.Basic{Username: "user", Password: "password"})))
+
+	n, err := image.NewName(ref.String())
+	require.NoError(t, err)
+
+	// Without credentials, the image can't be pulled.
+	l, err := DefaultLayoutFactory()(filepath.Join(dir, "default"))
+	require.NoError(t, err)
+	_, err = l.Add(n)
+	require.Error(t, err)
+
+	keychain := sheafremote.NewKeychain(sheafremote.KeychainCredentials(u.Host, sheafremote.Credentials{
+		Username: "user",
+		Password: "password",
+	}))
+
+	l, err = DefaultLayoutFactory(DefaultLayoutFactoryKeychain(keychain))(filepath.Join(dir, "keychain"))
+	require.NoError(t, err)
+
+	digest, err := l.Add(n)
+	require.NoError(t, err)
+
+	want, err := img.Digest()
+	require.NoError(t, err)
+	require.Equal(t, want.String(), digest.String())
+
+	pushed, err := image.NewName(fmt.Sprintf("%s/relocated/app:1.0", u.Host))
+	require.NoError(t, err)
+	require.NoError(t, l.Push(digest, pushed))
+}
@@ -185,6 +185,7 @@ func genManifestDir(rootPath string) string {
 // Images returns images in the fs.
 func (b *Bundle) Images() (images.Set, error) {
 	config := b.Config()
+	exclusions := sheaf.ImageExclusions(config.GetImageExclusions())
 
 	m, err := b.Manifests()
 	if err != nil {
@@ -196,13 +197,21 @@ func (b *Bundle) Images() (images.Set, error) {
 	}
 
 	seen := images.Empty
+	excluded := images.Empty
 	for _, bundleManifest := range bundleManifests {
 
-		list, err := manifest.ContainerImages(bundleManifest.ID, config.GetUserDefinedImages())
+		list, err := manifest.ContainerImages(bundleManifest.ID, config.GetUserDefinedImages(),
+			manifest.WithCatalog(sheaf.CatalogUserDefinedImages(config.GetCatalog())))
 		if err != nil {
 			return images.Empty, fmt.Errorf("find container images for %s: %w", bundleManifest, err)
 		}
 
+		list, excludedList, err := exclusions.Filter(list)
+		if err != nil {
+			return images.Empty, fmt.Errorf("exclude images for %s: %w", bundleManifest, err)
+		}
+		excluded = excluded.Union(excludedList)
+
 		names := list.Strings()
 		if len(names) < 1 {
 			continue
@@ -214,6 +223,25 @@ func (b *Bundle) Images() (images.Set, error) {
 		seen = seen.Union(list)
 	}
 
+	if names := config.GetImages(); len(names) > 0 {
+		list, err := images.New(names...)
+		if err != nil {
+			return images.Empty, fmt.Errorf("parse images in bundle config: %w", err)
+		}
+
+		list, excludedList, err := exclusions.Filter(list)
+		if err != nil {
+			return images.Empty, fmt.Errorf("exclude images in bundle config: %w", err)
+		}
+		excluded = excluded.Union(excludedList)
+
+		printImageTree(sheaf.BundleConfigFilename, list.Strings(), b.reporter)
+
+		seen = seen.Union(list)
+	}
+
+	printImageTree("excluded", excluded.Strings(), b.reporter)
+
 	return seen, nil
 }
 
@@ -225,6 +253,13 @@ func (b *Bundle) Copy(dest string) (sheaf.Bundle, error) {
 		return nil, fmt.Errorf("copy bundle config to %s: %w", dest, err)
 	}
 
+	lockPath := filepath.Join(b.Path(), sheaf.BundleLockFilename)
+	if _, err := os.Stat(lockPath); err == nil {
+		if err := filecopy.Copy(filepath.Join(dest, sheaf.BundleLockFilename), lockPath); err != nil {
+			return nil, fmt.Errorf("copy bundle lock to %s: %w", dest, err)
+		}
+	}
+
 	if err := filecopy.Copy(
 		filepath.Join(dest, "app", "manifests"),
 		filepath.Join(b.Path(), "app", "manifests")); err != nil {
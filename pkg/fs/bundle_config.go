@@ -32,7 +32,18 @@ func (b BundleConfigCodec) Encode(w io.Writer, bc sheaf.BundleConfig) error {
 		SchemaVersion:     bc.GetSchemaVersion(),
 		Name:              bc.GetName(),
 		Version:           bc.GetVersion(),
+		Images:            bc.GetImages(),
+		ImageExclusions:   bc.GetImageExclusions(),
 		UserDefinedImages: bc.GetUserDefinedImages(),
+		ManifestSources:   bc.GetManifestSources(),
+	}
+
+	if catalog := bc.GetCatalog(); catalog.Version != "" || len(catalog.Disabled) > 0 {
+		bcf.Catalog = &catalog
+	}
+
+	if strategy := bc.GetMappingStrategy(); !strategy.IsZero() {
+		bcf.MappingStrategy = &strategy
 	}
 
 	e := json.NewEncoder(w)
@@ -51,7 +62,18 @@ func (b BundleConfigCodec) Decode(r io.Reader) (sheaf.BundleConfig, error) {
 		schemaVersion:     bcf.SchemaVersion,
 		name:              bcf.Name,
 		version:           bcf.Version,
+		images:            bcf.Images,
+		imageExclusions:   bcf.ImageExclusions,
 		userDefinedImages: bcf.UserDefinedImages,
+		manifestSources:   bcf.ManifestSources,
+	}
+
+	if bcf.Catalog != nil {
+		bc.catalog = *bcf.Catalog
+	}
+
+	if bcf.MappingStrategy != nil {
+		bc.mappingStrategy = *bcf.MappingStrategy
 	}
 
 	return &bc, nil
@@ -64,8 +86,18 @@ type bundleConfigFormat struct {
 	Name string `json:"name"`
 	// Version is the version of the fs.
 	Version string `json:"version"`
+	// Images is a list of images included in addition to images found in manifests.
+	Images []string `json:"images,omitempty"`
+	// ImageExclusions is a list of images excluded from the bundle.
+	ImageExclusions []sheaf.ImageExclusion `json:"excludes,omitempty"`
 	// UserDefinedImages is a list of user defined image locations.
 	UserDefinedImages []sheaf.UserDefinedImage `json:"userDefinedImages,omitempty"`
+	// Catalog is the user defined image catalog selection.
+	Catalog *sheaf.CatalogSelection `json:"catalog,omitempty"`
+	// ManifestSources is a list of sources rendered into manifests.
+	ManifestSources []sheaf.ManifestSource `json:"manifestSources,omitempty"`
+	// MappingStrategy is the strategy used to name relocated images.
+	MappingStrategy *sheaf.MappingStrategy `json:"mappingStrategy,omitempty"`
 }
 
 // BundleConfig is a bundle configuration.
@@ -76,8 +108,18 @@ type BundleConfig struct {
 	name string
 	// Version is the version of the fs.
 	version string
+	// Images is a list of images included in addition to images found in manifests.
+	images []string
+	// ImageExclusions is a list of images excluded from the bundle.
+	imageExclusions []sheaf.ImageExclusion
 	// UserDefinedImages is a list of user defined image locations.
 	userDefinedImages []sheaf.UserDefinedImage
+	// Catalog is the user defined image catalog selection.
+	catalog sheaf.CatalogSelection
+	// ManifestSources is a list of sources rendered into manifests.
+	manifestSources []sheaf.ManifestSource
+	// MappingStrategy is the strategy used to name relocated images.
+	mappingStrategy sheaf.MappingStrategy
 }
 
 var _ sheaf.BundleConfig = &BundleConfig{}
@@ -112,6 +154,26 @@ func (b *BundleConfig) SetVersion(version string) {
 	b.version = version
 }
 
+// GetImages returns the bundle config's images.
+func (b BundleConfig) GetImages() []string {
+	return b.images
+}
+
+// SetImages sets the bundle config's images.
+func (b *BundleConfig) SetImages(images []string) {
+	b.images = images
+}
+
+// GetImageExclusions returns the bundle config's image exclusions.
+func (b BundleConfig) GetImageExclusions() []sheaf.ImageExclusion {
+	return b.imageExclusions
+}
+
+// SetImageExclusions sets the bundle config's image exclusions.
+func (b *BundleConfig) SetImageExclusions(imageExclusions []sheaf.ImageExclusion) {
+	b.imageExclusions = imageExclusions
+}
+
 // GetUserDefinedImages returns the bundle config's user defined images.
 func (b BundleConfig) GetUserDefinedImages() []sheaf.UserDefinedImage {
 	return b.userDefinedImages
@@ -122,6 +184,36 @@ func (b *BundleConfig) SetUserDefinedImages(userDefinedImages []sheaf.UserDefine
 	b.userDefinedImages = userDefinedImages
 }
 
+// GetCatalog returns the bundle config's catalog selection.
+func (b BundleConfig) GetCatalog() sheaf.CatalogSelection {
+	return b.catalog
+}
+
+// SetCatalog sets the bundle config's catalog selection.
+func (b *BundleConfig) SetCatalog(catalog sheaf.CatalogSelection) {
+	b.catalog = catalog
+}
+
+// GetManifestSources returns the bundle config's manifest sources.
+func (b BundleConfig) GetManifestSources() []sheaf.ManifestSource {
+	return b.manifestSources
+}
+
+// SetManifestSources sets the bundle config's manifest sources.
+func (b *BundleConfig) SetManifestSources(manifestSources []sheaf.ManifestSource) {
+	b.manifestSources = manifestSources
+}
+
+// GetMappingStrategy returns the bundle config's mapping strategy.
+func (b BundleConfig) GetMappingStrategy() sheaf.MappingStrategy {
+	return b.mappingStrategy
+}
+
+// SetMappingStrategy sets the bundle config's mapping strategy.
+func (b *BundleConfig) SetMappingStrategy(mappingStrategy sheaf.MappingStrategy) {
+	b.mappingStrategy = mappingStrategy
+}
+
 // NewBundleConfig creates a BundleConfig.
 func NewBundleConfig(name, version string) *BundleConfig {
 	if version == "" {
@@ -60,5 +60,5 @@ func (b BundleConfigWriter) Write(bundle sheaf.Bundle, config sheaf.BundleConfig
 }
 
 func openFile(filename string) (io.WriteCloser, error) {
-	return os.OpenFile(filename, os.O_WRONLY|os.O_CREATE, 0644)
+	return os.OpenFile(filename, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
 }
@@ -37,6 +37,10 @@ func TestBundleConfigWriter_Write(t *testing.T) {
 				config.EXPECT().GetSchemaVersion().Return("v1alpha1")
 				config.EXPECT().GetName().Return("test")
 				config.EXPECT().GetVersion().Return("0.1.0")
+				config.EXPECT().GetImages().Return([]string{"nginx:1.17"})
+				config.EXPECT().GetImageExclusions().Return([]sheaf.ImageExclusion{
+					{Registry: "registry.example.com"},
+				})
 				config.EXPECT().GetUserDefinedImages().Return([]sheaf.UserDefinedImage{
 					{
 						APIVersion: "v1",
@@ -44,6 +48,9 @@ func TestBundleConfigWriter_Write(t *testing.T) {
 						JSONPath:   ".",
 					},
 				})
+				config.EXPECT().GetCatalog().Return(sheaf.CatalogSelection{})
+				config.EXPECT().GetManifestSources().Return(nil)
+				config.EXPECT().GetMappingStrategy().Return(sheaf.MappingStrategy{Name: sheaf.PreservePathMappingStrategy})
 
 				return config
 			},
@@ -56,6 +63,8 @@ func TestBundleConfigWriter_Write(t *testing.T) {
 				config.EXPECT().GetSchemaVersion().Return("v1alpha1")
 				config.EXPECT().GetName().Return("test")
 				config.EXPECT().GetVersion().Return("0.1.0")
+				config.EXPECT().GetImages().Return(nil)
+				config.EXPECT().GetImageExclusions().Return(nil)
 				config.EXPECT().GetUserDefinedImages().Return([]sheaf.UserDefinedImage{
 					{
 						APIVersion: "v1",
@@ -63,6 +72,9 @@ func TestBundleConfigWriter_Write(t *testing.T) {
 						JSONPath:   ".",
 					},
 				})
+				config.EXPECT().GetCatalog().Return(sheaf.CatalogSelection{})
+				config.EXPECT().GetManifestSources().Return(nil)
+				config.EXPECT().GetMappingStrategy().Return(sheaf.MappingStrategy{})
 
 				return config
 			},
@@ -75,12 +87,38 @@ func TestBundleConfigWriter_Write(t *testing.T) {
 				config.EXPECT().GetSchemaVersion().Return("v1alpha1")
 				config.EXPECT().GetName().Return("test")
 				config.EXPECT().GetVersion().Return("0.1.0")
+				config.EXPECT().GetImages().Return(nil)
+				config.EXPECT().GetImageExclusions().Return(nil)
 				config.EXPECT().GetUserDefinedImages().Return(nil)
+				config.EXPECT().GetCatalog().Return(sheaf.CatalogSelection{})
+				config.EXPECT().GetManifestSources().Return(nil)
+				config.EXPECT().GetMappingStrategy().Return(sheaf.MappingStrategy{})
 
 				return config
 			},
 			expected: testutil.Testdata(t, "bundle-config-writer", "bundle-no-udi.json"),
 		},
+		{
+			name: "with catalog selection",
+			config: func(controller *gomock.Controller) *mocks.MockBundleConfig {
+				config := mocks.NewMockBundleConfig(controller)
+				config.EXPECT().GetSchemaVersion().Return("v1alpha1")
+				config.EXPECT().GetName().Return("test")
+				config.EXPECT().GetVersion().Return("0.1.0")
+				config.EXPECT().GetImages().Return(nil)
+				config.EXPECT().GetImageExclusions().Return(nil)
+				config.EXPECT().GetUserDefinedImages().Return(nil)
+				config.EXPECT().GetCatalog().Return(sheaf.CatalogSelection{
+					Version:  "v1",
+					Disabled: []string{"tekton"},
+				})
+				config.EXPECT().GetManifestSources().Return(nil)
+				config.EXPECT().GetMappingStrategy().Return(sheaf.MappingStrategy{})
+
+				return config
+			},
+			expected: testutil.Testdata(t, "bundle-config-writer", "bundle-catalog.json"),
+		},
 		{
 			name: "unable to open destination file",
 			config: func(controller *gomock.Controller) *mocks.MockBundleConfig {
@@ -0,0 +1,61 @@
+/*
+ * Copyright 2020 Sheaf Authors
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package fs
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/bryanl/sheaf/pkg/sheaf"
+)
+
+// Lock returns the bundle lock. An empty lock is returned if the bundle is not locked.
+func (b *Bundle) Lock() (sheaf.BundleLock, error) {
+	return loadBundleLock(b.rootPath)
+}
+
+// WriteLock writes the bundle lock next to the bundle config.
+func (b *Bundle) WriteLock(lock sheaf.BundleLock) error {
+	return writeBundleLock(b.rootPath, lock)
+}
+
+func writeBundleLock(rootPath string, lock sheaf.BundleLock) error {
+	data, err := json.MarshalIndent(lock, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode bundle lock: %w", err)
+	}
+
+	filename := filepath.Join(rootPath, sheaf.BundleLockFilename)
+	if err := ioutil.WriteFile(filename, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("write bundle lock %q: %w", filename, err)
+	}
+
+	return nil
+}
+
+func loadBundleLock(rootPath string) (sheaf.BundleLock, error) {
+	filename := filepath.Join(rootPath, sheaf.BundleLockFilename)
+
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return sheaf.BundleLock{}, nil
+		}
+
+		return sheaf.BundleLock{}, err
+	}
+
+	var lock sheaf.BundleLock
+	if err := json.Unmarshal(data, &lock); err != nil {
+		return sheaf.BundleLock{}, fmt.Errorf("decode bundle lock %q: %w", filename, err)
+	}
+
+	return lock, nil
+}
@@ -0,0 +1,72 @@
+// +build !integration
+
+/*
+ * Copyright 2020 Sheaf Authors
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package fs
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/bryanl/sheaf/internal/testutil"
+	"github.com/bryanl/sheaf/pkg/sheaf"
+)
+
+func TestBundle_Lock(t *testing.T) {
+	testutil.WithBundleDir(t, func(dir string) {
+		testutil.StageFile(t, sheaf.BundleConfigFilename, filepath.Join(dir, sheaf.BundleConfigFilename))
+		require.NoError(t, os.MkdirAll(filepath.Join(dir, "app", "manifests"), 0700))
+
+		bundle, err := NewBundle(dir)
+		require.NoError(t, err)
+
+		lock, err := bundle.Lock()
+		require.NoError(t, err)
+		require.True(t, lock.IsEmpty())
+
+		wanted := sheaf.BundleLock{
+			Images: []sheaf.LockedImage{
+				{
+					Name:   "docker.io/library/nginx:1.17",
+					Digest: "sha256:2539d4344dd18e1df02be842ffc435f8e1f699cfc55516e2cf2cb16b7a9aea0b",
+				},
+			},
+		}
+
+		require.NoError(t, bundle.WriteLock(wanted))
+
+		lock, err = bundle.Lock()
+		require.NoError(t, err)
+		require.Equal(t, wanted, lock)
+
+		testutil.WithBundleDir(t, func(dest string) {
+			copied, err := bundle.Copy(dest)
+			require.NoError(t, err)
+
+			lock, err = copied.Lock()
+			require.NoError(t, err)
+			require.Equal(t, wanted, lock)
+		})
+	})
+}
+
+func TestBundle_Lock_invalid(t *testing.T) {
+	testutil.WithBundleDir(t, func(dir string) {
+		testutil.StageFile(t, sheaf.BundleConfigFilename, filepath.Join(dir, sheaf.BundleConfigFilename))
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dir, sheaf.BundleLockFilename), []byte("{"), 0600))
+
+		bundle, err := NewBundle(dir)
+		require.NoError(t, err)
+
+		_, err = bundle.Lock()
+		require.Error(t, err)
+	})
+}
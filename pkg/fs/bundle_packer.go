@@ -7,11 +7,18 @@
 package fs
 
 import (
+	"crypto"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"log"
 	"os"
 	"path/filepath"
+	"strconv"
+	"sync"
+
+	"github.com/pivotal/image-relocation/pkg/image"
+	"go.uber.org/multierr"
 
 	"github.com/bryanl/sheaf/internal/goutil"
 	"github.com/bryanl/sheaf/pkg/archiver"
@@ -19,15 +26,69 @@ import (
 	"github.com/bryanl/sheaf/pkg/sheaf"
 )
 
+// DefaultBundlePackerConcurrency is the default number of images staged at
+// the same time.
+const DefaultBundlePackerConcurrency = 4
+
 // BundlePackerOption is a functional option for configuring BundlePacker.
 type BundlePackerOption func(bp *BundlePacker)
 
+// BundlePackerLayoutFactory configures the layout factory.
+func BundlePackerLayoutFactory(lf LayoutFactory) BundlePackerOption {
+	return func(bp *BundlePacker) {
+		bp.layoutFactory = lf
+	}
+}
+
+// BundlePackerConcurrency configures the number of images staged at the same time.
+func BundlePackerConcurrency(concurrency int) BundlePackerOption {
+	return func(bp *BundlePacker) {
+		bp.concurrency = concurrency
+	}
+}
+
+// BundlePackerArchiver configures the archiver.
+func BundlePackerArchiver(a sheaf.Archiver) BundlePackerOption {
+	return func(bp *BundlePacker) {
+		bp.archiver = a
+	}
+}
+
+// BundlePackerSigningKey configures the path of a PEM encoded ed25519 or
+// ECDSA private key used to sign archives. Only archives signed with an
+// ed25519 key are reproducible, because ECDSA signatures are randomized.
+func BundlePackerSigningKey(keyPath string) BundlePackerOption {
+	return func(bp *BundlePacker) {
+		bp.signingKeyPath = keyPath
+	}
+}
+
+// BundlePackerSplitSize configures the size of the volumes archives are
+// split into. Archives aren't split if the size is zero.
+func BundlePackerSplitSize(size int64) BundlePackerOption {
+	return func(bp *BundlePacker) {
+		bp.splitSize = size
+	}
+}
+
+// BundlePackerBase configures the path of a base archive. Blobs in the base
+// archive are left out, creating a delta archive that references it.
+func BundlePackerBase(basePath string) BundlePackerOption {
+	return func(bp *BundlePacker) {
+		bp.basePath = basePath
+	}
+}
+
 // BundlePacker packs bundles that live on a filesystem.
 type BundlePacker struct {
 	reporter           reporter.Reporter
 	archiver           sheaf.Archiver
 	layoutFactory      LayoutFactory
 	bundleConfigWriter sheaf.BundleConfigWriter
+	concurrency        int
+	signingKeyPath     string
+	splitSize          int64
+	basePath           string
 }
 
 var _ sheaf.BundlePacker = &BundlePacker{}
@@ -39,6 +100,7 @@ func NewBundlePacker(options ...BundlePackerOption) *BundlePacker {
 		archiver:           archiver.New(),
 		layoutFactory:      DefaultLayoutFactory(),
 		bundleConfigWriter: NewBundleConfigWriter(),
+		concurrency:        DefaultBundlePackerConcurrency,
 	}
 
 	for _, option := range options {
@@ -50,33 +112,64 @@ func NewBundlePacker(options ...BundlePackerOption) *BundlePacker {
 
 // Pack packs a bundle to a filesystem destination.
 func (bp BundlePacker) Pack(b sheaf.Bundle, dest string, force bool) error {
+	var signingKey crypto.Signer
+	if bp.signingKeyPath != "" {
+		key, err := loadSigningKey(bp.signingKeyPath)
+		if err != nil {
+			return fmt.Errorf("load signing key: %w", err)
+		}
+		signingKey = key
+	}
+
+	var base *baseArchive
+	if bp.basePath != "" {
+		bp.reporter.Headerf("Reading base archive %s", bp.basePath)
+		ba, err := readBaseArchive(bp.basePath)
+		if err != nil {
+			return fmt.Errorf("read base archive: %w", err)
+		}
+		base = &ba
+	}
+
 	bundleConfig := b.Config()
 
-	filename := fmt.Sprintf("%s-%s.tgz", bundleConfig.GetName(), bundleConfig.GetVersion())
+	filename := fmt.Sprintf("%s-%s%s", bundleConfig.GetName(), bundleConfig.GetVersion(), bp.archiver.Extension())
 
 	dest = filepath.Join(dest, filename)
-	if _, err := os.Stat(dest); err != nil {
+
+	// An archive split into volumes exists if its index exists.
+	existing := dest
+	if bp.splitSize > 0 {
+		existing = archiver.VolumeIndexPath(dest)
+	}
+
+	if _, err := os.Stat(existing); err != nil {
 		if !os.IsNotExist(err) {
 			return err
 		}
 
 	} else {
 		if force {
-			bp.reporter.Reportf("Removing existing file at %s", dest)
-			if err = os.RemoveAll(dest); err != nil {
+			bp.reporter.Reportf("Removing existing file at %s", existing)
+			if err = bp.removeArchive(dest); err != nil {
 				return fmt.Errorf("unable to remove destination: %w", err)
 			}
 		} else {
-			return fmt.Errorf("destination %s exists", dest)
+			return fmt.Errorf("destination %s exists", existing)
 		}
 	}
 
-	f, err := os.Create(dest)
+	w, err := bp.createArchive(dest)
 	if err != nil {
 		return fmt.Errorf("create archive file: %w", err)
 	}
 
-	defer goutil.Close(f)
+	closed := false
+	defer func() {
+		if !closed {
+			goutil.Close(w)
+		}
+	}()
 
 	dir, err := ioutil.TempDir("", "sheaf")
 	if err != nil {
@@ -101,19 +194,64 @@ func (bp BundlePacker) Pack(b sheaf.Bundle, dest string, force bool) error {
 		return fmt.Errorf("stage images: %w", err)
 	}
 
+	if base != nil {
+		bp.reporter.Header("Staging delta")
+		delta, err := stageDelta(dir, *base)
+		if err != nil {
+			return fmt.Errorf("stage delta: %w", err)
+		}
+		bp.reporter.Reportf("Left out %d blobs found in base archive %s", len(delta.Blobs), bp.basePath)
+	}
+
+	if signingKey != nil {
+		bp.reporter.Header("Signing archive")
+		if err := signArchive(dir, signingKey); err != nil {
+			return fmt.Errorf("sign archive: %w", err)
+		}
+	}
+
 	bp.reporter.Headerf("Creating archive: %s", dest)
-	if err := bp.archiver.Archive(dir, f); err != nil {
+	if err := bp.archiver.Archive(dir, w); err != nil {
 		return fmt.Errorf("create packed archive: %w", err)
 	}
 
+	closed = true
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("close archive: %w", err)
+	}
+
+	if vw, ok := w.(*archiver.VolumeWriter); ok {
+		bp.reporter.Reportf("Split archive into %d volumes listed in %s",
+			len(vw.Volumes()), archiver.VolumeIndexPath(dest))
+	}
+
 	return nil
 }
 
+// createArchive creates the file an archive is written to, or a writer that
+// splits it into volumes if a split size is configured.
+func (bp BundlePacker) createArchive(dest string) (io.WriteCloser, error) {
+	if bp.splitSize > 0 {
+		return archiver.NewVolumeWriter(dest, bp.splitSize)
+	}
+
+	return os.Create(dest)
+}
+
+// removeArchive removes an existing archive, including its volumes if a
+// split size is configured.
+func (bp BundlePacker) removeArchive(dest string) error {
+	if bp.splitSize > 0 {
+		return archiver.RemoveVolumes(dest)
+	}
+
+	return os.RemoveAll(dest)
+}
+
 func (bp BundlePacker) stageImages(dir string, b sheaf.Bundle) error {
 	bp.reporter.Header("Staging images")
 
-	layout, err := bp.layoutFactory(dir)
-	if err != nil {
+	if _, err := bp.layoutFactory(dir); err != nil {
 		return fmt.Errorf("create layout manager: %w", err)
 	}
 
@@ -122,15 +260,177 @@ func (bp BundlePacker) stageImages(dir string, b sheaf.Bundle) error {
 		return fmt.Errorf("get images from fs: %w", err)
 	}
 
-	for _, imageName := range imageList.Slice() {
-		bp.reporter.Reportf("adding %s to layout\n", imageName.String())
-		if _, err := layout.Add(imageName); err != nil {
-			return fmt.Errorf("add ref %s to image layout: %w", imageName, err)
+	exclusions := sheaf.ImageExclusions(b.Config().GetImageExclusions())
+	imageList, _, err = exclusions.Filter(imageList)
+	if err != nil {
+		return fmt.Errorf("exclude images: %w", err)
+	}
+
+	lock, err := b.Lock()
+	if err != nil {
+		return fmt.Errorf("load bundle lock: %w", err)
+	}
+
+	// Images pinned by the lock are tracked because the lock in the archive
+	// is updated if their platforms are limited. Other images map to an empty
+	// name.
+	var imageNames []image.Name
+	lockedNames := map[image.Name]image.Name{}
+	for _, n := range imageList.Slice() {
+		pinned, ok, err := lock.Pin(n)
+		if err != nil {
+			return fmt.Errorf("pin images: %w", err)
+		}
+
+		lockedName := image.EmptyName
+		if ok && n.Digest() == image.EmptyDigest {
+			lockedName = n
+		}
+
+		// An image that is also referenced by digest keeps every platform.
+		if previous, seen := lockedNames[pinned]; seen {
+			if previous != image.EmptyName && lockedName == image.EmptyName {
+				lockedNames[pinned] = image.EmptyName
+			}
+			continue
+		}
+
+		lockedNames[pinned] = lockedName
+		imageNames = append(imageNames, pinned)
+	}
+
+	if len(imageNames) == 0 {
+		return nil
+	}
+
+	workers := bp.concurrency
+	if workers < 1 {
+		workers = 1
+	}
+	if workers > len(imageNames) {
+		workers = len(imageNames)
+	}
+
+	// Each worker stages images in its own layout because layouts can't be
+	// updated concurrently. The layouts are merged once all images are staged.
+	workDir, err := ioutil.TempDir("", "sheaf-images")
+	if err != nil {
+		return fmt.Errorf("create temporary directory: %w", err)
+	}
+
+	defer func() {
+		if rErr := os.RemoveAll(workDir); rErr != nil {
+			log.Printf("unable to remove temporary directory: %v", rErr)
+		}
+	}()
+
+	var workerRoots []string
+	var workerLayouts []Layout
+	for i := 0; i < workers; i++ {
+		root := filepath.Join(workDir, strconv.Itoa(i))
+		l, err := bp.layoutFactory(root)
+		if err != nil {
+			return fmt.Errorf("create layout manager: %w", err)
+		}
+
+		workerRoots = append(workerRoots, root)
+		workerLayouts = append(workerLayouts, l)
+	}
+
+	var (
+		mu       sync.Mutex
+		wg       sync.WaitGroup
+		errs     error
+		staged   int
+		relocked = map[image.Name]image.Digest{}
+	)
+
+	queue := make(chan image.Name)
+	for _, l := range workerLayouts {
+		wg.Add(1)
+		go func(l Layout) {
+			defer wg.Done()
+
+			for imageName := range queue {
+				lockedName := lockedNames[imageName]
+				locked := lockedName != image.EmptyName
+
+				digest, err := addImage(l, imageName, locked)
+
+				// Report each image on a single line as it finishes so output from
+				// workers is not interleaved.
+				mu.Lock()
+				staged++
+				if err == nil && locked && digest != image.EmptyDigest && digest != imageName.Digest() {
+					relocked[lockedName] = digest
+				}
+				if err != nil {
+					errs = multierr.Append(errs, fmt.Errorf("add ref %s to image layout: %w", imageName, err))
+					bp.reporter.Reportf("[%d/%d] unable to add %s to layout", staged, len(imageNames), imageName)
+				} else {
+					bp.reporter.Reportf("[%d/%d] added %s to layout", staged, len(imageNames), imageName)
+				}
+				mu.Unlock()
+			}
+		}(l)
+	}
+
+	for _, imageName := range imageNames {
+		queue <- imageName
+	}
+	close(queue)
+	wg.Wait()
+
+	if errs != nil {
+		return errs
+	}
+
+	for _, root := range workerRoots {
+		if err := mergeLayout(layoutRootPath(root), layoutRootPath(dir)); err != nil {
+			return fmt.Errorf("merge image layouts: %w", err)
 		}
 	}
+
+	// Images are staged in any order, so the index is sorted to make the
+	// archive reproducible.
+	if err := sortLayoutIndex(layoutRootPath(dir)); err != nil {
+		return fmt.Errorf("sort image layout index: %w", err)
+	}
+
+	// Locked images with platforms left out are pinned to the digest of their
+	// staged index in the archive's lock.
+	if len(relocked) > 0 {
+		lock, err = lock.Relock(relocked)
+		if err != nil {
+			return fmt.Errorf("update bundle lock: %w", err)
+		}
+
+		if err := writeBundleLock(dir, lock); err != nil {
+			return fmt.Errorf("update bundle lock: %w", err)
+		}
+
+		bp.reporter.Reportf("Updated the digests of %d locked images in the archive's bundle lock", len(relocked))
+	}
+
 	return nil
 }
 
+// lockedImageAdder is implemented by layouts that limit the platforms of
+// images pinned by the bundle lock.
+type lockedImageAdder interface {
+	AddLocked(n image.Name) (image.Digest, error)
+}
+
+// addImage adds an image to a layout. Locked images are added with AddLocked
+// if the layout supports it.
+func addImage(l Layout, n image.Name, locked bool) (image.Digest, error) {
+	if la, ok := l.(lockedImageAdder); ok && locked {
+		return la.AddLocked(n)
+	}
+
+	return l.Add(n)
+}
+
 func (bp BundlePacker) stageManifests(dir string, b sheaf.Bundle) error {
 	bp.reporter.Header("Staging manifests")
 
@@ -157,6 +457,11 @@ func (bp BundlePacker) stageManifests(dir string, b sheaf.Bundle) error {
 			return fmt.Errorf("stage manifest %q: %w", bundleManifest.ID, err)
 		}
 	}
+
+	if err := writeManifestChecksums(dir); err != nil {
+		return fmt.Errorf("write manifest checksums: %w", err)
+	}
+
 	return nil
 }
 
@@ -7,38 +7,156 @@
 package fs
 
 import (
+	"crypto/sha256"
+	"fmt"
 	"io/ioutil"
+	"log"
+	"net/http/httptest"
+	"net/url"
 	"os"
 	"path/filepath"
+	"sort"
 	"testing"
 
 	"github.com/golang/mock/gomock"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/registry"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/layout"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
 	"github.com/pivotal/image-relocation/pkg/image"
+	"github.com/pivotal/image-relocation/pkg/images"
 	"github.com/stretchr/testify/require"
 
 	"github.com/bryanl/sheaf/internal/testutil"
+	"github.com/bryanl/sheaf/pkg/archiver"
 	"github.com/bryanl/sheaf/pkg/mocks"
 	"github.com/bryanl/sheaf/pkg/reporter"
+	"github.com/bryanl/sheaf/pkg/sheaf"
 )
 
 func TestBundlePacker_Pack(t *testing.T) {
+	genBundle := func(controller *gomock.Controller, exclusions ...sheaf.ImageExclusion) *mocks.MockBundle {
+		config := testutil.GenerateBundleConfig(controller)
+		config.EXPECT().GetImageExclusions().Return(exclusions).AnyTimes()
+
+		b := testutil.GenerateBundle(t, controller, testutil.BundleGeneratorConfig(config))
+		nb := testutil.GenerateBundle(t, controller)
+
+		b.EXPECT().Copy(gomock.Any()).Return(nb, nil)
+
+		return b
+	}
+
+	genBundleWithImages := func(controller *gomock.Controller, names ...string) *mocks.MockBundle {
+		config := testutil.GenerateBundleConfig(controller)
+		config.EXPECT().GetImageExclusions().Return(nil).AnyTimes()
+
+		b := testutil.GenerateBundle(t, controller,
+			testutil.BundleGeneratorConfig(config),
+			testutil.BundleGeneratorCreateBundle(
+				func(t *testing.T, controller *gomock.Controller, config sheaf.BundleConfig, manifests []sheaf.BundleManifest) *mocks.MockBundle {
+					bundle := mocks.NewMockBundle(controller)
+					bundle.EXPECT().Config().Return(config).AnyTimes()
+
+					m := mocks.NewMockManifestService(controller)
+					m.EXPECT().List().Return(manifests, nil).AnyTimes()
+					bundle.EXPECT().Manifests().Return(m, nil).AnyTimes()
+
+					imageList, err := images.New(names...)
+					require.NoError(t, err)
+					bundle.EXPECT().Images().Return(imageList, nil)
+					bundle.EXPECT().Lock().Return(sheaf.BundleLock{}, nil)
+
+					return bundle
+				}))
+		nb := testutil.GenerateBundle(t, controller)
+
+		b.EXPECT().Copy(gomock.Any()).Return(nb, nil)
+
+		return b
+	}
 
 	tests := []struct {
-		name    string
-		bundle  func(controller *gomock.Controller) *mocks.MockBundle
-		dest    string
-		force   bool
-		wantErr bool
+		name        string
+		bundle      func(controller *gomock.Controller) *mocks.MockBundle
+		dest        string
+		force       bool
+		concurrency int
+		signingKey  string
+		format      archiver.Format
+		splitSize   int64
+		failing     []string
+		wantImages  int
+		wantErr     []string
 	}{
 		{
 			name: "in general",
 			bundle: func(controller *gomock.Controller) *mocks.MockBundle {
-				b := testutil.GenerateBundle(t, controller)
-				nb := testutil.GenerateBundle(t, controller)
-
-				b.EXPECT().Copy(gomock.Any()).Return(nb, nil)
-
-				return b
+				return genBundle(controller)
+			},
+			wantImages: 1,
+		},
+		{
+			name: "with excluded image",
+			bundle: func(controller *gomock.Controller) *mocks.MockBundle {
+				return genBundle(controller, sheaf.ImageExclusion{Image: "image"})
+			},
+		},
+		{
+			name: "stages images concurrently",
+			bundle: func(controller *gomock.Controller) *mocks.MockBundle {
+				return genBundleWithImages(controller, "image1", "image2", "image3", "image4", "image5")
+			},
+			concurrency: 2,
+			wantImages:  5,
+		},
+		{
+			name: "signs archive",
+			bundle: func(controller *gomock.Controller) *mocks.MockBundle {
+				return genBundle(controller)
+			},
+			signingKey: filepath.Join("testdata", "signing-key.pem"),
+			wantImages: 1,
+		},
+		{
+			name: "zstd format",
+			bundle: func(controller *gomock.Controller) *mocks.MockBundle {
+				return genBundle(controller)
+			},
+			format:     archiver.Zstd,
+			wantImages: 1,
+		},
+		{
+			name: "split archive",
+			bundle: func(controller *gomock.Controller) *mocks.MockBundle {
+				return genBundle(controller)
+			},
+			signingKey: filepath.Join("testdata", "signing-key.pem"),
+			splitSize:  100,
+			wantImages: 1,
+		},
+		{
+			name: "invalid signing key",
+			bundle: func(controller *gomock.Controller) *mocks.MockBundle {
+				return testutil.GenerateBundle(t, controller)
+			},
+			signingKey: filepath.Join("testdata", "missing.pem"),
+			wantErr:    []string{"load signing key"},
+		},
+		{
+			name: "collects image errors",
+			bundle: func(controller *gomock.Controller) *mocks.MockBundle {
+				return genBundleWithImages(controller, "image1", "image2", "image3")
+			},
+			failing:    []string{"docker.io/library/image1", "docker.io/library/image3"},
+			wantImages: 3,
+			wantErr: []string{
+				"add ref docker.io/library/image1 to image layout",
+				"add ref docker.io/library/image3 to image layout",
 			},
 		},
 	}
@@ -48,14 +166,39 @@ func TestBundlePacker_Pack(t *testing.T) {
 			defer controller.Finish()
 
 			layout := mocks.NewMockLayout(controller)
-			layout.EXPECT().Add(gomock.Any()).Return(image.Digest{}, nil)
+			layout.EXPECT().Add(gomock.Any()).
+				DoAndReturn(func(n image.Name) (image.Digest, error) {
+					for _, failing := range test.failing {
+						if n.String() == failing {
+							return image.EmptyDigest, fmt.Errorf("error")
+						}
+					}
+					return image.Digest{}, nil
+				}).
+				Times(test.wantImages)
 
-			bp := NewBundlePacker(func(bp *BundlePacker) {
-				bp.reporter = reporter.Nop{}
-				bp.layoutFactory = func(_ string) (Layout, error) {
-					return layout, nil
-				}
-			})
+			options := []BundlePackerOption{
+				func(bp *BundlePacker) {
+					bp.reporter = reporter.Nop{}
+					bp.layoutFactory = func(_ string) (Layout, error) {
+						return layout, nil
+					}
+				},
+			}
+			if test.concurrency > 0 {
+				options = append(options, BundlePackerConcurrency(test.concurrency))
+			}
+			if test.signingKey != "" {
+				options = append(options, BundlePackerSigningKey(test.signingKey))
+			}
+			if test.splitSize > 0 {
+				options = append(options, BundlePackerSplitSize(test.splitSize))
+			}
+			if test.format != "" {
+				options = append(options, BundlePackerArchiver(archiver.New(archiver.WithFormat(test.format))))
+			}
+
+			bp := NewBundlePacker(options...)
 
 			bundle := test.bundle(controller)
 			force := test.force
@@ -70,12 +213,425 @@ func TestBundlePacker_Pack(t *testing.T) {
 			dest := filepath.Join(tempDir, test.dest)
 
 			err = bp.Pack(bundle, dest, force)
-			if test.wantErr {
+			if len(test.wantErr) > 0 {
 				require.Error(t, err)
+				for _, want := range test.wantErr {
+					require.Contains(t, err.Error(), want)
+				}
 				return
 			}
 
 			require.NoError(t, err)
+
+			format := test.format
+			if format == "" {
+				format = archiver.Gzip
+			}
+
+			archivePath := filepath.Join(dest, "project-0.1.0"+format.Extension())
+			if test.splitSize > 0 {
+				archivePath = archiver.VolumeIndexPath(archivePath)
+			}
+			require.FileExists(t, archivePath)
+
+			if test.signingKey != "" {
+				exploded := filepath.Join(tempDir, "exploded")
+				require.NoError(t, archiver.New().UnarchivePath(archivePath, exploded))
+				require.FileExists(t, filepath.Join(exploded, SignatureFile))
+			}
 		})
 	}
 }
+
+func TestBundlePacker_Pack_reproducible(t *testing.T) {
+	names := []string{"image1", "image2", "image3", "image4", "image5"}
+
+	imgs := map[string]v1.Image{}
+	for _, n := range names {
+		img, err := random.Image(256, 1)
+		require.NoError(t, err)
+		imgs["docker.io/library/"+n] = img
+	}
+
+	pack := func(t *testing.T, dest string, signingKey string) []byte {
+		controller := gomock.NewController(t)
+		defer controller.Finish()
+
+		config := testutil.GenerateBundleConfig(controller)
+		config.EXPECT().GetImageExclusions().Return(nil).AnyTimes()
+
+		b := testutil.GenerateBundle(t, controller,
+			testutil.BundleGeneratorConfig(config),
+			testutil.BundleGeneratorCreateBundle(
+				func(t *testing.T, controller *gomock.Controller, config sheaf.BundleConfig, manifests []sheaf.BundleManifest) *mocks.MockBundle {
+					bundle := mocks.NewMockBundle(controller)
+					bundle.EXPECT().Config().Return(config).AnyTimes()
+
+					m := mocks.NewMockManifestService(controller)
+					m.EXPECT().List().Return(manifests, nil).AnyTimes()
+					bundle.EXPECT().Manifests().Return(m, nil).AnyTimes()
+
+					imageList, err := images.New(names...)
+					require.NoError(t, err)
+					bundle.EXPECT().Images().Return(imageList, nil)
+					bundle.EXPECT().Lock().Return(sheaf.BundleLock{}, nil)
+
+					return bundle
+				}))
+		b.EXPECT().Copy(gomock.Any()).Return(testutil.GenerateBundle(t, controller), nil)
+
+		bp := NewBundlePacker(
+			BundlePackerConcurrency(len(names)),
+			BundlePackerSigningKey(signingKey),
+			func(bp *BundlePacker) {
+				bp.reporter = reporter.Nop{}
+				bp.layoutFactory = func(root string) (Layout, error) {
+					p, err := layout.Write(layoutRootPath(root), empty.Index)
+					if err != nil {
+						return nil, err
+					}
+
+					return &imageLayout{path: p, images: imgs}, nil
+				}
+			})
+
+		require.NoError(t, bp.Pack(b, dest, false))
+
+		data, err := ioutil.ReadFile(filepath.Join(dest, "project-0.1.0.tgz"))
+		require.NoError(t, err)
+		return data
+	}
+
+	tests := []struct {
+		name         string
+		signingKey   string
+		reproducible bool
+	}{
+		{
+			name:         "unsigned",
+			reproducible: true,
+		},
+		{
+			name:         "signed with an ed25519 key",
+			signingKey:   filepath.Join("testdata", "signing-key.pem"),
+			reproducible: true,
+		},
+		{
+			// ECDSA signatures are randomized, so signature.json differs.
+			name:       "signed with an ECDSA key",
+			signingKey: filepath.Join("testdata", "ecdsa-signing-key.pem"),
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			tempDir, err := ioutil.TempDir("", "sheaf-test")
+			require.NoError(t, err)
+
+			defer func() {
+				require.NoError(t, os.RemoveAll(tempDir))
+			}()
+
+			dest1 := filepath.Join(tempDir, "1")
+			require.NoError(t, os.Mkdir(dest1, 0700))
+			dest2 := filepath.Join(tempDir, "2")
+			require.NoError(t, os.Mkdir(dest2, 0700))
+
+			first := sha256.Sum256(pack(t, dest1, test.signingKey))
+			second := sha256.Sum256(pack(t, dest2, test.signingKey))
+			if test.reproducible {
+				require.Equal(t, first, second)
+				return
+			}
+
+			require.NotEqual(t, first, second)
+		})
+	}
+}
+
+func TestBundlePacker_Pack_delta(t *testing.T) {
+	imgs := map[string]v1.Image{}
+	for _, n := range []string{"image1", "image2", "image3"} {
+		img, err := random.Image(256, 1)
+		require.NoError(t, err)
+		imgs["docker.io/library/"+n] = img
+	}
+
+	tempDir, err := ioutil.TempDir("", "sheaf-test")
+	require.NoError(t, err)
+
+	defer func() {
+		require.NoError(t, os.RemoveAll(tempDir))
+	}()
+
+	pack := func(t *testing.T, dest string, names []string, options ...BundlePackerOption) string {
+		controller := gomock.NewController(t)
+		defer controller.Finish()
+
+		config := testutil.GenerateBundleConfig(controller)
+		config.EXPECT().GetImageExclusions().Return(nil).AnyTimes()
+
+		b := testutil.GenerateBundle(t, controller,
+			testutil.BundleGeneratorConfig(config),
+			testutil.BundleGeneratorCreateBundle(
+				func(t *testing.T, controller *gomock.Controller, config sheaf.BundleConfig, manifests []sheaf.BundleManifest) *mocks.MockBundle {
+					bundle := mocks.NewMockBundle(controller)
+					bundle.EXPECT().Config().Return(config).AnyTimes()
+
+					m := mocks.NewMockManifestService(controller)
+					m.EXPECT().List().Return(manifests, nil).AnyTimes()
+					bundle.EXPECT().Manifests().Return(m, nil).AnyTimes()
+
+					imageList, err := images.New(names...)
+					require.NoError(t, err)
+					bundle.EXPECT().Images().Return(imageList, nil)
+					bundle.EXPECT().Lock().Return(sheaf.BundleLock{}, nil)
+
+					return bundle
+				}))
+		b.EXPECT().Copy(gomock.Any()).Return(testutil.GenerateBundle(t, controller), nil)
+
+		bp := NewBundlePacker(append([]BundlePackerOption{
+			func(bp *BundlePacker) {
+				bp.reporter = reporter.Nop{}
+				bp.layoutFactory = func(root string) (Layout, error) {
+					p, err := layout.Write(layoutRootPath(root), empty.Index)
+					if err != nil {
+						return nil, err
+					}
+
+					return &imageLayout{path: p, images: imgs}, nil
+				}
+			}}, options...)...)
+
+		require.NoError(t, os.MkdirAll(dest, 0700))
+		require.NoError(t, bp.Pack(b, dest, false))
+
+		return filepath.Join(dest, "project-0.1.0.tgz")
+	}
+
+	base := pack(t, filepath.Join(tempDir, "base"), []string{"image1", "image2"})
+	delta := pack(t, filepath.Join(tempDir, "delta"), []string{"image1", "image2", "image3"},
+		BundlePackerBase(base))
+
+	baseArchive, err := readBaseArchive(base)
+	require.NoError(t, err)
+	deltaArchive, err := readBaseArchive(delta)
+	require.NoError(t, err)
+
+	// The layers of the images in the base are left out of the delta, but
+	// their manifests and configs are kept.
+	var baseLayers []string
+	for _, n := range []string{"image1", "image2"} {
+		img := imgs["docker.io/library/"+n]
+
+		layers, err := img.Layers()
+		require.NoError(t, err)
+		for _, layer := range layers {
+			h, err := layer.Digest()
+			require.NoError(t, err)
+			require.False(t, deltaArchive.blobs[h.String()], h)
+			baseLayers = append(baseLayers, h.String())
+		}
+
+		h, err := img.Digest()
+		require.NoError(t, err)
+		require.True(t, deltaArchive.blobs[h.String()], h)
+
+		h, err = img.ConfigName()
+		require.NoError(t, err)
+		require.True(t, deltaArchive.blobs[h.String()], h)
+	}
+	require.Len(t, deltaArchive.blobs, 7)
+
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	// The images in a delta archive can be listed without its base.
+	metadata := filepath.Join(tempDir, "metadata")
+	require.NoError(t, archiver.New().UnarchiveMetadataPath(delta, metadata))
+
+	mb := mocks.NewMockBundle(controller)
+	mb.EXPECT().Path().Return(metadata).AnyTimes()
+
+	list, err := NewArtifactsService(mb).Image().List()
+	require.NoError(t, err)
+	require.Len(t, list, 3)
+
+	exploded := filepath.Join(tempDir, "exploded")
+	require.NoError(t, archiver.New().UnarchivePath(delta, exploded))
+
+	d, err := readDelta(exploded)
+	require.NoError(t, err)
+	require.Equal(t, baseArchive.digest, d.Base.Digest)
+	sort.Strings(baseLayers)
+	require.Equal(t, baseLayers, d.Blobs)
+
+	b := mocks.NewMockBundle(controller)
+	b.EXPECT().Path().Return(exploded).AnyTimes()
+
+	root := layoutRootPath(exploded)
+	descriptors, err := layoutDescriptors(root)
+	require.NoError(t, err)
+	require.Len(t, descriptors, 3)
+
+	verifyImages := func() {
+		blobs, err := verifyBlobs(root)
+		require.NoError(t, err)
+		require.NoError(t, addBaseBlobs(exploded, blobs))
+
+		for _, desc := range descriptors {
+			require.Empty(t, verifyDescriptor(root, desc, blobs, map[v1.Hash]bool{}))
+		}
+	}
+
+	// The images verify before the delta is applied, without the layers
+	// taken from the base.
+	verifyImages()
+
+	require.NoError(t, NewDeltaApplier().ApplyDelta(b, base))
+
+	// Every image is complete once the delta is applied.
+	verifyImages()
+
+	blobs, err := verifyBlobs(root)
+	require.NoError(t, err)
+	for _, layer := range baseLayers {
+		h, err := v1.NewHash(layer)
+		require.NoError(t, err)
+		require.Contains(t, blobs, h)
+	}
+}
+
+func TestBundlePacker_Pack_lockedPlatforms(t *testing.T) {
+	server := httptest.NewServer(registry.New(registry.Logger(log.New(ioutil.Discard, "", 0))))
+	defer server.Close()
+
+	u, err := url.Parse(server.URL)
+	require.NoError(t, err)
+
+	amd64, err := random.Image(256, 1)
+	require.NoError(t, err)
+	arm64, err := random.Image(256, 1)
+	require.NoError(t, err)
+
+	ii := mutate.AppendManifests(empty.Index,
+		mutate.IndexAddendum{
+			Add:        amd64,
+			Descriptor: v1.Descriptor{Platform: &v1.Platform{OS: "linux", Architecture: "amd64"}},
+		},
+		mutate.IndexAddendum{
+			Add:        arm64,
+			Descriptor: v1.Descriptor{Platform: &v1.Platform{OS: "linux", Architecture: "arm64"}},
+		},
+	)
+
+	indexDigest, err := ii.Digest()
+	require.NoError(t, err)
+	arm64Digest, err := arm64.Digest()
+	require.NoError(t, err)
+
+	ref, err := name.ParseReference(fmt.Sprintf("%s/app:1.0", u.Host))
+	require.NoError(t, err)
+	require.NoError(t, remote.WriteIndex(ref, ii))
+
+	n, err := image.NewName(ref.String())
+	require.NoError(t, err)
+	lockedDigest, err := image.NewDigest(indexDigest.String())
+	require.NoError(t, err)
+	lock := sheaf.NewBundleLock(map[image.Name]image.Digest{n: lockedDigest})
+
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	config := testutil.GenerateBundleConfig(controller)
+	config.EXPECT().GetImageExclusions().Return(nil).AnyTimes()
+
+	b := testutil.GenerateBundle(t, controller,
+		testutil.BundleGeneratorConfig(config),
+		testutil.BundleGeneratorCreateBundle(
+			func(t *testing.T, controller *gomock.Controller, config sheaf.BundleConfig, manifests []sheaf.BundleManifest) *mocks.MockBundle {
+				bundle := mocks.NewMockBundle(controller)
+				bundle.EXPECT().Config().Return(config).AnyTimes()
+
+				m := mocks.NewMockManifestService(controller)
+				m.EXPECT().List().Return(manifests, nil).AnyTimes()
+				bundle.EXPECT().Manifests().Return(m, nil).AnyTimes()
+
+				imageList, err := images.New(ref.String())
+				require.NoError(t, err)
+				bundle.EXPECT().Images().Return(imageList, nil)
+				bundle.EXPECT().Lock().Return(lock, nil)
+
+				return bundle
+			}))
+	b.EXPECT().Copy(gomock.Any()).Return(testutil.GenerateBundle(t, controller), nil)
+
+	tempDir, err := ioutil.TempDir("", "sheaf-test")
+	require.NoError(t, err)
+
+	defer func() {
+		require.NoError(t, os.RemoveAll(tempDir))
+	}()
+
+	bp := NewBundlePacker(
+		BundlePackerLayoutFactory(DefaultLayoutFactory(DefaultLayoutFactoryPlatforms("linux/arm64"))),
+		func(bp *BundlePacker) {
+			bp.reporter = reporter.Nop{}
+		})
+	require.NoError(t, bp.Pack(b, tempDir, false))
+
+	exploded := filepath.Join(tempDir, "exploded")
+	require.NoError(t, archiver.New().UnarchivePath(filepath.Join(tempDir, "project-0.1.0.tgz"), exploded))
+
+	// The archive's lock pins the image to the index with the selected
+	// platform, and the image is stored under the pinned name.
+	archiveLock, err := loadBundleLock(exploded)
+	require.NoError(t, err)
+	pinned, ok, err := archiveLock.Pin(n)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.NotEqual(t, lockedDigest, pinned.Digest())
+
+	descriptors, err := layoutDescriptors(layoutRootPath(exploded))
+	require.NoError(t, err)
+	desc, ok := findDescriptor(descriptors, pinned)
+	require.True(t, ok)
+	require.Equal(t, pinned.Digest().String(), desc.Digest.String())
+
+	stored, err := layout.ImageIndexFromPath(layoutRootPath(exploded))
+	require.NoError(t, err)
+	storedIndex, err := stored.ImageIndex(desc.Digest)
+	require.NoError(t, err)
+	im, err := storedIndex.IndexManifest()
+	require.NoError(t, err)
+	require.Len(t, im.Manifests, 1)
+	require.Equal(t, arm64Digest, im.Manifests[0].Digest)
+}
+
+// imageLayout is a layout that adds images from memory.
+type imageLayout struct {
+	Layout
+
+	path   layout.Path
+	images map[string]v1.Image
+}
+
+func (l *imageLayout) Add(n image.Name) (image.Digest, error) {
+	img, ok := l.images[n.String()]
+	if !ok {
+		return image.EmptyDigest, fmt.Errorf("image %s not found", n)
+	}
+
+	if err := l.path.AppendImage(img, layout.WithAnnotations(map[string]string{
+		refNameAnnotation: n.String(),
+	})); err != nil {
+		return image.EmptyDigest, err
+	}
+
+	h, err := img.Digest()
+	if err != nil {
+		return image.EmptyDigest, err
+	}
+
+	return image.NewDigest(h.String())
+}
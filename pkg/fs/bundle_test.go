@@ -10,12 +10,14 @@ package fs
 
 import (
 	"bytes"
+	"os"
 	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/require"
 
 	"github.com/bryanl/sheaf/internal/testutil"
+	"github.com/bryanl/sheaf/pkg/reporter"
 	"github.com/bryanl/sheaf/pkg/sheaf"
 )
 
@@ -59,3 +61,69 @@ func TestLoadBundleConfig(t *testing.T) {
 	})
 
 }
+
+func TestBundle_Images(t *testing.T) {
+	testutil.WithBundleDir(t, func(dir string) {
+		config := NewBundleConfig("bundle", "0.1.0")
+		config.SetImages([]string{"busybox:1.31", "nginx:1.17.8"})
+
+		f, err := os.Create(filepath.Join(dir, sheaf.BundleConfigFilename))
+		require.NoError(t, err)
+		require.NoError(t, NewBundleConfigCodec().Encode(f, config))
+		require.NoError(t, f.Close())
+
+		manifestsDir := filepath.Join(dir, "app", "manifests")
+		require.NoError(t, os.MkdirAll(manifestsDir, 0700))
+		testutil.StageFile(t, filepath.Join("manifests", "deploy.yaml"), filepath.Join(manifestsDir, "deploy.yaml"))
+
+		bundle, err := NewBundle(dir, func(b Bundle) Bundle {
+			b.reporter = reporter.Nop{}
+			return b
+		})
+		require.NoError(t, err)
+
+		got, err := bundle.Images()
+		require.NoError(t, err)
+
+		require.Equal(t, []string{
+			"docker.io/library/busybox:1.31",
+			"docker.io/library/nginx:1.17.8",
+		}, got.Strings())
+	})
+}
+
+func TestBundle_Images_exclusions(t *testing.T) {
+	testutil.WithBundleDir(t, func(dir string) {
+		config := NewBundleConfig("bundle", "0.1.0")
+		config.SetImages([]string{"busybox:1.31", "example.com/test/hook:1.0"})
+		config.SetImageExclusions([]sheaf.ImageExclusion{
+			{Image: "busybox:1.31"},
+			{Registry: "example.com"},
+		})
+
+		f, err := os.Create(filepath.Join(dir, sheaf.BundleConfigFilename))
+		require.NoError(t, err)
+		require.NoError(t, NewBundleConfigCodec().Encode(f, config))
+		require.NoError(t, f.Close())
+
+		manifestsDir := filepath.Join(dir, "app", "manifests")
+		require.NoError(t, os.MkdirAll(manifestsDir, 0700))
+		testutil.StageFile(t, filepath.Join("manifests", "deploy.yaml"), filepath.Join(manifestsDir, "deploy.yaml"))
+
+		var buf bytes.Buffer
+		bundle, err := NewBundle(dir, func(b Bundle) Bundle {
+			b.reporter = reporter.New(reporter.WithWriter(&buf))
+			return b
+		})
+		require.NoError(t, err)
+
+		got, err := bundle.Images()
+		require.NoError(t, err)
+
+		require.Equal(t, []string{
+			"docker.io/library/nginx:1.17.8",
+		}, got.Strings())
+		require.Contains(t, buf.String(), "excluded")
+		require.Contains(t, buf.String(), "example.com/test/hook:1.0")
+	})
+}
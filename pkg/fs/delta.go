@@ -0,0 +1,362 @@
+/*
+ * Copyright 2020 Sheaf Authors
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package fs
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+
+	"github.com/bryanl/sheaf/pkg/archiver"
+	"github.com/bryanl/sheaf/pkg/sheaf"
+)
+
+// DeltaFile is the file in a delta archive that references its base archive.
+const DeltaFile = "delta.json"
+
+// layoutBlobsName is the name of the image layout blobs in an archive.
+const layoutBlobsName = "artifacts/layout/blobs"
+
+// Delta describes a delta archive.
+type Delta struct {
+	// Base is the archive the delta archive was packed against.
+	Base DeltaBase `json:"base"`
+	// Blobs are the digests of the layers taken from the base archive.
+	Blobs []string `json:"blobs"`
+}
+
+// DeltaBase is the base archive of a delta archive.
+type DeltaBase struct {
+	// Archive is the file name of the base archive.
+	Archive string `json:"archive"`
+	// Digest is the SHA-256 digest of the base archive.
+	Digest string `json:"digest"`
+}
+
+// baseArchive is an archive a delta archive is packed against.
+type baseArchive struct {
+	path   string
+	digest string
+	blobs  map[string]bool
+}
+
+// readBaseArchive streams an archive to find its digest and blobs.
+func readBaseArchive(path string) (baseArchive, error) {
+	names, digest, err := archiver.Files(path)
+	if err != nil {
+		return baseArchive{}, err
+	}
+
+	base := baseArchive{
+		path:   path,
+		digest: digest,
+		blobs:  map[string]bool{},
+	}
+
+	for _, name := range names {
+		if h, ok := blobDigest(name); ok {
+			base.blobs[h.String()] = true
+		}
+	}
+
+	return base, nil
+}
+
+// blobDigest returns the digest of a blob from its name in an archive.
+func blobDigest(name string) (v1.Hash, bool) {
+	rel := strings.TrimPrefix(name, layoutBlobsName+"/")
+	if rel == name {
+		return v1.Hash{}, false
+	}
+
+	h, err := v1.NewHash(strings.Replace(rel, "/", ":", 1))
+	if err != nil {
+		return v1.Hash{}, false
+	}
+
+	return h, true
+}
+
+// blobName returns the name of a blob in an archive.
+func blobName(h v1.Hash) string {
+	return path.Join(layoutBlobsName, h.Algorithm, h.Hex)
+}
+
+// stageDelta removes the staged layers that are in a base archive and writes
+// a delta file that references them. Manifests, indexes, and configs are
+// kept, so the images in a delta archive can be listed and compared without
+// its base archive.
+func stageDelta(dir string, base baseArchive) (Delta, error) {
+	layers, err := layoutLayers(layoutRootPath(dir))
+	if err != nil {
+		return Delta{}, fmt.Errorf("find layers: %w", err)
+	}
+
+	delta := Delta{
+		Base: DeltaBase{
+			Archive: filepath.Base(base.path),
+			Digest:  base.digest,
+		},
+		Blobs: []string{},
+	}
+
+	blobsPath := filepath.Join(layoutRootPath(dir), "blobs")
+	err = filepath.Walk(blobsPath, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) && path == blobsPath {
+				return nil
+			}
+			return err
+		}
+
+		if info.IsDir() {
+			return nil
+		}
+
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+
+		h, ok := blobDigest(filepath.ToSlash(rel))
+		if !ok || !layers[h] || !base.blobs[h.String()] {
+			return nil
+		}
+
+		delta.Blobs = append(delta.Blobs, h.String())
+		return os.Remove(path)
+	})
+	if err != nil {
+		return Delta{}, err
+	}
+
+	sort.Strings(delta.Blobs)
+
+	data, err := json.MarshalIndent(delta, "", "  ")
+	if err != nil {
+		return Delta{}, err
+	}
+
+	if err := ioutil.WriteFile(filepath.Join(dir, DeltaFile), data, 0600); err != nil {
+		return Delta{}, err
+	}
+
+	return delta, nil
+}
+
+// layoutLayers returns the layers of the images in a layout.
+func layoutLayers(root string) (map[v1.Hash]bool, error) {
+	descriptors, err := layoutDescriptors(root)
+	if err != nil {
+		return nil, err
+	}
+
+	layers := map[v1.Hash]bool{}
+
+	var addLayers func(desc v1.Descriptor) error
+	addLayers = func(desc v1.Descriptor) error {
+		data, err := ioutil.ReadFile(filepath.Join(root, "blobs", desc.Digest.Algorithm, desc.Digest.Hex))
+		if err != nil {
+			return err
+		}
+
+		switch desc.MediaType {
+		case types.OCIImageIndex, types.DockerManifestList:
+			var index v1.IndexManifest
+			if err := json.Unmarshal(data, &index); err != nil {
+				return fmt.Errorf("decode index %s: %w", desc.Digest, err)
+			}
+
+			for _, child := range index.Manifests {
+				if err := addLayers(child); err != nil {
+					return err
+				}
+			}
+		default:
+			var manifest v1.Manifest
+			if err := json.Unmarshal(data, &manifest); err != nil {
+				return fmt.Errorf("decode manifest %s: %w", desc.Digest, err)
+			}
+
+			for _, layer := range manifest.Layers {
+				layers[layer.Digest] = true
+			}
+		}
+
+		return nil
+	}
+
+	for _, desc := range descriptors {
+		if err := addLayers(desc); err != nil {
+			return nil, err
+		}
+	}
+
+	return layers, nil
+}
+
+// readDelta reads the delta file of an archive. It returns nil if the
+// archive is not a delta archive.
+func readDelta(root string) (*Delta, error) {
+	data, err := ioutil.ReadFile(filepath.Join(root, DeltaFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("read delta: %w", err)
+	}
+
+	var delta Delta
+	if err := json.Unmarshal(data, &delta); err != nil {
+		return nil, fmt.Errorf("decode delta: %w", err)
+	}
+
+	return &delta, nil
+}
+
+// baseBlobNames returns the names of the blobs a delta archive takes from
+// its base archive.
+func (d Delta) baseBlobNames() (map[string]bool, error) {
+	names := map[string]bool{}
+	for _, digest := range d.Blobs {
+		h, err := v1.NewHash(digest)
+		if err != nil {
+			return nil, fmt.Errorf("delta blob %q is invalid: %w", digest, err)
+		}
+
+		names[blobName(h)] = true
+	}
+
+	return names, nil
+}
+
+// missingBlobs returns the names of the blobs a delta archive takes from its
+// base archive that are not in the exploded archive.
+func (d Delta) missingBlobs(root string) (map[string]bool, error) {
+	names, err := d.baseBlobNames()
+	if err != nil {
+		return nil, err
+	}
+
+	missing := map[string]bool{}
+	for name := range names {
+		if _, err := os.Stat(filepath.Join(root, filepath.FromSlash(name))); err != nil {
+			if !os.IsNotExist(err) {
+				return nil, err
+			}
+			missing[name] = true
+		}
+	}
+
+	return missing, nil
+}
+
+// DeltaApplier applies exploded delta archives on a filesystem.
+type DeltaApplier struct{}
+
+var _ sheaf.DeltaApplier = &DeltaApplier{}
+
+// NewDeltaApplier creates an instance of DeltaApplier.
+func NewDeltaApplier() *DeltaApplier {
+	return &DeltaApplier{}
+}
+
+// RequiredBase returns the file name of the base archive of an exploded
+// delta archive if any of the blobs it takes from the base are missing.
+func (da *DeltaApplier) RequiredBase(b sheaf.Bundle) (string, error) {
+	delta, err := readDelta(b.Path())
+	if err != nil || delta == nil {
+		return "", err
+	}
+
+	missing, err := delta.missingBlobs(b.Path())
+	if err != nil {
+		return "", err
+	}
+
+	if len(missing) == 0 {
+		return "", nil
+	}
+
+	return delta.Base.Archive, nil
+}
+
+// ApplyDelta streams a base archive and adds the blobs an exploded delta
+// archive takes from it. The blobs are extracted next to the exploded
+// archive and only moved into it once the base archive's digest matches, so
+// a mismatched base leaves the exploded archive unchanged.
+func (da *DeltaApplier) ApplyDelta(b sheaf.Bundle, base string) error {
+	delta, err := readDelta(b.Path())
+	if err != nil {
+		return err
+	}
+
+	if delta == nil {
+		return fmt.Errorf("archive is not a delta archive")
+	}
+
+	missing, err := delta.missingBlobs(b.Path())
+	if err != nil {
+		return err
+	}
+
+	dir, err := ioutil.TempDir(filepath.Dir(b.Path()), "sheaf-delta")
+	if err != nil {
+		return fmt.Errorf("create temporary directory: %w", err)
+	}
+
+	defer func() {
+		if rErr := os.RemoveAll(dir); rErr != nil {
+			log.Printf("unable to remove temporary directory: %v", rErr)
+		}
+	}()
+
+	digest, err := archiver.ExtractFiles(base, dir, missing)
+	if err != nil {
+		return fmt.Errorf("read base archive: %w", err)
+	}
+
+	if digest != delta.Base.Digest {
+		return fmt.Errorf("%s (%s) is not the base archive of the delta; it was packed against %s (%s)",
+			base, digest, delta.Base.Archive, delta.Base.Digest)
+	}
+
+	var names []string
+	for name := range missing {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if _, err := os.Stat(filepath.Join(dir, filepath.FromSlash(name))); err != nil {
+			return fmt.Errorf("base archive %s does not contain blob %s", base, name)
+		}
+	}
+
+	for _, name := range names {
+		src := filepath.Join(dir, filepath.FromSlash(name))
+		dest := filepath.Join(b.Path(), filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+			return err
+		}
+
+		if err := os.Rename(src, dest); err != nil {
+			return fmt.Errorf("add blob %s: %w", name, err)
+		}
+	}
+
+	return nil
+}
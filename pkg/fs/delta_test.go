@@ -0,0 +1,235 @@
+/*
+ * Copyright 2020 Sheaf Authors
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package fs
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bryanl/sheaf/pkg/archiver"
+	"github.com/bryanl/sheaf/pkg/mocks"
+)
+
+func TestDeltaApplier(t *testing.T) {
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		mutate  func(t *testing.T, root string)
+		base    func(base, other string) string
+		wantErr string
+	}{
+		{
+			name: "in general",
+		},
+		{
+			name: "mismatched base",
+			base: func(base, other string) string {
+				return other
+			},
+			wantErr: "is not the base archive of the delta",
+		},
+		{
+			name: "base is missing a blob",
+			mutate: func(t *testing.T, root string) {
+				delta, err := readDelta(root)
+				require.NoError(t, err)
+
+				delta.Blobs = append(delta.Blobs, "sha256:"+checksum("missing"))
+				data, err := json.Marshal(delta)
+				require.NoError(t, err)
+				require.NoError(t, ioutil.WriteFile(filepath.Join(root, DeltaFile), data, 0600))
+			},
+			wantErr: "does not contain blob",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dir, err := ioutil.TempDir("", "sheaf-test")
+			require.NoError(t, err)
+
+			defer func() {
+				require.NoError(t, os.RemoveAll(dir))
+			}()
+
+			base := writeArchive(t, filepath.Join(dir, "base"), "shared", "removed")
+			other := writeArchive(t, filepath.Join(dir, "other"), "shared", "other")
+
+			root := filepath.Join(dir, "delta")
+			writeBlobs(t, root, "shared", "added")
+
+			ba, err := readBaseArchive(base)
+			require.NoError(t, err)
+
+			delta, err := stageDelta(root, ba)
+			require.NoError(t, err)
+			require.Equal(t, "base.tgz", delta.Base.Archive)
+			require.Equal(t, []string{"sha256:" + checksum("shared")}, delta.Blobs)
+
+			sharedPath := filepath.Join(layoutRootPath(root), "blobs", "sha256", checksum("shared"))
+			require.NoFileExists(t, sharedPath)
+			require.FileExists(t, filepath.Join(layoutRootPath(root), "blobs", "sha256", checksum("added")))
+
+			// Only layers are left out; the config is kept even though it is
+			// in the base archive.
+			require.FileExists(t, filepath.Join(layoutRootPath(root), "blobs", "sha256", checksum(deltaTestConfig)))
+
+			if test.mutate != nil {
+				test.mutate(t, root)
+			}
+
+			require.NoError(t, signArchive(root, privateKey))
+
+			keyPath := filepath.Join(dir, "key.pub")
+			writePublicKey(t, keyPath, publicKey)
+
+			controller := gomock.NewController(t)
+			defer controller.Finish()
+
+			b := mocks.NewMockBundle(controller)
+			b.EXPECT().Path().Return(root).AnyTimes()
+
+			sv := NewSignatureVerifier(keyPath)
+			require.NoError(t, sv.VerifySignature(b))
+
+			da := NewDeltaApplier()
+
+			required, err := da.RequiredBase(b)
+			require.NoError(t, err)
+			require.Equal(t, "base.tgz", required)
+
+			basePath := base
+			if test.base != nil {
+				basePath = test.base(base, other)
+			}
+
+			err = da.ApplyDelta(b, basePath)
+			if test.wantErr != "" {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), test.wantErr)
+
+				// A failed delta leaves the exploded archive unchanged.
+				require.NoFileExists(t, sharedPath)
+				return
+			}
+
+			require.NoError(t, err)
+
+			data, err := ioutil.ReadFile(sharedPath)
+			require.NoError(t, err)
+			require.Equal(t, "shared", string(data))
+			require.NoFileExists(t, filepath.Join(layoutRootPath(root), "blobs", "sha256", checksum("removed")))
+
+			required, err = da.RequiredBase(b)
+			require.NoError(t, err)
+			require.Empty(t, required)
+
+			// The signature covers the delta, so it is still valid.
+			require.NoError(t, sv.VerifySignature(b))
+		})
+	}
+
+	t.Run("not a delta archive", func(t *testing.T) {
+		dir, err := ioutil.TempDir("", "sheaf-test")
+		require.NoError(t, err)
+
+		defer func() {
+			require.NoError(t, os.RemoveAll(dir))
+		}()
+
+		controller := gomock.NewController(t)
+		defer controller.Finish()
+
+		b := mocks.NewMockBundle(controller)
+		b.EXPECT().Path().Return(dir).AnyTimes()
+
+		da := NewDeltaApplier()
+
+		required, err := da.RequiredBase(b)
+		require.NoError(t, err)
+		require.Empty(t, required)
+
+		err = da.ApplyDelta(b, filepath.Join(dir, "base.tgz"))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "not a delta archive")
+	})
+}
+
+func checksum(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
+
+// deltaTestConfig is the config of the image written by writeBlobs.
+const deltaTestConfig = `{"architecture":"amd64","os":"linux"}`
+
+// writeBlobs writes an archive root with a bundle config and an image layout
+// with one image whose layers are the contents.
+func writeBlobs(t *testing.T, root string, contents ...string) {
+	blobsPath := filepath.Join(layoutRootPath(root), "blobs", "sha256")
+	require.NoError(t, os.MkdirAll(blobsPath, 0700))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(root, "bundle.json"), []byte(`{"name":"bundle"}`), 0600))
+
+	writeBlob := func(content string) v1.Descriptor {
+		require.NoError(t, ioutil.WriteFile(filepath.Join(blobsPath, checksum(content)), []byte(content), 0600))
+		return v1.Descriptor{
+			MediaType: types.OCILayer,
+			Size:      int64(len(content)),
+			Digest:    v1.Hash{Algorithm: "sha256", Hex: checksum(content)},
+		}
+	}
+
+	manifest := v1.Manifest{
+		SchemaVersion: 2,
+		MediaType:     types.OCIManifestSchema1,
+		Config:        writeBlob(deltaTestConfig),
+	}
+	manifest.Config.MediaType = types.OCIConfigJSON
+
+	for _, content := range contents {
+		manifest.Layers = append(manifest.Layers, writeBlob(content))
+	}
+
+	data, err := json.Marshal(manifest)
+	require.NoError(t, err)
+
+	desc := writeBlob(string(data))
+	desc.MediaType = types.OCIManifestSchema1
+
+	data, err = json.Marshal(v1.IndexManifest{
+		SchemaVersion: 2,
+		Manifests:     []v1.Descriptor{desc},
+	})
+	require.NoError(t, err)
+	require.NoError(t, ioutil.WriteFile(filepath.Join(layoutRootPath(root), "index.json"), data, 0600))
+}
+
+// writeArchive writes an archive with blobs and returns its path.
+func writeArchive(t *testing.T, root string, contents ...string) string {
+	writeBlobs(t, root, contents...)
+
+	path := root + ".tgz"
+	f, err := os.Create(path)
+	require.NoError(t, err)
+	require.NoError(t, archiver.New().Archive(root, f))
+	require.NoError(t, f.Close())
+
+	return path
+}
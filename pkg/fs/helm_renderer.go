@@ -0,0 +1,116 @@
+/*
+ * Copyright 2020 Sheaf Authors
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package fs
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/bryanl/sheaf/pkg/sheaf"
+)
+
+// HelmRendererOption is a functional option for configuring HelmRenderer.
+type HelmRendererOption func(hr HelmRenderer) HelmRenderer
+
+// HelmRendererBinary sets the helm binary.
+func HelmRendererBinary(binary string) HelmRendererOption {
+	return func(hr HelmRenderer) HelmRenderer {
+		hr.binary = binary
+		return hr
+	}
+}
+
+// HelmRenderer renders Helm charts with `helm template`. Charts are rendered
+// offline; chart dependencies must be vendored in the chart.
+type HelmRenderer struct {
+	binary string
+	run    func(name string, args ...string) ([]byte, error)
+}
+
+var _ sheaf.ManifestRenderer = &HelmRenderer{}
+
+// NewHelmRenderer creates an instance of HelmRenderer.
+func NewHelmRenderer(options ...HelmRendererOption) *HelmRenderer {
+	hr := HelmRenderer{
+		binary: "helm",
+		run:    runCommand,
+	}
+
+	for _, option := range options {
+		hr = option(hr)
+	}
+
+	return &hr
+}
+
+// Render renders a Helm chart manifest source.
+func (hr HelmRenderer) Render(source sheaf.ManifestSource) ([]byte, error) {
+	if source.Type != sheaf.HelmManifestSource || source.Helm == nil {
+		return nil, fmt.Errorf("%s is not a helm manifest source", source.Path)
+	}
+
+	dir, err := ioutil.TempDir("", "sheaf")
+	if err != nil {
+		return nil, fmt.Errorf("create temporary directory: %w", err)
+	}
+
+	defer func() {
+		if rErr := os.RemoveAll(dir); rErr != nil {
+			log.Printf("unable to remove temporary directory: %v", rErr)
+		}
+	}()
+
+	args := []string{"template", source.Helm.ReleaseName, source.Path}
+	if source.Helm.Namespace != "" {
+		args = append(args, "--namespace", source.Helm.Namespace)
+	}
+
+	for i, values := range source.Helm.Values {
+		// JSON is valid YAML, so values can be written without conversion.
+		data, err := json.Marshal(values)
+		if err != nil {
+			return nil, fmt.Errorf("encode values: %w", err)
+		}
+
+		valuesPath := filepath.Join(dir, fmt.Sprintf("values-%d.yaml", i))
+		if err := ioutil.WriteFile(valuesPath, data, 0600); err != nil {
+			return nil, fmt.Errorf("write values: %w", err)
+		}
+
+		args = append(args, "--values", valuesPath)
+	}
+
+	out, err := hr.run(hr.binary, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s %s: %w", hr.binary, strings.Join(args[:3], " "), err)
+	}
+
+	return out, nil
+}
+
+// runCommand runs a command and returns its standard output. Standard error
+// is included in the returned error.
+func runCommand(name string, args ...string) ([]byte, error) {
+	var stdout, stderr bytes.Buffer
+
+	cmd := exec.Command(name, args...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("%w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	return stdout.Bytes(), nil
+}
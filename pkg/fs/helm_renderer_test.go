@@ -0,0 +1,118 @@
+// +build !integration
+
+/*
+ * Copyright 2020 Sheaf Authors
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package fs
+
+import (
+	"fmt"
+	"io/ioutil"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/bryanl/sheaf/pkg/sheaf"
+)
+
+func TestHelmRenderer_Render(t *testing.T) {
+	cases := []struct {
+		name       string
+		source     sheaf.ManifestSource
+		out        []byte
+		runErr     error
+		wantArgs   []string
+		wantValues []string
+		wantErr    bool
+	}{
+		{
+			name: "in general",
+			source: sheaf.ManifestSource{
+				Type:     sheaf.HelmManifestSource,
+				Path:     "/charts/app",
+				Manifest: "app.yaml",
+				Helm: &sheaf.HelmOptions{
+					ReleaseName: "app",
+					Namespace:   "apps",
+					Values: []map[string]interface{}{
+						{"replicas": 2},
+						{"image": map[string]interface{}{"tag": "1.0"}},
+					},
+				},
+			},
+			out:        []byte("kind: Deployment"),
+			wantArgs:   []string{"template", "app", "/charts/app", "--namespace", "apps"},
+			wantValues: []string{`{"replicas":2}`, `{"image":{"tag":"1.0"}}`},
+		},
+		{
+			name: "without values",
+			source: sheaf.ManifestSource{
+				Type:     sheaf.HelmManifestSource,
+				Path:     "/charts/app",
+				Manifest: "app.yaml",
+				Helm: &sheaf.HelmOptions{
+					ReleaseName: "app",
+				},
+			},
+			out:      []byte("kind: Deployment"),
+			wantArgs: []string{"template", "app", "/charts/app"},
+		},
+		{
+			name: "helm fails",
+			source: sheaf.ManifestSource{
+				Type:     sheaf.HelmManifestSource,
+				Path:     "/charts/app",
+				Manifest: "app.yaml",
+				Helm: &sheaf.HelmOptions{
+					ReleaseName: "app",
+				},
+			},
+			runErr:   fmt.Errorf("error"),
+			wantArgs: []string{"template", "app", "/charts/app"},
+			wantErr:  true,
+		},
+		{
+			name: "not a helm source",
+			source: sheaf.ManifestSource{
+				Type: "other",
+				Path: "/charts/app",
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			hr := NewHelmRenderer(HelmRendererBinary("helm3"))
+			hr.run = func(name string, args ...string) ([]byte, error) {
+				require.Equal(t, "helm3", name)
+
+				var values []string
+				for i := 0; i < len(args); i++ {
+					if args[i] != "--values" {
+						continue
+					}
+					data, err := ioutil.ReadFile(args[i+1])
+					require.NoError(t, err)
+					values = append(values, string(data))
+				}
+
+				require.Equal(t, tc.wantArgs, args[:len(tc.wantArgs)])
+				require.Equal(t, tc.wantValues, values)
+				return tc.out, tc.runErr
+			}
+
+			got, err := hr.Render(tc.source)
+			if tc.wantErr {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tc.out, got)
+		})
+	}
+}
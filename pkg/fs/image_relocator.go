@@ -9,9 +9,12 @@ package fs
 import (
 	"fmt"
 	"path/filepath"
+	"sync"
+	"time"
 
+	"github.com/google/go-containerregistry/pkg/v1/layout"
 	"github.com/pivotal/image-relocation/pkg/image"
-	"github.com/pivotal/image-relocation/pkg/pathmapping"
+	"go.uber.org/multierr"
 
 	"github.com/bryanl/sheaf/pkg/reporter"
 	"github.com/bryanl/sheaf/pkg/sheaf"
@@ -36,11 +39,86 @@ func ImageRelocatorDryRun(dryRun bool) ImageRelocatorOption {
 	}
 }
 
-// ImageRelocator relocates images to a registry.
+// ImageRelocatorReporter configures the reporter.
+func ImageRelocatorReporter(r reporter.Reporter) ImageRelocatorOption {
+	return func(is ImageRelocator) ImageRelocator {
+		is.reporter = r
+		return is
+	}
+}
+
+// ImageRelocatorConcurrency configures the number of images pushed at the same time.
+func ImageRelocatorConcurrency(concurrency int) ImageRelocatorOption {
+	return func(is ImageRelocator) ImageRelocator {
+		is.concurrency = concurrency
+		return is
+	}
+}
+
+// ImageRelocatorRetry configures how many times a push is attempted and the
+// initial delay between attempts. The delay doubles after each failed attempt.
+func ImageRelocatorRetry(attempts int, backoff time.Duration) ImageRelocatorOption {
+	return func(is ImageRelocator) ImageRelocator {
+		is.retryAttempts = attempts
+		is.retryBackoff = backoff
+		return is
+	}
+}
+
+// ImageRelocatorImageResolver configures the image resolver used to find
+// images that already exist at their new location.
+func ImageRelocatorImageResolver(ir sheaf.ImageResolver) ImageRelocatorOption {
+	return func(is ImageRelocator) ImageRelocator {
+		is.imageResolver = ir
+		return is
+	}
+}
+
+// ImageRelocatorOutput configures ImageRelocator to write images to a file
+// instead of a registry. The output is an OCI image layout directory prefixed
+// with LayoutRelocationOutput, or a docker save compatible tarball prefixed
+// with TarballRelocationOutput.
+func ImageRelocatorOutput(output string) ImageRelocatorOption {
+	return func(is ImageRelocator) ImageRelocator {
+		is.output = output
+		return is
+	}
+}
+
+// ImageRelocatorPlatform configures ImageRelocator to write the image of a
+// platform, in the form os/arch[/variant], for each image index relocated to
+// a docker save compatible tarball. Tarballs can't hold image indexes.
+func ImageRelocatorPlatform(platform string) ImageRelocatorOption {
+	return func(is ImageRelocator) ImageRelocator {
+		is.platform = platform
+		return is
+	}
+}
+
+const (
+	// DefaultImageRelocatorConcurrency is the default number of images pushed
+	// at the same time.
+	DefaultImageRelocatorConcurrency = 4
+	// DefaultImageRelocatorRetryAttempts is the default number of times a push
+	// is attempted.
+	DefaultImageRelocatorRetryAttempts = 4
+	// DefaultImageRelocatorRetryBackoff is the default delay before a push is
+	// attempted again.
+	DefaultImageRelocatorRetryBackoff = time.Second
+)
+
+// ImageRelocator relocates images to a registry, an OCI image layout, or a
+// docker save compatible tarball.
 type ImageRelocator struct {
 	layoutFactory LayoutFactory
 	reporter      reporter.Reporter
+	imageResolver sheaf.ImageResolver
+	output        string
+	platform      string
 	dryRun        bool
+	concurrency   int
+	retryAttempts int
+	retryBackoff  time.Duration
 }
 
 var _ sheaf.ImageRelocator = &ImageRelocator{}
@@ -50,6 +128,9 @@ func NewImageRelocator(options ...ImageRelocatorOption) *ImageRelocator {
 	is := ImageRelocator{
 		layoutFactory: DefaultLayoutFactory(),
 		reporter:      reporter.Default,
+		concurrency:   DefaultImageRelocatorConcurrency,
+		retryAttempts: DefaultImageRelocatorRetryAttempts,
+		retryBackoff:  DefaultImageRelocatorRetryBackoff,
 	}
 
 	for _, option := range options {
@@ -59,37 +140,141 @@ func NewImageRelocator(options ...ImageRelocatorOption) *ImageRelocator {
 	return &is
 }
 
-// Relocate relocates images to a registry given a prefix.
-func (i ImageRelocator) Relocate(rootPath, prefix string, images []image.Name, iw sheaf.ImageWriter) error {
+// relocation is an image in a layout and its new name.
+type relocation struct {
+	imageName    image.Name
+	imageDigest  image.Digest
+	newImageName image.Name
+}
+
+// Relocate relocates images to the names created by a mapping. Images excluded by
+// the bundle config are skipped. Images are pushed concurrently, and failed
+// pushes are retried. Images whose digest already exists at the new location
+// are not pushed again, so relocating again after a failure resumes where the
+// failed run stopped. If an output is configured, images are written to it
+// using their new names instead of being pushed.
+func (i ImageRelocator) Relocate(rootPath string, mapping sheaf.ImageMapping, config sheaf.BundleConfig, images []image.Name, iw sheaf.ImageWriter) (sheaf.RelocationMapping, error) {
 	layoutPath := filepath.Join(rootPath)
 	l, err := i.layoutFactory(layoutPath)
 	if err != nil {
-		return fmt.Errorf("create layout: %w", err)
+		return sheaf.RelocationMapping{}, fmt.Errorf("create layout: %w", err)
 	}
 
+	exclusions := sheaf.ImageExclusions(config.GetImageExclusions())
+
+	var relocations []relocation
 	for _, imageName := range images {
+		if exclusions.Excludes(imageName) {
+			i.reporter.Reportf("Skipping excluded image %s", imageName)
+			continue
+		}
+
 		imageDigest, err := l.Find(imageName)
 		if err != nil {
-			return fmt.Errorf("find image digest for ref %s: %w", imageName.String(), err)
+			return sheaf.RelocationMapping{}, fmt.Errorf("find image digest for ref %s: %w", imageName.String(), err)
 		}
 
-		newImageName, err := pathmapping.FlattenRepoPathPreserveTagDigest(prefix, imageName)
+		newImageName, err := mapping(imageName)
 		if err != nil {
-			return fmt.Errorf("create relocated image name: %w", err)
+			return sheaf.RelocationMapping{}, fmt.Errorf("create relocated image name: %w", err)
 		}
 
-		i.printImageRelocation(imageName.String(), newImageName.String(), i.dryRun)
-		if i.dryRun {
-			continue
-		}
+		relocations = append(relocations, relocation{
+			imageName:    imageName,
+			imageDigest:  imageDigest,
+			newImageName: newImageName,
+		})
+	}
 
-		// TODO: need to support insecure images
-		if err := l.Push(imageDigest, newImageName); err != nil {
-			return fmt.Errorf("push %s: %w", newImageName.String(), err)
+	var relocatedImages []sheaf.RelocatedImage
+	for _, r := range relocations {
+		relocatedImages = append(relocatedImages, sheaf.RelocatedImage{
+			Original:  r.imageName.String(),
+			Relocated: r.newImageName.String(),
+			Digest:    r.imageDigest.String(),
+		})
+	}
+	relocationMapping := sheaf.NewRelocationMapping(relocatedImages)
+
+	if i.dryRun {
+		for _, r := range relocations {
+			i.printImageRelocation(r.imageName.String(), r.newImageName.String(), true)
 		}
+		return relocationMapping, nil
+	}
+
+	target, err := i.newRelocationTarget(i.output)
+	if err != nil {
+		return sheaf.RelocationMapping{}, err
+	}
+
+	src := relocationSource{layout: l, path: layout.Path(layoutRootPath(rootPath))}
+
+	workers := i.concurrency
+	if workers < 1 {
+		workers = 1
+	}
+
+	var (
+		mu   sync.Mutex
+		wg   sync.WaitGroup
+		errs error
+	)
+
+	queue := make(chan relocation)
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for r := range queue {
+				exists, err := i.relocate(src, target, r)
+
+				mu.Lock()
+				switch {
+				case err != nil:
+					errs = multierr.Append(errs, fmt.Errorf("relocate %s: %w", r.newImageName.String(), err))
+				case exists:
+					i.reporter.Reportf("Image %s already exists at %s", r.imageName, r.newImageName)
+				default:
+					i.printImageRelocation(r.imageName.String(), r.newImageName.String(), false)
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+	for _, r := range relocations {
+		queue <- r
+	}
+	close(queue)
+	wg.Wait()
+
+	if errs != nil {
+		return sheaf.RelocationMapping{}, errs
+	}
+
+	if err := target.close(); err != nil {
+		return sheaf.RelocationMapping{}, err
+	}
+
+	return relocationMapping, nil
+}
+
+// relocate writes an image to its new name in a target. It returns true if
+// the image already exists at its new name.
+func (i ImageRelocator) relocate(src relocationSource, target relocationTarget, r relocation) (bool, error) {
+	// Pinned images keep their tag. Write by tag; the digest is preserved.
+	name := r.newImageName
+	if name.Tag() != "" {
+		name = name.WithoutDigest()
+	}
+
+	if target.exists(name, r.imageDigest) {
+		return true, nil
 	}
 
-	return nil
+	return false, target.write(src, r.imageDigest, name)
 }
 
 func (i ImageRelocator) printImageRelocation(oldName, newName string, isDryRun bool) {
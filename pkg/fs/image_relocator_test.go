@@ -0,0 +1,385 @@
+/*
+ * Copyright 2020 Sheaf Authors
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package fs
+
+import (
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/registry"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/layout"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/tarball"
+	"github.com/pivotal/image-relocation/pkg/image"
+	"github.com/pivotal/image-relocation/pkg/pathmapping"
+	"github.com/pivotal/image-relocation/pkg/registry/ggcr"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bryanl/sheaf/pkg/mocks"
+	"github.com/bryanl/sheaf/pkg/remote"
+	"github.com/bryanl/sheaf/pkg/reporter"
+	"github.com/bryanl/sheaf/pkg/sheaf"
+)
+
+// testRegistry is an in-process registry that counts manifest pushes and can
+// reject requests for repositories.
+type testRegistry struct {
+	handler http.Handler
+
+	mu       sync.Mutex
+	pushes   int
+	rejected string
+	failures int
+}
+
+func (tr *testRegistry) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	tr.mu.Lock()
+	isManifestPush := r.Method == http.MethodPut && strings.Contains(r.URL.Path, "/manifests/")
+	if tr.rejected != "" && strings.Contains(r.URL.Path, tr.rejected) {
+		tr.mu.Unlock()
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	if isManifestPush && tr.failures > 0 {
+		tr.failures--
+		tr.mu.Unlock()
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+	if isManifestPush {
+		tr.pushes++
+	}
+	tr.mu.Unlock()
+
+	tr.handler.ServeHTTP(w, r)
+}
+
+func (tr *testRegistry) reset(rejected string, failures int) {
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+
+	tr.pushes = 0
+	tr.rejected = rejected
+	tr.failures = failures
+}
+
+// genRelocatorLayout writes an archive layout with random images to root.
+func genRelocatorLayout(t *testing.T, root string) []image.Name {
+	p, err := layout.Write(layoutRootPath(root), empty.Index)
+	require.NoError(t, err)
+
+	var names []image.Name
+	for i := 1; i <= 3; i++ {
+		n, err := image.NewName(fmt.Sprintf("app%d:1.0", i))
+		require.NoError(t, err)
+
+		img, err := random.Image(256, 1)
+		require.NoError(t, err)
+
+		require.NoError(t, p.AppendImage(img, layout.WithAnnotations(map[string]string{
+			refNameAnnotation: n.String(),
+		})))
+
+		names = append(names, n)
+	}
+
+	return names
+}
+
+func TestImageRelocator_Relocate(t *testing.T) {
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	tr := &testRegistry{handler: registry.New(registry.Logger(log.New(ioutil.Discard, "", 0)))}
+	server := httptest.NewServer(tr)
+	defer server.Close()
+
+	u, err := url.Parse(server.URL)
+	require.NoError(t, err)
+	prefix := fmt.Sprintf("%s/relocated", u.Host)
+
+	root, err := ioutil.TempDir("", "sheaf-test")
+	require.NoError(t, err)
+
+	defer func() {
+		require.NoError(t, os.RemoveAll(root))
+	}()
+
+	names := genRelocatorLayout(t, root)
+
+	config := mocks.NewMockBundleConfig(controller)
+	config.EXPECT().GetImageExclusions().Return(nil).AnyTimes()
+
+	resolver := remote.NewImageResolver(remote.WithInsecureRegistry(true))
+
+	ir := NewImageRelocator(
+		ImageRelocatorLayoutFactory(DefaultLayoutFactory(DefaultLayoutFactoryInsecureSkipVerify())),
+		ImageRelocatorReporter(reporter.Nop{}),
+		ImageRelocatorImageResolver(resolver),
+		ImageRelocatorConcurrency(2),
+		ImageRelocatorRetry(2, time.Millisecond))
+
+	imageMapping, err := sheaf.MappingStrategy{}.ImageMapping(prefix)
+	require.NoError(t, err)
+
+	relocate := func() error {
+		_, err := ir.Relocate(root, imageMapping, config, names, nil)
+		return err
+	}
+
+	// app2 can't be pushed, but the other images are relocated.
+	tr.reset("app2", 0)
+	err = relocate()
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "app2")
+	require.Equal(t, 2, tr.pushes)
+
+	// Relocating again only pushes the image that failed. A transient failure
+	// is retried.
+	tr.reset("", 1)
+	require.NoError(t, relocate())
+	require.Equal(t, 1, tr.pushes)
+
+	// Every image already exists.
+	tr.reset("", 0)
+	mapping, err := ir.Relocate(root, imageMapping, config, names, nil)
+	require.NoError(t, err)
+	require.Equal(t, 0, tr.pushes)
+	require.Len(t, mapping.Images, len(names))
+
+	l, err := layout.FromPath(layoutRootPath(root))
+	require.NoError(t, err)
+	ii, err := l.ImageIndex()
+	require.NoError(t, err)
+	indexManifest, err := ii.IndexManifest()
+	require.NoError(t, err)
+
+	for i, n := range names {
+		newName, err := pathmapping.FlattenRepoPathPreserveTagDigest(prefix, n)
+		require.NoError(t, err)
+
+		got, err := resolver.Resolve(newName)
+		require.NoError(t, err)
+		require.Equal(t, indexManifest.Manifests[i].Digest.String(), got.String())
+
+		require.Equal(t, sheaf.RelocatedImage{
+			Original:  n.String(),
+			Relocated: newName.String(),
+			Digest:    got.String(),
+		}, mapping.Images[i])
+	}
+}
+
+func TestImageRelocator_Relocate_output(t *testing.T) {
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	root, err := ioutil.TempDir("", "sheaf-test")
+	require.NoError(t, err)
+
+	defer func() {
+		require.NoError(t, os.RemoveAll(root))
+	}()
+
+	names := genRelocatorLayout(t, filepath.Join(root, "archive"))
+
+	config := mocks.NewMockBundleConfig(controller)
+	config.EXPECT().GetImageExclusions().Return(nil).AnyTimes()
+
+	const prefix = "registry.example.com/relocated"
+	imageMapping, err := sheaf.MappingStrategy{Name: sheaf.BasenameOnlyMappingStrategy}.ImageMapping(prefix)
+	require.NoError(t, err)
+
+	relocate := func(output string) sheaf.RelocationMapping {
+		ir := NewImageRelocator(
+			ImageRelocatorReporter(reporter.Nop{}),
+			ImageRelocatorOutput(output))
+
+		mapping, err := ir.Relocate(filepath.Join(root, "archive"), imageMapping, config, names, nil)
+		require.NoError(t, err)
+		require.Len(t, mapping.Images, len(names))
+		return mapping
+	}
+
+	t.Run("oci layout", func(t *testing.T) {
+		dir := filepath.Join(root, "layout")
+
+		// Relocating again skips images that are already in the layout.
+		relocate(LayoutRelocationOutput + dir)
+		mapping := relocate(LayoutRelocationOutput + dir)
+
+		ii, err := layout.ImageIndexFromPath(dir)
+		require.NoError(t, err)
+		indexManifest, err := ii.IndexManifest()
+		require.NoError(t, err)
+		require.Len(t, indexManifest.Manifests, len(names))
+
+		l, err := ggcr.NewRegistryClient().ReadLayout(dir)
+		require.NoError(t, err)
+
+		for _, relocated := range mapping.Images {
+			n, err := image.NewName(relocated.Relocated)
+			require.NoError(t, err)
+
+			got, err := l.Find(n)
+			require.NoError(t, err)
+			require.Equal(t, relocated.Digest, got.String())
+		}
+	})
+
+	t.Run("docker archive", func(t *testing.T) {
+		path := filepath.Join(root, "images.tar")
+		mapping := relocate(TarballRelocationOutput + path)
+
+		src, err := layout.FromPath(layoutRootPath(filepath.Join(root, "archive")))
+		require.NoError(t, err)
+
+		for _, relocated := range mapping.Images {
+			tag, err := name.NewTag(relocated.Relocated)
+			require.NoError(t, err)
+
+			img, err := tarball.ImageFromPath(path, &tag)
+			require.NoError(t, err)
+
+			h, err := v1.NewHash(relocated.Digest)
+			require.NoError(t, err)
+			original, err := src.Image(h)
+			require.NoError(t, err)
+
+			want, err := original.ConfigName()
+			require.NoError(t, err)
+			got, err := img.ConfigName()
+			require.NoError(t, err)
+			require.Equal(t, want, got)
+		}
+	})
+
+	t.Run("unknown output", func(t *testing.T) {
+		ir := NewImageRelocator(
+			ImageRelocatorReporter(reporter.Nop{}),
+			ImageRelocatorOutput("unknown:"+root))
+
+		_, err := ir.Relocate(filepath.Join(root, "archive"), imageMapping, config, names, nil)
+		require.Error(t, err)
+	})
+}
+
+func TestImageRelocator_Relocate_outputPlatform(t *testing.T) {
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	root, err := ioutil.TempDir("", "sheaf-test")
+	require.NoError(t, err)
+
+	defer func() {
+		require.NoError(t, os.RemoveAll(root))
+	}()
+
+	amd64, err := random.Image(256, 1)
+	require.NoError(t, err)
+	arm64, err := random.Image(256, 1)
+	require.NoError(t, err)
+
+	ii := mutate.AppendManifests(empty.Index,
+		mutate.IndexAddendum{
+			Add:        amd64,
+			Descriptor: v1.Descriptor{Platform: &v1.Platform{OS: "linux", Architecture: "amd64"}},
+		},
+		mutate.IndexAddendum{
+			Add:        arm64,
+			Descriptor: v1.Descriptor{Platform: &v1.Platform{OS: "linux", Architecture: "arm64", Variant: "v8"}},
+		},
+	)
+
+	n, err := image.NewName("app:1.0")
+	require.NoError(t, err)
+
+	p, err := layout.Write(layoutRootPath(filepath.Join(root, "archive")), empty.Index)
+	require.NoError(t, err)
+	require.NoError(t, p.AppendIndex(ii, layout.WithAnnotations(map[string]string{
+		refNameAnnotation: n.String(),
+	})))
+
+	config := mocks.NewMockBundleConfig(controller)
+	config.EXPECT().GetImageExclusions().Return(nil).AnyTimes()
+
+	imageMapping, err := sheaf.MappingStrategy{Name: sheaf.BasenameOnlyMappingStrategy}.ImageMapping("registry.example.com/relocated")
+	require.NoError(t, err)
+
+	cases := []struct {
+		name     string
+		platform string
+		want     v1.Image
+		wantErr  bool
+	}{
+		{
+			name:     "selected platform",
+			platform: "linux/arm64",
+			want:     arm64,
+		},
+		{
+			name:    "no platform",
+			wantErr: true,
+		},
+		{
+			name:     "no matching platform",
+			platform: "windows/amd64",
+			wantErr:  true,
+		},
+		{
+			name:     "invalid platform",
+			platform: "linux",
+			wantErr:  true,
+		},
+	}
+
+	for i, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			path := filepath.Join(root, fmt.Sprintf("images-%d.tar", i))
+
+			ir := NewImageRelocator(
+				ImageRelocatorReporter(reporter.Nop{}),
+				ImageRelocatorOutput(TarballRelocationOutput+path),
+				ImageRelocatorPlatform(tc.platform))
+
+			mapping, err := ir.Relocate(filepath.Join(root, "archive"), imageMapping, config, []image.Name{n}, nil)
+			if tc.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, mapping.Images, 1)
+
+			tag, err := name.NewTag(mapping.Images[0].Relocated)
+			require.NoError(t, err)
+
+			img, err := tarball.ImageFromPath(path, &tag)
+			require.NoError(t, err)
+
+			want, err := tc.want.ConfigName()
+			require.NoError(t, err)
+			got, err := img.ConfigName()
+			require.NoError(t, err)
+			require.Equal(t, want, got)
+		})
+	}
+}
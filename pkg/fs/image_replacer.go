@@ -7,9 +7,6 @@
 package fs
 
 import (
-	"github.com/pivotal/image-relocation/pkg/image"
-	"github.com/pivotal/image-relocation/pkg/pathmapping"
-
 	"github.com/bryanl/sheaf/pkg/manifest"
 	"github.com/bryanl/sheaf/pkg/sheaf"
 )
@@ -27,12 +24,11 @@ func NewImageReplacer() *ImageReplacer {
 }
 
 // Replace replaces container images found in a bundle manifest.
-func (i ImageReplacer) Replace(m sheaf.BundleManifest, config sheaf.BundleConfig, prefix string) ([]byte, error) {
-	if prefix == "" {
+func (i ImageReplacer) Replace(m sheaf.BundleManifest, config sheaf.BundleConfig, mapping sheaf.ImageMapping) ([]byte, error) {
+	if mapping == nil {
 		return m.Data, nil
 	}
 
-	return manifest.MapContainer(m.Data, config.GetUserDefinedImages(), func(originalImage image.Name) (image.Name, error) {
-		return pathmapping.FlattenRepoPathPreserveTagDigest(prefix, originalImage)
-	})
+	return manifest.MapContainer(m.Data, config.GetUserDefinedImages(), mapping,
+		manifest.WithCatalog(sheaf.CatalogUserDefinedImages(config.GetCatalog())))
 }
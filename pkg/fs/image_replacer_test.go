@@ -12,6 +12,8 @@ import (
 	"testing"
 
 	"github.com/golang/mock/gomock"
+	"github.com/pivotal/image-relocation/pkg/image"
+	"github.com/pivotal/image-relocation/pkg/pathmapping"
 	"github.com/stretchr/testify/require"
 
 	"github.com/bryanl/sheaf/pkg/mocks"
@@ -22,17 +24,16 @@ func TestImageReplacer_Replace(t *testing.T) {
 	cases := []struct {
 		name     string
 		data     string
-		prefix   string
+		mapping  sheaf.ImageMapping
 		init     func(t *testing.T, mockBundleConfig *mocks.MockBundleConfig)
 		expected string
 	}{
 		{
-			name: "empty prefix",
+			name: "nil mapping",
 			data: `spec:
   containers:
   - name: a
     image: b:1`,
-			prefix: "",
 			init: func(t *testing.T, mockBundleConfig *mocks.MockBundleConfig) {
 			},
 			expected: `spec:
@@ -41,14 +42,17 @@ func TestImageReplacer_Replace(t *testing.T) {
     image: b:1`,
 		},
 		{
-			name: "non-empty prefix",
+			name: "with mapping",
 			data: `spec:
   containers:
   - name: a
     image: b:1`,
-			prefix: "example.com/user",
+			mapping: func(originalImage image.Name) (image.Name, error) {
+				return pathmapping.FlattenRepoPathPreserveTagDigest("example.com/user", originalImage)
+			},
 			init: func(t *testing.T, mockBundleConfig *mocks.MockBundleConfig) {
 				mockBundleConfig.EXPECT().GetUserDefinedImages().Return(nil)
+				mockBundleConfig.EXPECT().GetCatalog().Return(sheaf.CatalogSelection{})
 			},
 			expected: `spec:
   containers:
@@ -72,7 +76,7 @@ func TestImageReplacer_Replace(t *testing.T) {
 			actual, err := imageReplacer.Replace(sheaf.BundleManifest{
 				ID:   "test",
 				Data: []byte(tc.data),
-			}, mockBundleConfig, tc.prefix)
+			}, mockBundleConfig, tc.mapping)
 			require.NoError(t, err)
 			require.Equal(t, tc.expected, string(actual))
 		})
@@ -7,8 +7,11 @@
 package fs
 
 import (
+	"fmt"
 	"sort"
 
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/types"
 	ociv1 "github.com/opencontainers/image-spec/specs-go/v1"
 
 	"github.com/bryanl/sheaf/pkg/codec"
@@ -70,10 +73,16 @@ func (s *ImageService) List() ([]sheaf.BundleImage, error) {
 			continue
 		}
 
+		platforms, err := s.platforms(manifest)
+		if err != nil {
+			return nil, fmt.Errorf("read platforms of %s: %w", refName, err)
+		}
+
 		image := sheaf.BundleImage{
 			Name:      refName,
 			Digest:    manifest.Digest.String(),
 			MediaType: manifest.MediaType,
+			Platforms: platforms,
 		}
 
 		list = append(list, image)
@@ -85,3 +94,62 @@ func (s *ImageService) List() ([]sheaf.BundleImage, error) {
 
 	return list, nil
 }
+
+// platforms returns the platforms of an image. The platforms of an image index
+// are the platforms of its manifests, and the platform of an image manifest is
+// in its config.
+func (s *ImageService) platforms(desc ociv1.Descriptor) ([]string, error) {
+	data, err := s.ArtifactsService.Blob(desc.Digest.String())
+	if err != nil {
+		return nil, err
+	}
+
+	switch types.MediaType(desc.MediaType) {
+	case types.OCIImageIndex, types.DockerManifestList:
+		var index ociv1.Index
+		if err := s.Decoder.Decode(data, &index); err != nil {
+			return nil, err
+		}
+
+		seen := map[string]bool{}
+		var platforms []string
+		for _, m := range index.Manifests {
+			if m.Platform == nil {
+				continue
+			}
+
+			p := formatPlatform(v1.Platform{
+				OS:           m.Platform.OS,
+				Architecture: m.Platform.Architecture,
+				Variant:      m.Platform.Variant,
+			})
+			if !seen[p] {
+				seen[p] = true
+				platforms = append(platforms, p)
+			}
+		}
+
+		return platforms, nil
+	default:
+		var manifest ociv1.Manifest
+		if err := s.Decoder.Decode(data, &manifest); err != nil {
+			return nil, err
+		}
+
+		data, err := s.ArtifactsService.Blob(manifest.Config.Digest.String())
+		if err != nil {
+			return nil, err
+		}
+
+		var config v1.Platform
+		if err := s.Decoder.Decode(data, &config); err != nil {
+			return nil, err
+		}
+
+		if config.OS == "" || config.Architecture == "" {
+			return nil, nil
+		}
+
+		return []string{formatPlatform(config)}, nil
+	}
+}
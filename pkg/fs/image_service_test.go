@@ -42,21 +42,80 @@ func TestImageService_List(t *testing.T) {
 				m.artifacts.EXPECT().Index().Return(data, nil)
 
 				m.decoder.EXPECT().Decode(gomock.Any(), gomock.Any()).
-					DoAndReturn(json.Unmarshal)
+					DoAndReturn(json.Unmarshal).AnyTimes()
+
+				m.artifacts.EXPECT().
+					Blob("sha256:4528b0a54dd4ec91f0398856216b24532566618340c7ef6fd00345b776fb2c10").
+					Return(testImageManifest("sha256:1111111111111111111111111111111111111111111111111111111111111111"), nil)
+				m.artifacts.EXPECT().
+					Blob("sha256:1111111111111111111111111111111111111111111111111111111111111111").
+					Return([]byte(`{"os": "linux", "architecture": "amd64"}`), nil)
+				m.artifacts.EXPECT().
+					Blob("sha256:a7358e4600ae00bf976dba9c299c3dcd7bd0473e18ff334dde35ba0f6535663b").
+					Return(testImageManifest("sha256:2222222222222222222222222222222222222222222222222222222222222222"), nil)
+				m.artifacts.EXPECT().
+					Blob("sha256:2222222222222222222222222222222222222222222222222222222222222222").
+					Return([]byte(`{"os": "linux", "architecture": "arm64", "variant": "v8"}`), nil)
 			},
 			wanted: []sheaf.BundleImage{
 				{
 					Name:      "example/image-a:abc",
 					Digest:    "sha256:4528b0a54dd4ec91f0398856216b24532566618340c7ef6fd00345b776fb2c10",
 					MediaType: "application/vnd.docker.distribution.manifest.v2+json",
+					Platforms: []string{"linux/amd64"},
 				},
 				{
 					Name:      "example/image-b:v1.0.0",
 					Digest:    "sha256:a7358e4600ae00bf976dba9c299c3dcd7bd0473e18ff334dde35ba0f6535663b",
 					MediaType: "application/vnd.docker.distribution.manifest.v2+json",
+					Platforms: []string{"linux/arm64/v8"},
+				},
+			},
+		},
+		{
+			name: "image index",
+			init: func(t *testing.T, m listMocks) {
+				data, err := ioutil.ReadFile(filepath.Join("testdata", "index-multi-arch.json"))
+				require.NoError(t, err)
+				m.artifacts.EXPECT().Index().Return(data, nil)
+
+				m.decoder.EXPECT().Decode(gomock.Any(), gomock.Any()).
+					DoAndReturn(json.Unmarshal).AnyTimes()
+
+				m.artifacts.EXPECT().
+					Blob("sha256:3333333333333333333333333333333333333333333333333333333333333333").
+					Return([]byte(`{
+  "schemaVersion": 2,
+  "manifests": [
+    {"digest": "sha256:4444444444444444444444444444444444444444444444444444444444444444", "platform": {"os": "linux", "architecture": "amd64"}},
+    {"digest": "sha256:5555555555555555555555555555555555555555555555555555555555555555", "platform": {"os": "linux", "architecture": "arm64", "variant": "v8"}},
+    {"digest": "sha256:6666666666666666666666666666666666666666666666666666666666666666"}
+  ]
+}`), nil)
+			},
+			wanted: []sheaf.BundleImage{
+				{
+					Name:      "example/image-c:v1.0.0",
+					Digest:    "sha256:3333333333333333333333333333333333333333333333333333333333333333",
+					MediaType: "application/vnd.docker.distribution.manifest.list.v2+json",
+					Platforms: []string{"linux/amd64", "linux/arm64/v8"},
 				},
 			},
 		},
+		{
+			name: "blob is missing",
+			init: func(t *testing.T, m listMocks) {
+				data, err := ioutil.ReadFile(filepath.Join("testdata", "index.json"))
+				require.NoError(t, err)
+				m.artifacts.EXPECT().Index().Return(data, nil)
+
+				m.decoder.EXPECT().Decode(gomock.Any(), gomock.Any()).
+					DoAndReturn(json.Unmarshal).AnyTimes()
+
+				m.artifacts.EXPECT().Blob(gomock.Any()).Return(nil, fmt.Errorf("error"))
+			},
+			wantErr: true,
+		},
 		{
 			name: "image is missing ref name",
 			init: func(t *testing.T, m listMocks) {
@@ -119,3 +178,15 @@ func TestImageService_List(t *testing.T) {
 	}
 
 }
+
+func testImageManifest(configDigest string) []byte {
+	return []byte(fmt.Sprintf(`{
+  "schemaVersion": 2,
+  "mediaType": "application/vnd.docker.distribution.manifest.v2+json",
+  "config": {
+    "mediaType": "application/vnd.docker.container.image.v1+json",
+    "size": 100,
+    "digest": %q
+  }
+}`, configDigest))
+}
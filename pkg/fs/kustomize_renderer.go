@@ -0,0 +1,46 @@
+/*
+ * Copyright 2020 Sheaf Authors
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package fs
+
+import (
+	"fmt"
+
+	"sigs.k8s.io/kustomize/api/filesys"
+	"sigs.k8s.io/kustomize/api/krusty"
+
+	"github.com/bryanl/sheaf/pkg/sheaf"
+)
+
+// KustomizeRenderer builds kustomizations in-process.
+type KustomizeRenderer struct {
+	fileSystem filesys.FileSystem
+}
+
+var _ sheaf.ManifestRenderer = &KustomizeRenderer{}
+
+// NewKustomizeRenderer creates an instance of KustomizeRenderer.
+func NewKustomizeRenderer() *KustomizeRenderer {
+	return &KustomizeRenderer{
+		fileSystem: filesys.MakeFsOnDisk(),
+	}
+}
+
+// Render builds a kustomization manifest source.
+func (kr KustomizeRenderer) Render(source sheaf.ManifestSource) ([]byte, error) {
+	if source.Type != sheaf.KustomizeManifestSource {
+		return nil, fmt.Errorf("%s is not a kustomize manifest source", source.Path)
+	}
+
+	k := krusty.MakeKustomizer(kr.fileSystem, krusty.MakeDefaultOptions())
+
+	resMap, err := k.Run(source.Path)
+	if err != nil {
+		return nil, fmt.Errorf("build kustomization: %w", err)
+	}
+
+	return resMap.AsYaml()
+}
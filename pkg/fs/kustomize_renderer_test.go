@@ -0,0 +1,86 @@
+// +build !integration
+
+/*
+ * Copyright 2020 Sheaf Authors
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package fs
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/pivotal/image-relocation/pkg/images"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bryanl/sheaf/pkg/manifest"
+	"github.com/bryanl/sheaf/pkg/sheaf"
+)
+
+func TestKustomizeRenderer_Render(t *testing.T) {
+	cases := []struct {
+		name       string
+		source     sheaf.ManifestSource
+		wantImages []string
+		wantErr    bool
+	}{
+		{
+			name: "base",
+			source: sheaf.ManifestSource{
+				Type:     sheaf.KustomizeManifestSource,
+				Path:     filepath.Join("testdata", "kustomize", "base"),
+				Manifest: "base.yaml",
+			},
+			wantImages: []string{"nginx:1.17"},
+		},
+		{
+			name: "overlay with image overrides",
+			source: sheaf.ManifestSource{
+				Type:     sheaf.KustomizeManifestSource,
+				Path:     filepath.Join("testdata", "kustomize", "overlay"),
+				Manifest: "overlay.yaml",
+			},
+			wantImages: []string{"example.com/nginx:1.19"},
+		},
+		{
+			name: "missing kustomization",
+			source: sheaf.ManifestSource{
+				Type:     sheaf.KustomizeManifestSource,
+				Path:     filepath.Join("testdata", "kustomize", "missing"),
+				Manifest: "missing.yaml",
+			},
+			wantErr: true,
+		},
+		{
+			name: "not a kustomize source",
+			source: sheaf.ManifestSource{
+				Type: sheaf.HelmManifestSource,
+				Path: filepath.Join("testdata", "kustomize", "base"),
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			kr := NewKustomizeRenderer()
+
+			data, err := kr.Render(tc.source)
+			if tc.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+
+			got, err := manifest.ContainerImagesFromBytes(data, nil)
+			require.NoError(t, err)
+
+			want, err := images.New(tc.wantImages...)
+			require.NoError(t, err)
+
+			require.Equal(t, want.Strings(), got.Strings())
+		})
+	}
+}
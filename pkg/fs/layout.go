@@ -7,14 +7,23 @@
 package fs
 
 import (
+	"encoding/json"
 	"fmt"
+	"io/ioutil"
 	"net/http"
 	"os"
 	"path/filepath"
+	"sort"
 
+	"github.com/google/go-containerregistry/pkg/authn"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/layout"
 	"github.com/pivotal/image-relocation/pkg/registry"
 	"github.com/pivotal/image-relocation/pkg/registry/ggcr"
 	"github.com/pivotal/image-relocation/pkg/transport"
+
+	sheafremote "github.com/bryanl/sheaf/pkg/remote"
+	"github.com/bryanl/sheaf/pkg/reporter"
 )
 
 //go:generate mockgen -destination=../mocks/mock_layout.go -package mocks github.com/bryanl/sheaf/pkg/fs Layout
@@ -28,7 +37,10 @@ type LayoutOptionFunc func(options LayoutOptions) LayoutOptions
 // LayoutOptions are options for DefaultLayoutFactory.
 type LayoutOptions struct {
 	insecureSkipVerify bool
-	certs              []string
+	transport          http.RoundTripper
+	keychain           authn.Keychain
+	registries         *sheafremote.Registries
+	platforms          []string
 }
 
 // DefaultLayoutFactoryInsecureSkipVerify configures support for insecure registries.
@@ -39,6 +51,42 @@ func DefaultLayoutFactoryInsecureSkipVerify() LayoutOptionFunc {
 	}
 }
 
+// DefaultLayoutFactoryTransport configures the transport used to connect to
+// registries, e.g. one that trusts custom CAs.
+func DefaultLayoutFactoryTransport(t http.RoundTripper) LayoutOptionFunc {
+	return func(options LayoutOptions) LayoutOptions {
+		options.transport = t
+		return options
+	}
+}
+
+// DefaultLayoutFactoryKeychain configures the keychain used to authenticate
+// with registries instead of the default docker keychain.
+func DefaultLayoutFactoryKeychain(keychain authn.Keychain) LayoutOptionFunc {
+	return func(options LayoutOptions) LayoutOptions {
+		options.keychain = keychain
+		return options
+	}
+}
+
+// DefaultLayoutFactoryRegistries configures the registries config used for
+// insecure, mirrored, and blocked registries.
+func DefaultLayoutFactoryRegistries(registries *sheafremote.Registries) LayoutOptionFunc {
+	return func(options LayoutOptions) LayoutOptions {
+		options.registries = registries
+		return options
+	}
+}
+
+// DefaultLayoutFactoryPlatforms configures the platforms, e.g. linux/arm64,
+// kept when image indexes are added. Every platform is kept by default.
+func DefaultLayoutFactoryPlatforms(platforms ...string) LayoutOptionFunc {
+	return func(options LayoutOptions) LayoutOptions {
+		options.platforms = platforms
+		return options
+	}
+}
+
 // DefaultLayoutFactory generates a LayoutFactory.
 func DefaultLayoutFactory(options ...LayoutOptionFunc) LayoutFactory {
 	var lo LayoutOptions
@@ -46,13 +94,26 @@ func DefaultLayoutFactory(options ...LayoutOptionFunc) LayoutFactory {
 		lo = option(lo)
 	}
 
-	return func(root string) (layout Layout, err error) {
+	return func(root string) (Layout, error) {
+		var platforms []v1.Platform
+		for _, s := range lo.platforms {
+			p, err := ParsePlatform(s)
+			if err != nil {
+				return nil, err
+			}
+
+			platforms = append(platforms, p)
+		}
+
 		var t http.RoundTripper
 
-		if lo.insecureSkipVerify {
-			t = newInsecureTransport()
-		} else {
-			nt, err := transport.NewHttpTransport(lo.certs, lo.insecureSkipVerify)
+		switch {
+		case lo.insecureSkipVerify:
+			t = newInsecureTransport(lo.transport)
+		case lo.transport != nil:
+			t = lo.transport
+		default:
+			nt, err := transport.NewHttpTransport(nil, false)
 			if err != nil {
 				return nil, fmt.Errorf("create http transport: %w", err)
 			}
@@ -60,17 +121,175 @@ func DefaultLayoutFactory(options ...LayoutOptionFunc) LayoutFactory {
 			t = nt
 		}
 
-		layoutPath := filepath.Join(root, "artifacts", "layout")
+		if !lo.registries.IsDefault() {
+			t = newRegistriesInsecureTransport(lo.registries, t)
+		}
+
+		// Images added by remoteLayout are pulled with the transport before it
+		// is authenticated for the layout registry client.
+		remoteTransport := t
+
+		if lo.keychain != nil {
+			t = newAuthTransport(lo.keychain, t)
+		}
+
+		layoutPath := layoutRootPath(root)
+
+		var l Layout
 		if _, err := os.Stat(layoutPath); err != nil {
-			if os.IsNotExist(err) {
-				return ggcr.NewRegistryClient(ggcr.WithTransport(t)).
-					NewLayout(layoutPath)
+			if !os.IsNotExist(err) {
+				return nil, fmt.Errorf("layout path: %w", err)
+			}
+
+			if l, err = ggcr.NewRegistryClient(ggcr.WithTransport(t)).NewLayout(layoutPath); err != nil {
+				return nil, err
+			}
+		} else {
+			if l, err = ggcr.NewRegistryClient(ggcr.WithTransport(t)).ReadLayout(layoutPath); err != nil {
+				return nil, err
 			}
-			return nil, fmt.Errorf("layout path: %w", err)
 		}
-		return ggcr.NewRegistryClient(ggcr.WithTransport(t)).
-			ReadLayout(layoutPath)
+
+		return &remoteLayout{
+			Layout:        l,
+			path:          layout.Path(layoutPath),
+			registries:    lo.registries,
+			keychain:      lo.keychain,
+			transport:     remoteTransport,
+			pushTransport: t,
+			platforms:     platforms,
+			reporter:      reporter.New(),
+		}, nil
+	}
+}
+
+// layoutRootPath returns the path of the OCI layout for a root path.
+func layoutRootPath(root string) string {
+	return filepath.Join(root, "artifacts", "layout")
+}
+
+// mergeLayout moves the blobs and index entries of the OCI layout at src into
+// the OCI layout at dest. Blobs that already exist in dest are skipped. A
+// source without an index is treated as empty.
+func mergeLayout(src, dest string) error {
+	if _, err := os.Stat(filepath.Join(src, "index.json")); err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	srcPath, err := layout.FromPath(src)
+	if err != nil {
+		return fmt.Errorf("read layout %s: %w", src, err)
+	}
+
+	destPath, err := layout.FromPath(dest)
+	if err != nil {
+		return fmt.Errorf("read layout %s: %w", dest, err)
+	}
+
+	blobsDir := filepath.Join(src, "blobs")
+	err = filepath.Walk(blobsDir, func(path string, info os.FileInfo, err error) error {
+		// A layout that no images were added to has no blobs.
+		if os.IsNotExist(err) && path == blobsDir {
+			return nil
+		}
+
+		if err != nil || info.IsDir() {
+			return err
+		}
+
+		rel, err := filepath.Rel(blobsDir, path)
+		if err != nil {
+			return err
+		}
+
+		target := filepath.Join(dest, "blobs", rel)
+		if _, err := os.Stat(target); err == nil {
+			return nil
+		}
+
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return err
+		}
+
+		if err := os.Rename(path, target); err == nil {
+			return nil
+		}
+
+		// Fall back to copying when the blob can't be moved, e.g. across devices.
+		h := v1.Hash{Algorithm: filepath.Dir(rel), Hex: filepath.Base(rel)}
+		r, err := srcPath.Blob(h)
+		if err != nil {
+			return err
+		}
+
+		return destPath.WriteBlob(h, r)
+	})
+	if err != nil {
+		return fmt.Errorf("move blobs: %w", err)
+	}
+
+	index, err := srcPath.ImageIndex()
+	if err != nil {
+		return err
+	}
+
+	indexManifest, err := index.IndexManifest()
+	if err != nil {
+		return err
+	}
+
+	for _, desc := range indexManifest.Manifests {
+		if err := destPath.AppendDescriptor(desc); err != nil {
+			return fmt.Errorf("append %s to index: %w", desc.Digest, err)
+		}
+	}
+
+	return nil
+}
+
+// sortLayoutIndex sorts the descriptors in the index of the OCI layout at
+// root by image name and digest. A layout without an index is left as is.
+func sortLayoutIndex(root string) error {
+	if _, err := os.Stat(filepath.Join(root, "index.json")); err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	p, err := layout.FromPath(root)
+	if err != nil {
+		return fmt.Errorf("read layout %s: %w", root, err)
+	}
+
+	index, err := p.ImageIndex()
+	if err != nil {
+		return err
+	}
+
+	indexManifest, err := index.IndexManifest()
+	if err != nil {
+		return err
+	}
+
+	sort.SliceStable(indexManifest.Manifests, func(i, j int) bool {
+		a, b := indexManifest.Manifests[i], indexManifest.Manifests[j]
+		if a.Annotations[refNameAnnotation] != b.Annotations[refNameAnnotation] {
+			return a.Annotations[refNameAnnotation] < b.Annotations[refNameAnnotation]
+		}
+		return a.Digest.String() < b.Digest.String()
+	})
+
+	// The index is written the same way the layout package writes it.
+	data, err := json.MarshalIndent(indexManifest, "", "   ")
+	if err != nil {
+		return err
 	}
+
+	return ioutil.WriteFile(filepath.Join(root, "index.json"), data, 0644)
 }
 
 // Layout manages OCI layouts.
@@ -80,17 +299,43 @@ type Layout interface {
 
 type insecureTransport struct {
 	roundTripperFunc func(*http.Request) (*http.Response, error)
+	// insecure returns true if requests to a host are sent over plain HTTP.
+	// Every request is sent over plain HTTP if it is nil.
+	insecure func(host string) (bool, error)
 }
 
 var _ http.RoundTripper = &insecureTransport{}
 
-func newInsecureTransport() *insecureTransport {
+func newInsecureTransport(inner http.RoundTripper) *insecureTransport {
+	if inner == nil {
+		inner = http.DefaultTransport
+	}
+
 	return &insecureTransport{
-		roundTripperFunc: http.DefaultTransport.RoundTrip,
+		roundTripperFunc: inner.RoundTrip,
 	}
 }
 
+// newRegistriesInsecureTransport creates a transport that sends requests to
+// insecure registries in a registries config over plain HTTP.
+func newRegistriesInsecureTransport(registries *sheafremote.Registries, inner http.RoundTripper) *insecureTransport {
+	t := newInsecureTransport(inner)
+	t.insecure = registries.Insecure
+	return t
+}
+
 func (i insecureTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	if i.insecure != nil {
+		insecure, err := i.insecure(r.URL.Host)
+		if err != nil {
+			return nil, err
+		}
+
+		if !insecure {
+			return i.roundTripperFunc(r)
+		}
+	}
+
 	r.URL.Scheme = "http"
 	return i.roundTripperFunc(r)
 }
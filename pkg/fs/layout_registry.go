@@ -0,0 +1,263 @@
+/*
+ * Copyright 2020 Sheaf Authors
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package fs
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/layout"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+	"github.com/pivotal/image-relocation/pkg/image"
+)
+
+// LayoutRegistry serves an OCI layout over the read-only part of the OCI
+// distribution API. Manifests and blobs are served from the layout's files.
+//
+// Images are addressed by their repository path, e.g. library/nginx:1.17, or
+// by their fully qualified repository name, e.g. docker.io/library/nginx:1.17.
+type LayoutRegistry struct {
+	root string
+}
+
+var _ http.Handler = &LayoutRegistry{}
+
+// NewLayoutRegistry creates an instance of LayoutRegistry for the OCI layout at root.
+func NewLayoutRegistry(root string) *LayoutRegistry {
+	return &LayoutRegistry{root: root}
+}
+
+// ServeHTTP serves a distribution API request.
+func (lr *LayoutRegistry) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Docker-Distribution-API-Version", "registry/2.0")
+
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		writeRegistryError(w, http.StatusMethodNotAllowed, "UNSUPPORTED", "registry is read-only")
+		return
+	}
+
+	p := strings.TrimPrefix(r.URL.Path, "/v2/")
+	if p == r.URL.Path {
+		writeRegistryError(w, http.StatusNotFound, "NOT_FOUND", "not found")
+		return
+	}
+
+	if p == "" {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte("{}"))
+		return
+	}
+
+	if repo := strings.TrimSuffix(p, "/tags/list"); repo != p {
+		lr.serveTags(w, repo)
+		return
+	}
+
+	for _, kind := range []string{"manifests", "blobs"} {
+		i := strings.LastIndex(p, "/"+kind+"/")
+		if i < 1 {
+			continue
+		}
+
+		repo, ref := p[:i], p[i+len(kind)+2:]
+		if kind == "manifests" {
+			lr.serveManifest(w, r, repo, ref)
+		} else {
+			lr.serveBlob(w, r, ref)
+		}
+		return
+	}
+
+	writeRegistryError(w, http.StatusNotFound, "NOT_FOUND", "not found")
+}
+
+// serveManifest serves a manifest by tag or digest.
+func (lr *LayoutRegistry) serveManifest(w http.ResponseWriter, r *http.Request, repo, ref string) {
+	descriptors, err := lr.descriptors()
+	if err != nil {
+		writeRegistryError(w, http.StatusInternalServerError, "UNKNOWN", err.Error())
+		return
+	}
+
+	var desc *v1.Descriptor
+	if h, err := v1.NewHash(ref); err == nil {
+		desc = &v1.Descriptor{Digest: h}
+		for i := range descriptors {
+			if descriptors[i].Digest == h {
+				desc = &descriptors[i]
+				break
+			}
+		}
+	} else {
+		for i := range descriptors {
+			n, ok := descriptorName(descriptors[i])
+			if ok && n.Tag() == ref && repositoryMatches(repo, n) {
+				desc = &descriptors[i]
+				break
+			}
+		}
+	}
+
+	if desc == nil {
+		writeRegistryError(w, http.StatusNotFound, "MANIFEST_UNKNOWN", fmt.Sprintf("manifest %s:%s is unknown", repo, ref))
+		return
+	}
+
+	data, err := ioutil.ReadFile(lr.blobPath(desc.Digest))
+	if err != nil {
+		if os.IsNotExist(err) {
+			writeRegistryError(w, http.StatusNotFound, "MANIFEST_UNKNOWN", fmt.Sprintf("manifest %s is unknown", desc.Digest))
+			return
+		}
+		writeRegistryError(w, http.StatusInternalServerError, "UNKNOWN", err.Error())
+		return
+	}
+
+	mediaType := desc.MediaType
+	if mediaType == "" {
+		// Manifests referenced by an image index are not in the layout index.
+		// Their media type is in the manifest.
+		var m struct {
+			MediaType types.MediaType `json:"mediaType"`
+		}
+		if err := json.Unmarshal(data, &m); err != nil || m.MediaType == "" {
+			writeRegistryError(w, http.StatusNotFound, "MANIFEST_UNKNOWN", fmt.Sprintf("%s is not a manifest", desc.Digest))
+			return
+		}
+		mediaType = m.MediaType
+	}
+
+	w.Header().Set("Content-Type", string(mediaType))
+	w.Header().Set("Content-Length", fmt.Sprint(len(data)))
+	w.Header().Set("Docker-Content-Digest", desc.Digest.String())
+
+	if r.Method == http.MethodHead {
+		return
+	}
+
+	_, _ = w.Write(data)
+}
+
+// serveBlob serves a blob from the layout's blob directory.
+func (lr *LayoutRegistry) serveBlob(w http.ResponseWriter, r *http.Request, ref string) {
+	h, err := v1.NewHash(ref)
+	if err != nil {
+		writeRegistryError(w, http.StatusBadRequest, "DIGEST_INVALID", err.Error())
+		return
+	}
+
+	f, err := os.Open(lr.blobPath(h))
+	if err != nil {
+		if os.IsNotExist(err) {
+			writeRegistryError(w, http.StatusNotFound, "BLOB_UNKNOWN", fmt.Sprintf("blob %s is unknown", h))
+			return
+		}
+		writeRegistryError(w, http.StatusInternalServerError, "UNKNOWN", err.Error())
+		return
+	}
+	defer f.Close()
+
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Header().Set("Docker-Content-Digest", h.String())
+	http.ServeContent(w, r, "", time.Time{}, f)
+}
+
+// serveTags lists the tags of a repository.
+func (lr *LayoutRegistry) serveTags(w http.ResponseWriter, repo string) {
+	descriptors, err := lr.descriptors()
+	if err != nil {
+		writeRegistryError(w, http.StatusInternalServerError, "UNKNOWN", err.Error())
+		return
+	}
+
+	seen := map[string]bool{}
+	tags := []string{}
+	for _, desc := range descriptors {
+		n, ok := descriptorName(desc)
+		if !ok || n.Tag() == "" || !repositoryMatches(repo, n) || seen[n.Tag()] {
+			continue
+		}
+
+		seen[n.Tag()] = true
+		tags = append(tags, n.Tag())
+	}
+
+	if len(tags) == 0 {
+		writeRegistryError(w, http.StatusNotFound, "NAME_UNKNOWN", fmt.Sprintf("repository %s is unknown", repo))
+		return
+	}
+
+	sort.Strings(tags)
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(struct {
+		Name string   `json:"name"`
+		Tags []string `json:"tags"`
+	}{Name: repo, Tags: tags})
+}
+
+// descriptors returns the descriptors in the layout index.
+func (lr *LayoutRegistry) descriptors() ([]v1.Descriptor, error) {
+	index, err := layout.ImageIndexFromPath(lr.root)
+	if err != nil {
+		return nil, fmt.Errorf("read layout index: %w", err)
+	}
+
+	indexManifest, err := index.IndexManifest()
+	if err != nil {
+		return nil, fmt.Errorf("read layout index: %w", err)
+	}
+
+	return indexManifest.Manifests, nil
+}
+
+func (lr *LayoutRegistry) blobPath(h v1.Hash) string {
+	return filepath.Join(lr.root, "blobs", h.Algorithm, h.Hex)
+}
+
+// descriptorName returns the image name recorded in a descriptor.
+func descriptorName(desc v1.Descriptor) (image.Name, bool) {
+	ref, ok := desc.Annotations[refNameAnnotation]
+	if !ok {
+		return image.EmptyName, false
+	}
+
+	n, err := image.NewName(ref)
+	if err != nil {
+		return image.EmptyName, false
+	}
+
+	return n, true
+}
+
+// repositoryMatches returns true if a requested repository is the repository
+// path or the fully qualified repository name of an image.
+func repositoryMatches(repo string, n image.Name) bool {
+	return repo == n.Path() || repo == n.Name()
+}
+
+func writeRegistryError(w http.ResponseWriter, status int, code, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	type registryError struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	}
+
+	_ = json.NewEncoder(w).Encode(struct {
+		Errors []registryError `json:"errors"`
+	}{Errors: []registryError{{Code: code, Message: message}}})
+}
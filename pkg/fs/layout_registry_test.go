@@ -0,0 +1,129 @@
+/*
+ * Copyright 2020 Sheaf Authors
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package fs
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/layout"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/validate"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLayoutRegistry(t *testing.T) {
+	root, err := ioutil.TempDir("", "sheaf-test")
+	require.NoError(t, err)
+
+	defer func() {
+		require.NoError(t, os.RemoveAll(root))
+	}()
+
+	p, err := layout.Write(root, empty.Index)
+	require.NoError(t, err)
+
+	img, err := random.Image(256, 2)
+	require.NoError(t, err)
+	require.NoError(t, p.AppendImage(img, layout.WithAnnotations(map[string]string{
+		refNameAnnotation: "docker.io/library/nginx:1.17",
+	})))
+
+	idx, err := random.Index(256, 1, 2)
+	require.NoError(t, err)
+	require.NoError(t, p.AppendIndex(idx, layout.WithAnnotations(map[string]string{
+		refNameAnnotation: "gcr.io/project/app:v1",
+	})))
+
+	server := httptest.NewServer(NewLayoutRegistry(root))
+	defer server.Close()
+
+	u, err := url.Parse(server.URL)
+	require.NoError(t, err)
+
+	imgDigest, err := img.Digest()
+	require.NoError(t, err)
+
+	idxDigest, err := idx.Digest()
+	require.NoError(t, err)
+
+	t.Run("pull image by repository path", func(t *testing.T) {
+		ref, err := name.ParseReference(u.Host + "/library/nginx:1.17")
+		require.NoError(t, err)
+
+		got, err := remote.Image(ref)
+		require.NoError(t, err)
+		require.NoError(t, validate.Image(got))
+
+		gotDigest, err := got.Digest()
+		require.NoError(t, err)
+		require.Equal(t, imgDigest, gotDigest)
+	})
+
+	t.Run("pull image by fully qualified name and digest", func(t *testing.T) {
+		ref, err := name.ParseReference(u.Host + "/docker.io/library/nginx@" + imgDigest.String())
+		require.NoError(t, err)
+
+		got, err := remote.Image(ref)
+		require.NoError(t, err)
+		require.NoError(t, validate.Image(got))
+	})
+
+	t.Run("pull image index", func(t *testing.T) {
+		ref, err := name.ParseReference(u.Host + "/project/app:v1")
+		require.NoError(t, err)
+
+		got, err := remote.Index(ref)
+		require.NoError(t, err)
+		require.NoError(t, validate.Index(got))
+
+		gotDigest, err := got.Digest()
+		require.NoError(t, err)
+		require.Equal(t, idxDigest, gotDigest)
+	})
+
+	t.Run("list tags", func(t *testing.T) {
+		repo, err := name.NewRepository(u.Host + "/library/nginx")
+		require.NoError(t, err)
+
+		tags, err := remote.List(repo)
+		require.NoError(t, err)
+		require.Equal(t, []string{"1.17"}, tags)
+	})
+
+	t.Run("unknown image", func(t *testing.T) {
+		ref, err := name.ParseReference(u.Host + "/library/nginx:missing")
+		require.NoError(t, err)
+
+		_, err = remote.Image(ref)
+		require.Error(t, err)
+	})
+
+	t.Run("blob is not a manifest", func(t *testing.T) {
+		configName, err := img.ConfigName()
+		require.NoError(t, err)
+
+		res, err := http.Get(server.URL + "/v2/library/nginx/manifests/" + configName.String())
+		require.NoError(t, err)
+		require.NoError(t, res.Body.Close())
+		require.Equal(t, http.StatusNotFound, res.StatusCode)
+	})
+
+	t.Run("push", func(t *testing.T) {
+		ref, err := name.ParseReference(u.Host + "/library/nginx:pushed")
+		require.NoError(t, err)
+
+		require.Error(t, remote.Write(ref, img))
+	})
+}
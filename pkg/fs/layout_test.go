@@ -10,13 +10,72 @@ import (
 	"bytes"
 	"io/ioutil"
 	"net/http"
+	"os"
+	"path/filepath"
 	"testing"
 
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/layout"
+	"github.com/google/go-containerregistry/pkg/v1/random"
 	"github.com/stretchr/testify/require"
 )
 
+func Test_mergeLayout(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sheaf-test")
+	require.NoError(t, err)
+
+	defer func() {
+		require.NoError(t, os.RemoveAll(dir))
+	}()
+
+	writeLayout := func(name string, n int) (string, []v1.Hash) {
+		p, err := layout.Write(filepath.Join(dir, name), empty.Index)
+		require.NoError(t, err)
+
+		var digests []v1.Hash
+		for i := 0; i < n; i++ {
+			img, err := random.Image(64, 2)
+			require.NoError(t, err)
+			require.NoError(t, p.AppendImage(img))
+
+			d, err := img.Digest()
+			require.NoError(t, err)
+			digests = append(digests, d)
+		}
+
+		return filepath.Join(dir, name), digests
+	}
+
+	dest, destDigests := writeLayout("dest", 1)
+	src, srcDigests := writeLayout("src", 2)
+
+	require.NoError(t, mergeLayout(src, dest))
+	require.NoError(t, mergeLayout(filepath.Join(dir, "missing"), dest))
+
+	ii, err := layout.ImageIndexFromPath(dest)
+	require.NoError(t, err)
+
+	for _, d := range append(destDigests, srcDigests...) {
+		img, err := ii.Image(d)
+		require.NoError(t, err)
+
+		layers, err := img.Layers()
+		require.NoError(t, err)
+		for _, l := range layers {
+			rc, err := l.Compressed()
+			require.NoError(t, err)
+			require.NoError(t, rc.Close())
+		}
+	}
+
+	indexManifest, err := ii.IndexManifest()
+	require.NoError(t, err)
+	require.Len(t, indexManifest.Manifests, 3)
+}
+
 func Test_insecureTransport(t *testing.T) {
-	tr := newInsecureTransport()
+	tr := newInsecureTransport(nil)
 
 	var scheme string
 	tr.roundTripperFunc = func(r *http.Request) (response *http.Response, err error) {
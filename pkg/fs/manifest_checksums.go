@@ -0,0 +1,148 @@
+/*
+ * Copyright 2020 Sheaf Authors
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package fs
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// ManifestChecksumsFile is the file in an archive that contains the SHA-256
+// checksums of its manifests. It uses the sha256sum format, so it can also be
+// checked with `sha256sum -c` from the exploded archive.
+const ManifestChecksumsFile = "checksums.sha256"
+
+// manifestsPath returns the path of the manifests in an archive.
+func manifestsPath(root string) string {
+	return filepath.Join(root, "app", "manifests")
+}
+
+// manifestFiles returns the manifest files in an archive as paths relative
+// to the archive root.
+func manifestFiles(root string) ([]string, error) {
+	var files []string
+	err := filepath.Walk(manifestsPath(root), func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) && path == manifestsPath(root) {
+				return nil
+			}
+			return err
+		}
+
+		if info.IsDir() {
+			return nil
+		}
+
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+
+		files = append(files, filepath.ToSlash(rel))
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Strings(files)
+	return files, nil
+}
+
+// writeManifestChecksums writes the checksums of the manifests in an archive.
+func writeManifestChecksums(root string) error {
+	files, err := manifestFiles(root)
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	for _, file := range files {
+		sum, err := fileChecksum(filepath.Join(root, filepath.FromSlash(file)))
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintf(&buf, "%s  %s\n", sum, file)
+	}
+
+	return ioutil.WriteFile(filepath.Join(root, ManifestChecksumsFile), buf.Bytes(), 0600)
+}
+
+// readManifestChecksums reads the checksums of the manifests in an archive.
+// The checksums are keyed by path relative to the archive root.
+func readManifestChecksums(root string) (map[string]string, error) {
+	f, err := os.Open(filepath.Join(root, ManifestChecksumsFile))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	checksums := map[string]string{}
+
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSuffix(scanner.Text(), "\r")
+		if strings.TrimSpace(text) == "" {
+			continue
+		}
+
+		path, sum, ok := parseChecksumLine(text)
+		if !ok {
+			return nil, fmt.Errorf("%s line %d is invalid", ManifestChecksumsFile, line)
+		}
+
+		checksums[path] = sum
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return checksums, nil
+}
+
+// parseChecksumLine parses a line in the sha256sum format: the checksum, a
+// space, a space or a '*' marking a file read in binary mode, and the rest of
+// the line as the path. Paths may contain spaces.
+func parseChecksumLine(text string) (string, string, bool) {
+	i := strings.Index(text, " ")
+	if i < 1 || len(text) < i+3 {
+		return "", "", false
+	}
+
+	if mode := text[i+1]; mode != ' ' && mode != '*' {
+		return "", "", false
+	}
+
+	return text[i+2:], text[:i], true
+}
+
+// fileChecksum returns the hex encoded SHA-256 checksum of a file.
+func fileChecksum(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,99 @@
+/*
+ * Copyright 2020 Sheaf Authors
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package fs
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_readManifestChecksums(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		wanted  map[string]string
+		wantErr bool
+	}{
+		{
+			name: "in general",
+			data: "aaa  app/manifests/a.yaml\n\nbbb *app/manifests/b.yaml\n",
+			wanted: map[string]string{
+				"app/manifests/a.yaml": "aaa",
+				"app/manifests/b.yaml": "bbb",
+			},
+		},
+		{
+			name: "path with spaces",
+			data: "aaa  app/manifests/my app.yaml\nbbb *app/manifests/b c.yaml\n",
+			wanted: map[string]string{
+				"app/manifests/my app.yaml": "aaa",
+				"app/manifests/b c.yaml":    "bbb",
+			},
+		},
+		{
+			name:    "invalid line",
+			data:    "aaa\n",
+			wantErr: true,
+		},
+		{
+			name:    "missing path",
+			data:    "aaa  \n",
+			wantErr: true,
+		},
+		{
+			name:    "invalid separator",
+			data:    "aaa app/manifests/a.yaml\n",
+			wantErr: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			root, err := ioutil.TempDir("", "sheaf-test")
+			require.NoError(t, err)
+
+			defer func() {
+				require.NoError(t, os.RemoveAll(root))
+			}()
+
+			require.NoError(t, ioutil.WriteFile(filepath.Join(root, ManifestChecksumsFile), []byte(test.data), 0600))
+
+			got, err := readManifestChecksums(root)
+			if test.wantErr {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, test.wanted, got)
+		})
+	}
+}
+
+func Test_writeManifestChecksums(t *testing.T) {
+	root, err := ioutil.TempDir("", "sheaf-test")
+	require.NoError(t, err)
+
+	defer func() {
+		require.NoError(t, os.RemoveAll(root))
+	}()
+
+	require.NoError(t, os.MkdirAll(manifestsPath(root), 0700))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(manifestsPath(root), "my app.yaml"), []byte("kind: ConfigMap\n"), 0600))
+
+	require.NoError(t, writeManifestChecksums(root))
+
+	got, err := readManifestChecksums(root)
+	require.NoError(t, err)
+
+	sum, err := fileChecksum(filepath.Join(manifestsPath(root), "my app.yaml"))
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"app/manifests/my app.yaml": sum}, got)
+}
@@ -0,0 +1,37 @@
+/*
+ * Copyright 2020 Sheaf Authors
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package fs
+
+import (
+	"github.com/bryanl/sheaf/pkg/manifest"
+	"github.com/bryanl/sheaf/pkg/sheaf"
+)
+
+// ManifestDiffer compares manifests by resource.
+type ManifestDiffer struct{}
+
+var _ sheaf.ManifestDiffer = &ManifestDiffer{}
+
+// NewManifestDiffer creates an instance of ManifestDiffer.
+func NewManifestDiffer() *ManifestDiffer {
+	return &ManifestDiffer{}
+}
+
+// Diff returns the differences between the resources in two lists of
+// manifests.
+func (md ManifestDiffer) Diff(oldManifests, newManifests []sheaf.BundleManifest) ([]sheaf.ResourceDifference, error) {
+	return manifest.Diff(manifestData(oldManifests), manifestData(newManifests))
+}
+
+func manifestData(manifests []sheaf.BundleManifest) [][]byte {
+	var list [][]byte
+	for _, m := range manifests {
+		list = append(list, m.Data)
+	}
+
+	return list
+}
@@ -36,10 +36,24 @@ func ManifestServiceReporter(r reporter.Reporter) ManifestServiceOption {
 	}
 }
 
+// ManifestServiceRenderer sets the renderer for a manifest source type.
+func ManifestServiceRenderer(sourceType sheaf.ManifestSourceType, r sheaf.ManifestRenderer) ManifestServiceOption {
+	return func(m ManifestService) ManifestService {
+		renderers := map[sheaf.ManifestSourceType]sheaf.ManifestRenderer{}
+		for k, v := range m.renderers {
+			renderers[k] = v
+		}
+		renderers[sourceType] = r
+		m.renderers = renderers
+		return m
+	}
+}
+
 // ManifestService is a service for interacting with manifests on a filesystem.
 type ManifestService struct {
 	manifestsDir string
 	reporter     reporter.Reporter
+	renderers    map[sheaf.ManifestSourceType]sheaf.ManifestRenderer
 }
 
 var _ sheaf.ManifestService = &ManifestService{}
@@ -49,6 +63,10 @@ func NewManifestService(manifestsDir string, options ...ManifestServiceOption) (
 	m := ManifestService{
 		manifestsDir: manifestsDir,
 		reporter:     reporter.Default,
+		renderers: map[sheaf.ManifestSourceType]sheaf.ManifestRenderer{
+			sheaf.HelmManifestSource:      NewHelmRenderer(),
+			sheaf.KustomizeManifestSource: NewKustomizeRenderer(),
+		},
 	}
 
 	for _, option := range options {
@@ -141,6 +159,36 @@ func (m ManifestService) Add(overwrite bool, manifestURIs ...string) error {
 	return nil
 }
 
+// AddSource renders a manifest source and adds the result to the filesystem.
+func (m ManifestService) AddSource(overwrite bool, source sheaf.ManifestSource) error {
+	if err := source.Validate(); err != nil {
+		return fmt.Errorf("invalid manifest source: %w", err)
+	}
+
+	renderer, ok := m.renderers[source.Type]
+	if !ok {
+		return fmt.Errorf("no renderer for manifest source type %q", source.Type)
+	}
+
+	m.reporter.Header(fmt.Sprintf("Rendering %s manifest from %s", source.Type, source.Path))
+
+	if err := os.MkdirAll(m.manifestsDir, 0700); err != nil {
+		return err
+	}
+
+	dest := filepath.Join(m.manifestsDir, source.Manifest)
+	if err := checkManifestDest(dest, overwrite); err != nil {
+		return err
+	}
+
+	data, err := renderer.Render(source)
+	if err != nil {
+		return fmt.Errorf("render %s: %w", source.Path, err)
+	}
+
+	return ioutil.WriteFile(dest, data, 0600)
+}
+
 func (m ManifestService) addURL(manifestURL url.URL, overwrite bool) error {
 	if !strings.HasPrefix(manifestURL.Scheme, "http") {
 		return fmt.Errorf("%s is an unsupported URL", manifestURL.String())
@@ -195,6 +243,14 @@ func (m ManifestService) addFile(manifestURI string, overwrite bool) error {
 	_, file := filepath.Split(manifestURI)
 
 	dest := filepath.Join(m.manifestsDir, file)
+	if err := checkManifestDest(dest, overwrite); err != nil {
+		return err
+	}
+
+	return fs.CopyFile(dest, manifestURI)
+}
+
+func checkManifestDest(dest string, overwrite bool) error {
 	_, err := os.Stat(dest)
 	if err != nil {
 		if !os.IsNotExist(err) {
@@ -204,7 +260,7 @@ func (m ManifestService) addFile(manifestURI string, overwrite bool) error {
 		return fmt.Errorf("%s exists", dest)
 	}
 
-	return fs.CopyFile(dest, manifestURI)
+	return nil
 }
 
 func (m ManifestService) addDir(manifestDir string, overwrite bool) error {
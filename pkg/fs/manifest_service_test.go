@@ -10,15 +10,18 @@ package fs
 
 import (
 	"fmt"
+	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"path/filepath"
 	"testing"
 
+	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/require"
 
 	"github.com/bryanl/sheaf/internal/testutil"
+	"github.com/bryanl/sheaf/pkg/mocks"
 	"github.com/bryanl/sheaf/pkg/reporter"
 	"github.com/bryanl/sheaf/pkg/sheaf"
 )
@@ -152,6 +155,110 @@ func TestManifestService_Add_from_fs(t *testing.T) {
 	}
 }
 
+func TestManifestService_AddSource(t *testing.T) {
+	source := sheaf.ManifestSource{
+		Type:     sheaf.HelmManifestSource,
+		Path:     "/chart",
+		Manifest: "release.yaml",
+		Helm: &sheaf.HelmOptions{
+			ReleaseName: "release",
+		},
+	}
+
+	cases := []struct {
+		name      string
+		source    sheaf.ManifestSource
+		overwrite bool
+		setup     func(t *testing.T, manifestDir string)
+		renderer  func(controller *gomock.Controller) sheaf.ManifestRenderer
+		wantErr   bool
+	}{
+		{
+			name:   "in general",
+			source: source,
+			renderer: func(controller *gomock.Controller) sheaf.ManifestRenderer {
+				r := mocks.NewMockManifestRenderer(controller)
+				r.EXPECT().Render(source).Return([]byte("rendered"), nil)
+				return r
+			},
+		},
+		{
+			name:   "manifest exists",
+			source: source,
+			setup: func(t *testing.T, manifestDir string) {
+				require.NoError(t, os.MkdirAll(manifestDir, 0700))
+				require.NoError(t, ioutil.WriteFile(filepath.Join(manifestDir, "release.yaml"), nil, 0600))
+			},
+			renderer: func(controller *gomock.Controller) sheaf.ManifestRenderer {
+				return mocks.NewMockManifestRenderer(controller)
+			},
+			wantErr: true,
+		},
+		{
+			name:      "manifest exists with overwrite",
+			source:    source,
+			overwrite: true,
+			setup: func(t *testing.T, manifestDir string) {
+				require.NoError(t, os.MkdirAll(manifestDir, 0700))
+				require.NoError(t, ioutil.WriteFile(filepath.Join(manifestDir, "release.yaml"), nil, 0600))
+			},
+			renderer: func(controller *gomock.Controller) sheaf.ManifestRenderer {
+				r := mocks.NewMockManifestRenderer(controller)
+				r.EXPECT().Render(source).Return([]byte("rendered"), nil)
+				return r
+			},
+		},
+		{
+			name:   "render fails",
+			source: source,
+			renderer: func(controller *gomock.Controller) sheaf.ManifestRenderer {
+				r := mocks.NewMockManifestRenderer(controller)
+				r.EXPECT().Render(source).Return(nil, fmt.Errorf("error"))
+				return r
+			},
+			wantErr: true,
+		},
+		{
+			name:   "invalid source",
+			source: sheaf.ManifestSource{Type: sheaf.HelmManifestSource},
+			renderer: func(controller *gomock.Controller) sheaf.ManifestRenderer {
+				return mocks.NewMockManifestRenderer(controller)
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			controller := gomock.NewController(t)
+			defer controller.Finish()
+
+			testutil.WithBundleDir(t, func(bundleDir string) {
+				manifestDir := filepath.Join(bundleDir, "app", "manifests")
+				if tc.setup != nil {
+					tc.setup(t, manifestDir)
+				}
+
+				m, err := NewManifestService(manifestDir,
+					ManifestServiceReporter(reporter.Nop{}),
+					ManifestServiceRenderer(sheaf.HelmManifestSource, tc.renderer(controller)))
+				require.NoError(t, err)
+
+				err = m.AddSource(tc.overwrite, tc.source)
+				if tc.wantErr {
+					require.Error(t, err)
+					return
+				}
+				require.NoError(t, err)
+
+				data, err := ioutil.ReadFile(filepath.Join(manifestDir, tc.source.Manifest))
+				require.NoError(t, err)
+				require.Equal(t, "rendered", string(data))
+			})
+		})
+	}
+}
+
 func TestManifestService_Test_Get_URL(t *testing.T) {
 
 	cases := []struct {
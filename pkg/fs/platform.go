@@ -0,0 +1,162 @@
+/*
+ * Copyright 2020 Sheaf Authors
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package fs
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/partial"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+)
+
+// ParsePlatform parses a platform in the form os/arch[/variant], e.g. linux/arm64.
+func ParsePlatform(s string) (v1.Platform, error) {
+	parts := strings.Split(s, "/")
+	if len(parts) < 2 || len(parts) > 3 {
+		return v1.Platform{}, fmt.Errorf("invalid platform %q (use os/arch[/variant])", s)
+	}
+
+	for _, part := range parts {
+		if part == "" {
+			return v1.Platform{}, fmt.Errorf("invalid platform %q (use os/arch[/variant])", s)
+		}
+	}
+
+	p := v1.Platform{OS: parts[0], Architecture: parts[1]}
+	if len(parts) == 3 {
+		p.Variant = parts[2]
+	}
+
+	return p, nil
+}
+
+// formatPlatform formats a platform as os/arch[/variant].
+func formatPlatform(p v1.Platform) string {
+	s := p.OS + "/" + p.Architecture
+	if p.Variant != "" {
+		s += "/" + p.Variant
+	}
+
+	return s
+}
+
+// platformMatches returns true if a platform matches a selected platform. A
+// selected platform without a variant matches every variant.
+func platformMatches(selected, p v1.Platform) bool {
+	if selected.OS != p.OS || selected.Architecture != p.Architecture {
+		return false
+	}
+
+	return selected.Variant == "" || selected.Variant == p.Variant
+}
+
+// platformImage returns the image of a platform from an index. It returns an
+// error if no image matches the platform.
+func platformImage(ii v1.ImageIndex, platform v1.Platform) (v1.Image, error) {
+	im, err := ii.IndexManifest()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, desc := range im.Manifests {
+		if desc.Platform == nil || !platformMatches(platform, *desc.Platform) {
+			continue
+		}
+
+		switch desc.MediaType {
+		case types.OCIManifestSchema1, types.DockerManifestSchema2:
+			return ii.Image(desc.Digest)
+		}
+	}
+
+	return nil, fmt.Errorf("no image for platform %s", formatPlatform(platform))
+}
+
+// filterIndex returns an index with the manifests of the selected platforms.
+// Manifests without a platform are kept. It returns an error if no manifest
+// matches a selected platform.
+func filterIndex(ii v1.ImageIndex, platforms []v1.Platform) (v1.ImageIndex, error) {
+	im, err := ii.IndexManifest()
+	if err != nil {
+		return nil, err
+	}
+
+	filtered := *im
+	filtered.Manifests = nil
+
+	matched := false
+	for _, desc := range im.Manifests {
+		if desc.Platform == nil {
+			filtered.Manifests = append(filtered.Manifests, desc)
+			continue
+		}
+
+		for _, p := range platforms {
+			if platformMatches(p, *desc.Platform) {
+				filtered.Manifests = append(filtered.Manifests, desc)
+				matched = true
+				break
+			}
+		}
+	}
+
+	if !matched {
+		var names []string
+		for _, p := range platforms {
+			names = append(names, formatPlatform(p))
+		}
+		return nil, fmt.Errorf("index has no manifest for %s", strings.Join(names, ", "))
+	}
+
+	raw, err := json.Marshal(filtered)
+	if err != nil {
+		return nil, err
+	}
+
+	return &filteredIndex{base: ii, manifest: &filtered, raw: raw}, nil
+}
+
+// filteredIndex is an image index with a subset of the manifests of a base
+// index. It keeps the media type of the base index.
+type filteredIndex struct {
+	base     v1.ImageIndex
+	manifest *v1.IndexManifest
+	raw      []byte
+}
+
+var _ v1.ImageIndex = &filteredIndex{}
+
+func (i *filteredIndex) MediaType() (types.MediaType, error) {
+	return i.base.MediaType()
+}
+
+func (i *filteredIndex) Digest() (v1.Hash, error) {
+	return partial.Digest(i)
+}
+
+func (i *filteredIndex) Size() (int64, error) {
+	return partial.Size(i)
+}
+
+func (i *filteredIndex) IndexManifest() (*v1.IndexManifest, error) {
+	return i.manifest, nil
+}
+
+func (i *filteredIndex) RawManifest() ([]byte, error) {
+	return i.raw, nil
+}
+
+func (i *filteredIndex) Image(h v1.Hash) (v1.Image, error) {
+	return i.base.Image(h)
+}
+
+func (i *filteredIndex) ImageIndex(h v1.Hash) (v1.ImageIndex, error) {
+	return i.base.ImageIndex(h)
+}
@@ -0,0 +1,62 @@
+/*
+ * Copyright 2020 Sheaf Authors
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package fs
+
+import (
+	"testing"
+
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePlatform(t *testing.T) {
+	cases := []struct {
+		name    string
+		s       string
+		want    v1.Platform
+		wantErr bool
+	}{
+		{
+			name: "os and architecture",
+			s:    "linux/amd64",
+			want: v1.Platform{OS: "linux", Architecture: "amd64"},
+		},
+		{
+			name: "variant",
+			s:    "linux/arm64/v8",
+			want: v1.Platform{OS: "linux", Architecture: "arm64", Variant: "v8"},
+		},
+		{
+			name:    "missing architecture",
+			s:       "linux",
+			wantErr: true,
+		},
+		{
+			name:    "empty part",
+			s:       "linux//v8",
+			wantErr: true,
+		},
+		{
+			name:    "too many parts",
+			s:       "linux/arm64/v8/extra",
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := ParsePlatform(tc.s)
+			if tc.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+
+			require.Equal(t, tc.want, got)
+		})
+	}
+}
@@ -0,0 +1,84 @@
+/*
+ * Copyright 2020 Sheaf Authors
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package fs
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/google/go-containerregistry/pkg/v1/layout"
+
+	"github.com/bryanl/sheaf/pkg/reporter"
+	"github.com/bryanl/sheaf/pkg/sheaf"
+)
+
+// RegistryServerOption is a functional option for configuring RegistryServer.
+type RegistryServerOption func(rs RegistryServer) RegistryServer
+
+// RegistryServerReporter configures the reporter.
+func RegistryServerReporter(r reporter.Reporter) RegistryServerOption {
+	return func(rs RegistryServer) RegistryServer {
+		rs.reporter = r
+		return rs
+	}
+}
+
+// RegistryServer serves the OCI layout of a bundle as a read-only registry.
+type RegistryServer struct {
+	reporter reporter.Reporter
+}
+
+var _ sheaf.RegistryServer = &RegistryServer{}
+
+// NewRegistryServer creates an instance of RegistryServer.
+func NewRegistryServer(options ...RegistryServerOption) *RegistryServer {
+	rs := RegistryServer{
+		reporter: reporter.Default,
+	}
+
+	for _, option := range options {
+		rs = option(rs)
+	}
+
+	return &rs
+}
+
+// Serve serves the OCI layout in a bundle root path at an address until the
+// context is done.
+func (rs RegistryServer) Serve(ctx context.Context, rootPath, addr string) error {
+	layoutPath := layoutRootPath(rootPath)
+	if _, err := layout.FromPath(layoutPath); err != nil {
+		return fmt.Errorf("read layout: %w", err)
+	}
+
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+
+	server := &http.Server{Handler: NewLayoutRegistry(layoutPath)}
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- server.Serve(listener)
+	}()
+
+	rs.reporter.Reportf("Serving images at %s", listener.Addr())
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		return server.Shutdown(shutdownCtx)
+	}
+}
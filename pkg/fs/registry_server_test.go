@@ -0,0 +1,42 @@
+/*
+ * Copyright 2020 Sheaf Authors
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package fs
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/layout"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bryanl/sheaf/pkg/reporter"
+)
+
+func TestRegistryServer_Serve(t *testing.T) {
+	root, err := ioutil.TempDir("", "sheaf-test")
+	require.NoError(t, err)
+
+	defer func() {
+		require.NoError(t, os.RemoveAll(root))
+	}()
+
+	rs := NewRegistryServer(RegistryServerReporter(reporter.Nop{}))
+
+	// The bundle has no layout.
+	require.Error(t, rs.Serve(context.Background(), root, "127.0.0.1:0"))
+
+	_, err = layout.Write(layoutRootPath(root), empty.Index)
+	require.NoError(t, err)
+
+	// Serving stops when the context is done.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	require.NoError(t, rs.Serve(ctx, root, "127.0.0.1:0"))
+}
@@ -0,0 +1,275 @@
+/*
+ * Copyright 2020 Sheaf Authors
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package fs
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/layout"
+	"github.com/google/go-containerregistry/pkg/v1/tarball"
+	"github.com/pivotal/image-relocation/pkg/image"
+	"github.com/pivotal/image-relocation/pkg/registry/ggcr"
+
+	"github.com/bryanl/sheaf/pkg/sheaf"
+)
+
+const (
+	// LayoutRelocationOutput is the output prefix for relocating images to an
+	// OCI image layout directory.
+	LayoutRelocationOutput = "oci:"
+	// TarballRelocationOutput is the output prefix for relocating images to a
+	// docker save compatible tarball.
+	TarballRelocationOutput = "docker-archive:"
+)
+
+// refNameAnnotation is the annotation used by layouts to record image names.
+const refNameAnnotation = "org.opencontainers.image.ref.name"
+
+// relocationSource is the layout images are relocated from.
+type relocationSource struct {
+	layout Layout
+	path   layout.Path
+}
+
+// image returns the image or image index with a digest from the source layout.
+func (s relocationSource) image(digest image.Digest) (v1.Image, v1.ImageIndex, error) {
+	h, err := v1.NewHash(digest.String())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	index, err := s.path.ImageIndex()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if img, err := index.Image(h); err == nil {
+		return img, nil, nil
+	}
+
+	ii, err := index.ImageIndex(h)
+	if err != nil {
+		return nil, nil, fmt.Errorf("find %s in layout: %w", digest, err)
+	}
+
+	return nil, ii, nil
+}
+
+// relocationTarget is where relocated images are written.
+type relocationTarget interface {
+	// exists returns true if an image with a digest exists at a name.
+	exists(n image.Name, digest image.Digest) bool
+	// write writes an image with a digest from the source to a name.
+	write(src relocationSource, digest image.Digest, n image.Name) error
+	// close finishes writing images.
+	close() error
+}
+
+// newRelocationTarget creates a relocation target from an output. An empty
+// output relocates images to a registry.
+func (i ImageRelocator) newRelocationTarget(output string) (relocationTarget, error) {
+	switch {
+	case output == "":
+		return &registryTarget{
+			imageResolver: i.imageResolver,
+			retryAttempts: i.retryAttempts,
+			retryBackoff:  i.retryBackoff,
+		}, nil
+	case strings.HasPrefix(output, LayoutRelocationOutput):
+		return newLayoutTarget(strings.TrimPrefix(output, LayoutRelocationOutput))
+	case strings.HasPrefix(output, TarballRelocationOutput):
+		return newTarballTarget(strings.TrimPrefix(output, TarballRelocationOutput), i.platform)
+	default:
+		return nil, fmt.Errorf("unknown output %q (use %s<dir> or %s<file>)",
+			output, LayoutRelocationOutput, TarballRelocationOutput)
+	}
+}
+
+// registryTarget pushes images to a registry.
+type registryTarget struct {
+	imageResolver sheaf.ImageResolver
+	retryAttempts int
+	retryBackoff  time.Duration
+}
+
+func (t *registryTarget) exists(n image.Name, digest image.Digest) bool {
+	if t.imageResolver == nil {
+		return false
+	}
+
+	found, err := t.imageResolver.Resolve(n)
+	return err == nil && found == digest
+}
+
+func (t *registryTarget) write(src relocationSource, digest image.Digest, n image.Name) error {
+	// TODO: need to support insecure images
+	return retry(t.retryAttempts, t.retryBackoff, func() error {
+		return src.layout.Push(digest, n)
+	})
+}
+
+func (t *registryTarget) close() error {
+	return nil
+}
+
+// layoutTarget writes images to an OCI image layout directory. Images are
+// named the same way as in archive layouts, so the directory can be read as a
+// Layout and relocated again.
+type layoutTarget struct {
+	mu     sync.Mutex
+	path   layout.Path
+	layout Layout
+}
+
+func newLayoutTarget(dir string) (*layoutTarget, error) {
+	if dir == "" {
+		return nil, fmt.Errorf("layout directory is required")
+	}
+
+	p, err := layout.FromPath(dir)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			return nil, fmt.Errorf("read layout %s: %w", dir, err)
+		}
+
+		if p, err = layout.Write(dir, empty.Index); err != nil {
+			return nil, fmt.Errorf("create layout %s: %w", dir, err)
+		}
+	}
+
+	l, err := ggcr.NewRegistryClient().ReadLayout(dir)
+	if err != nil {
+		return nil, fmt.Errorf("read layout %s: %w", dir, err)
+	}
+
+	return &layoutTarget{path: p, layout: l}, nil
+}
+
+func (t *layoutTarget) exists(n image.Name, digest image.Digest) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	found, err := t.layout.Find(n)
+	return err == nil && found == digest
+}
+
+func (t *layoutTarget) write(src relocationSource, digest image.Digest, n image.Name) error {
+	img, ii, err := src.image(digest)
+	if err != nil {
+		return err
+	}
+
+	// Layout index writes are not safe for concurrent use.
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	annotations := layout.WithAnnotations(map[string]string{
+		refNameAnnotation: n.String(),
+	})
+
+	if img != nil {
+		return t.path.AppendImage(img, annotations)
+	}
+
+	return t.path.AppendIndex(ii, annotations)
+}
+
+func (t *layoutTarget) close() error {
+	return nil
+}
+
+// tarballTarget writes images to a docker save compatible tarball. Images are
+// collected as they are written, and the tarball is created when the target
+// is closed. Tarballs can't hold image indexes, so the image of the selected
+// platform is written for an image index.
+type tarballTarget struct {
+	mu       sync.Mutex
+	path     string
+	platform *v1.Platform
+	images   map[name.Reference]v1.Image
+}
+
+func newTarballTarget(path string, platform string) (*tarballTarget, error) {
+	if path == "" {
+		return nil, fmt.Errorf("tarball path is required")
+	}
+
+	t := &tarballTarget{
+		path:   path,
+		images: map[name.Reference]v1.Image{},
+	}
+
+	if platform != "" {
+		p, err := ParsePlatform(platform)
+		if err != nil {
+			return nil, err
+		}
+		t.platform = &p
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+
+	return t, nil
+}
+
+func (t *tarballTarget) exists(image.Name, image.Digest) bool {
+	return false
+}
+
+func (t *tarballTarget) write(src relocationSource, digest image.Digest, n image.Name) error {
+	if n.Tag() == "" {
+		return fmt.Errorf("%s has no tag; docker archives require tagged references", n)
+	}
+
+	img, ii, err := src.image(digest)
+	if err != nil {
+		return err
+	}
+
+	if img == nil {
+		if t.platform == nil {
+			return fmt.Errorf("%s is an image index; select a platform to write it to a docker archive", n)
+		}
+
+		if img, err = platformImage(ii, *t.platform); err != nil {
+			return fmt.Errorf("select image of %s: %w", n, err)
+		}
+	}
+
+	tag, err := name.NewTag(n.String())
+	if err != nil {
+		return err
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.images[tag] = img
+	return nil
+}
+
+func (t *tarballTarget) close() error {
+	if len(t.images) == 0 {
+		return nil
+	}
+
+	if err := tarball.MultiRefWriteToFile(t.path, t.images); err != nil {
+		return fmt.Errorf("write docker archive %s: %w", t.path, err)
+	}
+
+	return nil
+}
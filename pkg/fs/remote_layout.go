@@ -0,0 +1,233 @@
+/*
+ * Copyright 2020 Sheaf Authors
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package fs
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/layout"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+	"github.com/pivotal/image-relocation/pkg/image"
+	"go.uber.org/multierr"
+
+	sheafremote "github.com/bryanl/sheaf/pkg/remote"
+	"github.com/bryanl/sheaf/pkg/reporter"
+)
+
+// remoteLayout adds and pushes images itself instead of through the layout
+// registry client. Images are added from the registry's mirrors before the
+// registry itself, images can't be added from or pushed to blocked
+// registries, and image indexes can be limited to a set of platforms. Push
+// errors keep their type, so transient registry errors can be told apart.
+type remoteLayout struct {
+	Layout
+
+	path          layout.Path
+	registries    *sheafremote.Registries
+	keychain      authn.Keychain
+	transport     http.RoundTripper
+	pushTransport http.RoundTripper
+	platforms     []v1.Platform
+	reporter      reporter.Reporter
+}
+
+var _ Layout = &remoteLayout{}
+
+// Add adds an image to the layout.
+func (l *remoteLayout) Add(n image.Name) (image.Digest, error) {
+	return l.add(n, false)
+}
+
+// AddLocked adds an image pinned to its locked digest to the layout. Unlike
+// images referenced by digest in manifests, the index of a locked image is
+// limited to the selected platforms. The returned digest is the digest of the
+// stored image, which differs from the locked digest if platforms were left out.
+func (l *remoteLayout) AddLocked(n image.Name) (image.Digest, error) {
+	return l.add(n, true)
+}
+
+func (l *remoteLayout) add(n image.Name, locked bool) (image.Digest, error) {
+	if l.registries.IsDefault() && len(l.platforms) == 0 {
+		return l.Layout.Add(n)
+	}
+
+	ref, err := name.ParseReference(n.String())
+	if err != nil {
+		return image.EmptyDigest, fmt.Errorf("parse reference %s: %w", n, err)
+	}
+
+	refs, err := l.registries.PullReferences(ref)
+	if err != nil {
+		return image.EmptyDigest, fmt.Errorf("add %s: %w", n, err)
+	}
+
+	var errs error
+	for _, pullRef := range refs {
+		digest, err := l.addFrom(pullRef, n, locked)
+		if err != nil {
+			errs = multierr.Append(errs, err)
+			continue
+		}
+
+		return digest, nil
+	}
+
+	return image.EmptyDigest, errs
+}
+
+// Push pushes an image from the layout.
+func (l *remoteLayout) Push(digest image.Digest, n image.Name) error {
+	ref, err := name.ParseReference(n.String())
+	if err != nil {
+		return fmt.Errorf("parse reference %s: %w", n, err)
+	}
+
+	if _, err := l.registries.PushReference(ref); err != nil {
+		return fmt.Errorf("push %s: %w", n, err)
+	}
+
+	h, err := v1.NewHash(digest.String())
+	if err != nil {
+		return err
+	}
+
+	// A name with a tag and a digest is pushed by tag. The digest is kept by
+	// the push.
+	if n.Tag() != "" {
+		if ref, err = name.ParseReference(n.WithoutDigest().String()); err != nil {
+			return fmt.Errorf("parse reference %s: %w", n, err)
+		}
+	}
+
+	auth, err := l.keychainOrDefault().Resolve(ref.Context().Registry)
+	if err != nil {
+		return fmt.Errorf("authenticate with %s: %w", ref.Context().RegistryStr(), err)
+	}
+
+	options := []remote.Option{remote.WithAuth(auth), remote.WithTransport(l.pushTransport)}
+
+	index, err := l.path.ImageIndex()
+	if err != nil {
+		return err
+	}
+
+	if img, err := index.Image(h); err == nil {
+		if err := remote.Write(ref, img, options...); err != nil {
+			return fmt.Errorf("write image %s to %s: %w", digest, n, err)
+		}
+
+		return nil
+	}
+
+	ii, err := index.ImageIndex(h)
+	if err != nil {
+		return fmt.Errorf("find %s in layout: %w", digest, err)
+	}
+
+	if err := remote.WriteIndex(ref, ii, options...); err != nil {
+		return fmt.Errorf("write image index %s to %s: %w", digest, n, err)
+	}
+
+	return nil
+}
+
+// addFrom adds the image at a reference to the layout using the name of the
+// image, which differs from the reference if it is a mirror.
+func (l *remoteLayout) addFrom(ref name.Reference, n image.Name, locked bool) (image.Digest, error) {
+	desc, err := remote.Get(ref, remote.WithAuthFromKeychain(l.keychainOrDefault()), remote.WithTransport(l.transport))
+	if err != nil {
+		return image.EmptyDigest, fmt.Errorf("fetch %s: %w", ref, err)
+	}
+
+	if n.Digest() != image.EmptyDigest && desc.Digest.String() != n.Digest().String() {
+		return image.EmptyDigest, fmt.Errorf("%s resolved to %s instead of %s", ref, desc.Digest, n.Digest())
+	}
+
+	switch desc.MediaType {
+	case types.OCIImageIndex, types.DockerManifestList:
+		ii, err := desc.ImageIndex()
+		if err != nil {
+			return image.EmptyDigest, err
+		}
+
+		ii, err = l.filterPlatforms(ii, n, locked)
+		if err != nil {
+			return image.EmptyDigest, fmt.Errorf("select platforms of %s: %w", n, err)
+		}
+
+		h, err := ii.Digest()
+		if err != nil {
+			return image.EmptyDigest, err
+		}
+
+		digest, err := image.NewDigest(h.String())
+		if err != nil {
+			return image.EmptyDigest, err
+		}
+
+		// A locked image is stored with the digest of the filtered index, so
+		// it can be found once the lock is updated.
+		if n.Digest() != image.EmptyDigest && n.Digest() != digest {
+			if n, err = n.WithoutDigest().WithDigest(digest); err != nil {
+				return image.EmptyDigest, err
+			}
+		}
+
+		if err := l.path.AppendIndex(ii, refNameAnnotations(n)); err != nil {
+			return image.EmptyDigest, err
+		}
+
+		return digest, nil
+	default:
+		img, err := desc.Image()
+		if err != nil {
+			return image.EmptyDigest, err
+		}
+
+		if err := l.path.AppendImage(img, refNameAnnotations(n)); err != nil {
+			return image.EmptyDigest, err
+		}
+
+		return image.NewDigest(desc.Digest.String())
+	}
+}
+
+// filterPlatforms limits an index to the selected platforms. Images
+// referenced by digest in manifests keep every platform, because filtering
+// changes the index digest. Locked images are filtered because the lock is
+// updated with the digest of the filtered index.
+func (l *remoteLayout) filterPlatforms(ii v1.ImageIndex, n image.Name, locked bool) (v1.ImageIndex, error) {
+	if len(l.platforms) == 0 {
+		return ii, nil
+	}
+
+	if n.Digest() != image.EmptyDigest && !locked {
+		l.reporter.Reportf("Keeping all platforms of %s because it is referenced by digest", n)
+		return ii, nil
+	}
+
+	return filterIndex(ii, l.platforms)
+}
+
+func (l *remoteLayout) keychainOrDefault() authn.Keychain {
+	if l.keychain == nil {
+		return authn.DefaultKeychain
+	}
+
+	return l.keychain
+}
+
+func refNameAnnotations(n image.Name) layout.Option {
+	return layout.WithAnnotations(map[string]string{
+		refNameAnnotation: n.String(),
+	})
+}
@@ -0,0 +1,387 @@
+/*
+ * Copyright 2020 Sheaf Authors
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package fs
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/registry"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/layout"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/pivotal/image-relocation/pkg/image"
+	"github.com/stretchr/testify/require"
+
+	sheafremote "github.com/bryanl/sheaf/pkg/remote"
+)
+
+func TestDefaultLayoutFactoryRegistries(t *testing.T) {
+	server := httptest.NewServer(registry.New(registry.Logger(log.New(ioutil.Discard, "", 0))))
+	defer server.Close()
+
+	u, err := url.Parse(server.URL)
+	require.NoError(t, err)
+
+	dir, err := ioutil.TempDir("", "sheaf-test")
+	require.NoError(t, err)
+
+	defer func() {
+		require.NoError(t, os.RemoveAll(dir))
+	}()
+
+	img, err := random.Image(256, 1)
+	require.NoError(t, err)
+
+	for _, s := range []string{"mirror/app:1.0", "blocked/app:1.0"} {
+		ref, err := name.ParseReference(fmt.Sprintf("%s/%s", u.Host, s))
+		require.NoError(t, err)
+		require.NoError(t, remote.Write(ref, img))
+	}
+
+	config := filepath.Join(dir, "registries.conf")
+	require.NoError(t, ioutil.WriteFile(config, []byte(fmt.Sprintf(`
+[[registry]]
+location = "registry.invalid"
+
+[[registry.mirror]]
+location = "%[1]s/mirror"
+
+[[registry]]
+location = "%[1]s/blocked"
+blocked = true
+`, u.Host)), 0600))
+
+	l, err := DefaultLayoutFactory(DefaultLayoutFactoryRegistries(sheafremote.NewRegistries(config)))(dir)
+	require.NoError(t, err)
+
+	want, err := img.Digest()
+	require.NoError(t, err)
+
+	// The image is added from the mirror, and named as the original image.
+	mirrored, err := image.NewName("registry.invalid/app:1.0")
+	require.NoError(t, err)
+
+	digest, err := l.Add(mirrored)
+	require.NoError(t, err)
+	require.Equal(t, want.String(), digest.String())
+
+	found, err := l.Find(mirrored)
+	require.NoError(t, err)
+	require.Equal(t, digest, found)
+
+	blocked, err := image.NewName(fmt.Sprintf("%s/blocked/app:1.0", u.Host))
+	require.NoError(t, err)
+
+	_, err = l.Add(blocked)
+	require.Error(t, err)
+
+	require.Error(t, l.Push(digest, blocked))
+
+	pushed, err := image.NewName(fmt.Sprintf("%s/pushed/app:1.0", u.Host))
+	require.NoError(t, err)
+	require.NoError(t, l.Push(digest, pushed))
+}
+
+func TestRemoteLayout_Push_keychain(t *testing.T) {
+	server := httptest.NewServer(registry.New(registry.Logger(log.New(ioutil.Discard, "", 0))))
+	defer server.Close()
+
+	u, err := url.Parse(server.URL)
+	require.NoError(t, err)
+
+	dir, err := ioutil.TempDir("", "sheaf-test")
+	require.NoError(t, err)
+
+	defer func() {
+		require.NoError(t, os.RemoveAll(dir))
+	}()
+
+	img, err := random.Image(256, 1)
+	require.NoError(t, err)
+
+	ref, err := name.ParseReference(fmt.Sprintf("%s/app:1.0", u.Host))
+	require.NoError(t, err)
+	require.NoError(t, remote.Write(ref, img))
+
+	keychain := &recordingKeychain{}
+	l, err := DefaultLayoutFactory(DefaultLayoutFactoryKeychain(keychain))(dir)
+	require.NoError(t, err)
+
+	n, err := image.NewName(ref.String())
+	require.NoError(t, err)
+
+	digest, err := l.Add(n)
+	require.NoError(t, err)
+
+	pushed, err := image.NewName(fmt.Sprintf("%s/pushed/app:1.0", u.Host))
+	require.NoError(t, err)
+	require.NoError(t, l.Push(digest, pushed))
+
+	// The push authenticates with the registry through the configured
+	// keychain, not only through the repository transport.
+	pushedRef, err := name.ParseReference(pushed.String())
+	require.NoError(t, err)
+	require.Contains(t, keychain.resources(), authn.Resource(pushedRef.Context().Registry))
+}
+
+// recordingKeychain is an anonymous keychain that records the resources it
+// resolves.
+type recordingKeychain struct {
+	mu       sync.Mutex
+	resolved []authn.Resource
+}
+
+func (k *recordingKeychain) Resolve(r authn.Resource) (authn.Authenticator, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	k.resolved = append(k.resolved, r)
+	return authn.Anonymous, nil
+}
+
+func (k *recordingKeychain) resources() []authn.Resource {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	return append([]authn.Resource(nil), k.resolved...)
+}
+
+func Test_newRegistriesInsecureTransport(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sheaf-test")
+	require.NoError(t, err)
+
+	defer func() {
+		require.NoError(t, os.RemoveAll(dir))
+	}()
+
+	config := filepath.Join(dir, "registries.conf")
+	require.NoError(t, ioutil.WriteFile(config, []byte(`
+[[registry]]
+location = "insecure.example.com/team"
+insecure = true
+`), 0600))
+
+	cases := []struct {
+		name string
+		url  string
+		want string
+	}{
+		{
+			name: "insecure registry",
+			url:  "https://insecure.example.com/v2/",
+			want: "http",
+		},
+		{
+			name: "other registry",
+			url:  "https://registry.example.com/v2/",
+			want: "https",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			tr := newRegistriesInsecureTransport(sheafremote.NewRegistries(config), nil)
+
+			var scheme string
+			tr.roundTripperFunc = func(r *http.Request) (*http.Response, error) {
+				scheme = r.URL.Scheme
+				return &http.Response{Body: ioutil.NopCloser(&bytes.Buffer{})}, nil
+			}
+
+			r, err := http.NewRequest(http.MethodGet, tc.url, nil)
+			require.NoError(t, err)
+
+			resp, err := tr.RoundTrip(r)
+			require.NoError(t, err)
+			require.NoError(t, resp.Body.Close())
+
+			require.Equal(t, tc.want, scheme)
+		})
+	}
+}
+
+func TestDefaultLayoutFactoryPlatforms(t *testing.T) {
+	server := httptest.NewServer(registry.New(registry.Logger(log.New(ioutil.Discard, "", 0))))
+	defer server.Close()
+
+	u, err := url.Parse(server.URL)
+	require.NoError(t, err)
+
+	amd64, err := random.Image(256, 1)
+	require.NoError(t, err)
+	arm64, err := random.Image(256, 1)
+	require.NoError(t, err)
+
+	ii := mutate.AppendManifests(empty.Index,
+		mutate.IndexAddendum{
+			Add:        amd64,
+			Descriptor: v1.Descriptor{Platform: &v1.Platform{OS: "linux", Architecture: "amd64"}},
+		},
+		mutate.IndexAddendum{
+			Add:        arm64,
+			Descriptor: v1.Descriptor{Platform: &v1.Platform{OS: "linux", Architecture: "arm64", Variant: "v8"}},
+		},
+	)
+
+	indexDigest, err := ii.Digest()
+	require.NoError(t, err)
+	amd64Digest, err := amd64.Digest()
+	require.NoError(t, err)
+	arm64Digest, err := arm64.Digest()
+	require.NoError(t, err)
+
+	ref, err := name.ParseReference(fmt.Sprintf("%s/app:1.0", u.Host))
+	require.NoError(t, err)
+	require.NoError(t, remote.WriteIndex(ref, ii))
+
+	cases := []struct {
+		name          string
+		image         string
+		platforms     []string
+		locked        bool
+		wantPlatforms []v1.Hash
+		wantDigest    *v1.Hash
+		wantErr       bool
+	}{
+		{
+			name:          "every platform",
+			image:         ref.String(),
+			wantPlatforms: []v1.Hash{amd64Digest, arm64Digest},
+			wantDigest:    &indexDigest,
+		},
+		{
+			name:          "selected platform",
+			image:         ref.String(),
+			platforms:     []string{"linux/arm64"},
+			wantPlatforms: []v1.Hash{arm64Digest},
+		},
+		{
+			name:          "referenced by digest",
+			image:         fmt.Sprintf("%s/app@%s", u.Host, indexDigest),
+			platforms:     []string{"linux/arm64"},
+			wantPlatforms: []v1.Hash{amd64Digest, arm64Digest},
+			wantDigest:    &indexDigest,
+		},
+		{
+			name:          "locked",
+			image:         fmt.Sprintf("%s/app:1.0@%s", u.Host, indexDigest),
+			platforms:     []string{"linux/arm64"},
+			locked:        true,
+			wantPlatforms: []v1.Hash{arm64Digest},
+		},
+		{
+			name:      "no matching platform",
+			image:     ref.String(),
+			platforms: []string{"windows/amd64"},
+			wantErr:   true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			dir, err := ioutil.TempDir("", "sheaf-test")
+			require.NoError(t, err)
+
+			defer func() {
+				require.NoError(t, os.RemoveAll(dir))
+			}()
+
+			l, err := DefaultLayoutFactory(DefaultLayoutFactoryPlatforms(tc.platforms...))(dir)
+			require.NoError(t, err)
+
+			n, err := image.NewName(tc.image)
+			require.NoError(t, err)
+
+			add := l.Add
+			if tc.locked {
+				add = l.(*remoteLayout).AddLocked
+			}
+
+			digest, err := add(n)
+			if tc.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+
+			if tc.wantDigest != nil {
+				require.Equal(t, tc.wantDigest.String(), digest.String())
+			}
+
+			h, err := v1.NewHash(digest.String())
+			require.NoError(t, err)
+
+			stored, err := layout.ImageIndexFromPath(layoutRootPath(dir))
+			require.NoError(t, err)
+
+			storedIndex, err := stored.ImageIndex(h)
+			require.NoError(t, err)
+
+			im, err := storedIndex.IndexManifest()
+			require.NoError(t, err)
+
+			var got []v1.Hash
+			for _, desc := range im.Manifests {
+				got = append(got, desc.Digest)
+			}
+			require.Equal(t, tc.wantPlatforms, got)
+
+			// The image is stored under its name with the stored digest.
+			if n.Digest() != image.EmptyDigest {
+				n, err = n.WithoutDigest().WithDigest(digest)
+				require.NoError(t, err)
+			}
+			descriptors, err := layoutDescriptors(layoutRootPath(dir))
+			require.NoError(t, err)
+			_, ok := findDescriptor(descriptors, n)
+			require.True(t, ok)
+
+			// Images of other platforms are not stored.
+			for _, h := range []v1.Hash{amd64Digest, arm64Digest} {
+				_, err := os.Stat(filepath.Join(layoutRootPath(dir), "blobs", h.Algorithm, h.Hex))
+				require.Equal(t, containsHash(tc.wantPlatforms, h), err == nil)
+			}
+		})
+	}
+}
+
+func TestDefaultLayoutFactoryPlatforms_invalid(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sheaf-test")
+	require.NoError(t, err)
+
+	defer func() {
+		require.NoError(t, os.RemoveAll(dir))
+	}()
+
+	_, err = DefaultLayoutFactory(DefaultLayoutFactoryPlatforms("linux"))(dir)
+	require.Error(t, err)
+}
+
+func containsHash(list []v1.Hash, h v1.Hash) bool {
+	for _, item := range list {
+		if item == h {
+			return true
+		}
+	}
+
+	return false
+}
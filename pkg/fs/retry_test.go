@@ -0,0 +1,185 @@
+/*
+ * Copyright 2020 Sheaf Authors
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package fs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/google/go-containerregistry/pkg/v1/layout"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/remote/transport"
+	"github.com/pivotal/image-relocation/pkg/image"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_retry(t *testing.T) {
+	cases := []struct {
+		name      string
+		attempts  int
+		failures  int
+		err       error
+		wantCalls int
+		wantErr   bool
+	}{
+		{
+			name:      "succeeds first time",
+			attempts:  3,
+			wantCalls: 1,
+		},
+		{
+			name:      "succeeds after failures",
+			attempts:  3,
+			failures:  2,
+			wantCalls: 3,
+		},
+		{
+			name:      "fails every attempt",
+			attempts:  3,
+			failures:  5,
+			wantCalls: 3,
+			wantErr:   true,
+		},
+		{
+			name:      "at least one attempt",
+			failures:  5,
+			wantCalls: 1,
+			wantErr:   true,
+		},
+		{
+			name:      "rate limited",
+			attempts:  3,
+			failures:  1,
+			err:       &transport.Error{StatusCode: http.StatusTooManyRequests},
+			wantCalls: 2,
+		},
+		{
+			name:      "connection failed",
+			attempts:  3,
+			failures:  1,
+			err:       &net.OpError{Op: "dial", Err: fmt.Errorf("connection refused")},
+			wantCalls: 2,
+		},
+		{
+			name:      "unauthorized is not retried",
+			attempts:  3,
+			failures:  5,
+			err:       fmt.Errorf("write image: %w", &transport.Error{StatusCode: http.StatusUnauthorized}),
+			wantCalls: 1,
+			wantErr:   true,
+		},
+		{
+			name:      "not found is not retried",
+			attempts:  3,
+			failures:  5,
+			err:       &transport.Error{StatusCode: http.StatusNotFound},
+			wantCalls: 1,
+			wantErr:   true,
+		},
+		{
+			name:      "canceled is not retried",
+			attempts:  3,
+			failures:  5,
+			err:       fmt.Errorf("write image: %w", context.Canceled),
+			wantCalls: 1,
+			wantErr:   true,
+		},
+		{
+			name:      "other errors are not retried",
+			attempts:  3,
+			failures:  5,
+			err:       fmt.Errorf("digest mismatch"),
+			wantCalls: 1,
+			wantErr:   true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			calls := 0
+			err := retry(tc.attempts, time.Millisecond, func() error {
+				calls++
+				if calls <= tc.failures {
+					if tc.err != nil {
+						return tc.err
+					}
+					return &transport.Error{StatusCode: http.StatusServiceUnavailable}
+				}
+				return nil
+			})
+
+			require.Equal(t, tc.wantCalls, calls)
+			if tc.wantErr {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+		})
+	}
+}
+
+func Test_retry_unauthorizedPush(t *testing.T) {
+	// Pings are anonymous, and every push is unauthorized.
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v2/" {
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+	defer server.Close()
+
+	u, err := url.Parse(server.URL)
+	require.NoError(t, err)
+
+	dir, err := ioutil.TempDir("", "sheaf-test")
+	require.NoError(t, err)
+
+	defer func() {
+		require.NoError(t, os.RemoveAll(dir))
+	}()
+
+	// Keep the default keychain from reading the user's docker config.
+	defer os.Setenv("DOCKER_CONFIG", os.Getenv("DOCKER_CONFIG"))
+	require.NoError(t, os.Setenv("DOCKER_CONFIG", dir))
+
+	l, err := DefaultLayoutFactory()(dir)
+	require.NoError(t, err)
+
+	img, err := random.Image(256, 1)
+	require.NoError(t, err)
+	require.NoError(t, layout.Path(layoutRootPath(dir)).AppendImage(img))
+
+	h, err := img.Digest()
+	require.NoError(t, err)
+
+	digest, err := image.NewDigest(h.String())
+	require.NoError(t, err)
+
+	n, err := image.NewName(fmt.Sprintf("%s/app:1.0", u.Host))
+	require.NoError(t, err)
+
+	calls := 0
+	err = retry(4, time.Millisecond, func() error {
+		calls++
+		return l.Push(digest, n)
+	})
+	require.Error(t, err)
+
+	var te *transport.Error
+	require.True(t, errors.As(err, &te))
+	require.Equal(t, http.StatusUnauthorized, te.StatusCode)
+	require.Equal(t, 1, calls)
+}
@@ -0,0 +1,291 @@
+/*
+ * Copyright 2020 Sheaf Authors
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package fs
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/bryanl/sheaf/pkg/sheaf"
+)
+
+// SignatureFile is the file in an archive that contains its signature.
+const SignatureFile = "signature.json"
+
+const (
+	ed25519Algorithm     = "ed25519"
+	ecdsaSHA256Algorithm = "ecdsa-sha256"
+)
+
+// Signature is the signature of an archive. The digest covers the path and
+// content of every file in the archive except the signature itself.
+type Signature struct {
+	// Algorithm is the signing algorithm.
+	Algorithm string `json:"algorithm"`
+	// Digest is the digest of the archive contents.
+	Digest string `json:"digest"`
+	// Signature is the signature of the digest.
+	Signature []byte `json:"signature"`
+}
+
+// loadSigningKey loads an ed25519 or ECDSA private key from a PEM file.
+func loadSigningKey(path string) (crypto.Signer, error) {
+	block, err := readPEM(path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch block.Type {
+	case "PRIVATE KEY":
+		key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("parse private key %s: %w", path, err)
+		}
+
+		switch key := key.(type) {
+		case ed25519.PrivateKey:
+			return key, nil
+		case *ecdsa.PrivateKey:
+			return key, nil
+		default:
+			return nil, fmt.Errorf("private key %s is not an ed25519 or ECDSA key", path)
+		}
+	case "EC PRIVATE KEY":
+		key, err := x509.ParseECPrivateKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("parse private key %s: %w", path, err)
+		}
+
+		return key, nil
+	default:
+		return nil, fmt.Errorf("%s does not contain a private key", path)
+	}
+}
+
+// loadVerificationKey loads an ed25519 or ECDSA public key from a PEM file.
+func loadVerificationKey(path string) (crypto.PublicKey, error) {
+	block, err := readPEM(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if block.Type != "PUBLIC KEY" {
+		return nil, fmt.Errorf("%s does not contain a public key", path)
+	}
+
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("parse public key %s: %w", path, err)
+	}
+
+	switch key := key.(type) {
+	case ed25519.PublicKey:
+		return key, nil
+	case *ecdsa.PublicKey:
+		return key, nil
+	default:
+		return nil, fmt.Errorf("public key %s is not an ed25519 or ECDSA key", path)
+	}
+}
+
+func readPEM(path string) (*pem.Block, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read key: %w", err)
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("%s is not a PEM file", path)
+	}
+
+	return block, nil
+}
+
+// contentsDigest returns the SHA-256 digest of the files in an archive. It
+// is the digest of a sorted list of file checksums and paths, so it changes
+// if any file is added, removed, renamed, or modified. The blobs a delta
+// archive takes from its base archive are not part of the digest; they are
+// covered by the base archive digest in the delta file.
+func contentsDigest(root string) ([]byte, error) {
+	baseBlobs := map[string]bool{}
+	delta, err := readDelta(root)
+	if err != nil {
+		return nil, err
+	}
+	if delta != nil {
+		if baseBlobs, err = delta.baseBlobNames(); err != nil {
+			return nil, err
+		}
+	}
+
+	var files []string
+	err = filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if info.IsDir() {
+			return nil
+		}
+
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+
+		rel = filepath.ToSlash(rel)
+		if rel == SignatureFile || baseBlobs[rel] {
+			return nil
+		}
+
+		files = append(files, rel)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Strings(files)
+
+	var buf bytes.Buffer
+	for _, file := range files {
+		sum, err := fileChecksum(filepath.Join(root, filepath.FromSlash(file)))
+		if err != nil {
+			return nil, err
+		}
+
+		fmt.Fprintf(&buf, "%s  %s\n", sum, file)
+	}
+
+	digest := sha256.Sum256(buf.Bytes())
+	return digest[:], nil
+}
+
+// signArchive signs the contents of an archive and writes the signature to
+// the archive. ed25519 signatures are deterministic, so the signature is the
+// same every time. ECDSA signatures use a random nonce and differ each time.
+func signArchive(root string, key crypto.Signer) error {
+	digest, err := contentsDigest(root)
+	if err != nil {
+		return fmt.Errorf("create archive digest: %w", err)
+	}
+
+	signature := Signature{
+		Digest: "sha256:" + hex.EncodeToString(digest),
+	}
+
+	switch key.(type) {
+	case ed25519.PrivateKey:
+		signature.Algorithm = ed25519Algorithm
+		signature.Signature, err = key.Sign(rand.Reader, digest, crypto.Hash(0))
+	default:
+		signature.Algorithm = ecdsaSHA256Algorithm
+		signature.Signature, err = key.Sign(rand.Reader, digest, crypto.SHA256)
+	}
+	if err != nil {
+		return fmt.Errorf("sign archive digest: %w", err)
+	}
+
+	data, err := json.MarshalIndent(signature, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(filepath.Join(root, SignatureFile), data, 0600)
+}
+
+// SignatureVerifier verifies archive signatures with a public key.
+type SignatureVerifier struct {
+	keyPath string
+}
+
+var _ sheaf.SignatureVerifier = &SignatureVerifier{}
+
+// NewSignatureVerifier creates an instance of SignatureVerifier that
+// verifies signatures with the public key at a path.
+func NewSignatureVerifier(keyPath string) *SignatureVerifier {
+	return &SignatureVerifier{
+		keyPath: keyPath,
+	}
+}
+
+// VerifySignature verifies that an exploded archive was signed with the
+// private key matching the verifier's public key and hasn't changed since.
+func (sv *SignatureVerifier) VerifySignature(b sheaf.Bundle) error {
+	key, err := loadVerificationKey(sv.keyPath)
+	if err != nil {
+		return err
+	}
+
+	data, err := ioutil.ReadFile(filepath.Join(b.Path(), SignatureFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("archive is not signed")
+		}
+		return fmt.Errorf("read signature: %w", err)
+	}
+
+	var signature Signature
+	if err := json.Unmarshal(data, &signature); err != nil {
+		return fmt.Errorf("decode signature: %w", err)
+	}
+
+	digest, err := contentsDigest(b.Path())
+	if err != nil {
+		return fmt.Errorf("create archive digest: %w", err)
+	}
+
+	if got := "sha256:" + hex.EncodeToString(digest); got != signature.Digest {
+		return fmt.Errorf("archive contents (%s) do not match the signed digest (%s)", got, signature.Digest)
+	}
+
+	if !verifyDigest(key, signature, digest) {
+		return fmt.Errorf("signature is not valid for key %s", sv.keyPath)
+	}
+
+	return nil
+}
+
+// verifyDigest verifies the signature of a digest.
+func verifyDigest(key crypto.PublicKey, signature Signature, digest []byte) bool {
+	switch key := key.(type) {
+	case ed25519.PublicKey:
+		return signature.Algorithm == ed25519Algorithm &&
+			ed25519.Verify(key, digest, signature.Signature)
+	case *ecdsa.PublicKey:
+		if signature.Algorithm != ecdsaSHA256Algorithm {
+			return false
+		}
+
+		var sig struct {
+			R, S *big.Int
+		}
+		if rest, err := asn1.Unmarshal(signature.Signature, &sig); err != nil || len(rest) > 0 {
+			return false
+		}
+
+		return ecdsa.Verify(key, digest, sig.R, sig.S)
+	default:
+		return false
+	}
+}
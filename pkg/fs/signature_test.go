@@ -0,0 +1,261 @@
+/*
+ * Copyright 2020 Sheaf Authors
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package fs
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bryanl/sheaf/pkg/mocks"
+)
+
+func TestSignatureVerifier_VerifySignature(t *testing.T) {
+	ed25519Public, ed25519Private, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	ecdsaPrivate, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	otherPublic, _, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name       string
+		signingKey crypto.Signer
+		publicKey  crypto.PublicKey
+		delta      bool
+		mutate     func(t *testing.T, root string)
+		wantErr    string
+	}{
+		{
+			name:       "ed25519",
+			signingKey: ed25519Private,
+			publicKey:  ed25519Public,
+		},
+		{
+			name:       "ecdsa",
+			signingKey: ecdsaPrivate,
+			publicKey:  ecdsaPrivate.Public(),
+		},
+		{
+			name:       "wrong key",
+			signingKey: ed25519Private,
+			publicKey:  otherPublic,
+			wantErr:    "signature is not valid",
+		},
+		{
+			name:       "wrong key type",
+			signingKey: ed25519Private,
+			publicKey:  ecdsaPrivate.Public(),
+			wantErr:    "signature is not valid",
+		},
+		{
+			name:       "modified file",
+			signingKey: ed25519Private,
+			publicKey:  ed25519Public,
+			mutate: func(t *testing.T, root string) {
+				require.NoError(t, ioutil.WriteFile(filepath.Join(root, "bundle.json"), []byte("{}"), 0600))
+			},
+			wantErr: "do not match the signed digest",
+		},
+		{
+			name:       "added file",
+			signingKey: ed25519Private,
+			publicKey:  ed25519Public,
+			mutate: func(t *testing.T, root string) {
+				require.NoError(t, ioutil.WriteFile(filepath.Join(manifestsPath(root), "added.yaml"), []byte("added"), 0600))
+			},
+			wantErr: "do not match the signed digest",
+		},
+		{
+			name:       "delta with blobs from its base",
+			signingKey: ed25519Private,
+			publicKey:  ed25519Public,
+			delta:      true,
+			mutate:     addBaseBlob,
+		},
+		{
+			name:       "delta without its delta file",
+			signingKey: ed25519Private,
+			publicKey:  ed25519Public,
+			delta:      true,
+			mutate: func(t *testing.T, root string) {
+				addBaseBlob(t, root)
+				require.NoError(t, os.Remove(filepath.Join(root, DeltaFile)))
+			},
+			wantErr: "do not match the signed digest",
+		},
+		{
+			name:      "not signed",
+			publicKey: ed25519Public,
+			wantErr:   "archive is not signed",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			root, err := ioutil.TempDir("", "sheaf-test")
+			require.NoError(t, err)
+
+			defer func() {
+				require.NoError(t, os.RemoveAll(root))
+			}()
+
+			require.NoError(t, os.MkdirAll(manifestsPath(root), 0700))
+			require.NoError(t, ioutil.WriteFile(filepath.Join(root, "bundle.json"), []byte(`{"name":"bundle"}`), 0600))
+			require.NoError(t, ioutil.WriteFile(filepath.Join(manifestsPath(root), "deploy.yaml"), []byte("kind: Deployment\n"), 0600))
+
+			if test.delta {
+				data, err := json.Marshal(Delta{
+					Base:  DeltaBase{Archive: "base.tgz", Digest: "sha256:base"},
+					Blobs: []string{testBaseBlob.String()},
+				})
+				require.NoError(t, err)
+				require.NoError(t, ioutil.WriteFile(filepath.Join(root, DeltaFile), data, 0600))
+			}
+
+			if test.signingKey != nil {
+				require.NoError(t, signArchive(root, test.signingKey))
+			}
+
+			if test.mutate != nil {
+				test.mutate(t, root)
+			}
+
+			keyPath := filepath.Join(root, "..", filepath.Base(root)+".pub")
+			writePublicKey(t, keyPath, test.publicKey)
+			defer func() {
+				require.NoError(t, os.Remove(keyPath))
+			}()
+
+			controller := gomock.NewController(t)
+			defer controller.Finish()
+
+			b := mocks.NewMockBundle(controller)
+			b.EXPECT().Path().Return(root).AnyTimes()
+
+			sv := NewSignatureVerifier(keyPath)
+
+			err = sv.VerifySignature(b)
+			if test.wantErr != "" {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), test.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+		})
+	}
+}
+
+// testBaseBlob is a blob a delta archive takes from its base archive.
+var testBaseBlob = v1.Hash{Algorithm: "sha256", Hex: fmt.Sprintf("%x", sha256.Sum256([]byte("layer")))}
+
+// addBaseBlob adds the blob taken from the base archive, as applying a delta
+// archive does.
+func addBaseBlob(t *testing.T, root string) {
+	name := filepath.Join(root, filepath.FromSlash(blobName(testBaseBlob)))
+	require.NoError(t, os.MkdirAll(filepath.Dir(name), 0700))
+	require.NoError(t, ioutil.WriteFile(name, []byte("layer"), 0600))
+}
+
+func Test_loadSigningKey(t *testing.T) {
+	_, ed25519Private, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	ecdsaPrivate, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	rsaPrivate, err := rsa.GenerateKey(rand.Reader, 1024)
+	require.NoError(t, err)
+
+	pkcs8 := func(key interface{}) *pem.Block {
+		der, err := x509.MarshalPKCS8PrivateKey(key)
+		require.NoError(t, err)
+		return &pem.Block{Type: "PRIVATE KEY", Bytes: der}
+	}
+
+	ecDER, err := x509.MarshalECPrivateKey(ecdsaPrivate)
+	require.NoError(t, err)
+
+	publicDER, err := x509.MarshalPKIXPublicKey(ed25519Private.Public())
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		block   *pem.Block
+		wantErr bool
+	}{
+		{
+			name:  "ed25519",
+			block: pkcs8(ed25519Private),
+		},
+		{
+			name:  "ecdsa",
+			block: pkcs8(ecdsaPrivate),
+		},
+		{
+			name:  "ecdsa sec1",
+			block: &pem.Block{Type: "EC PRIVATE KEY", Bytes: ecDER},
+		},
+		{
+			name:    "rsa",
+			block:   pkcs8(rsaPrivate),
+			wantErr: true,
+		},
+		{
+			name:    "public key",
+			block:   &pem.Block{Type: "PUBLIC KEY", Bytes: publicDER},
+			wantErr: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			f, err := ioutil.TempFile("", "sheaf-test")
+			require.NoError(t, err)
+
+			defer func() {
+				require.NoError(t, os.Remove(f.Name()))
+			}()
+
+			require.NoError(t, pem.Encode(f, test.block))
+			require.NoError(t, f.Close())
+
+			_, err = loadSigningKey(f.Name())
+			if test.wantErr {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+		})
+	}
+}
+
+func writePublicKey(t *testing.T, path string, key crypto.PublicKey) {
+	der, err := x509.MarshalPKIXPublicKey(key)
+	require.NoError(t, err)
+
+	data := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})
+	require.NoError(t, ioutil.WriteFile(path, data, 0600))
+}
@@ -21,8 +21,61 @@ import (
 	"github.com/vmware-labs/yaml-jsonpath/pkg/yamlpath"
 )
 
+// podSpecImagesQuery locates images in every container list of a pod spec:
+// containers, init containers, and ephemeral containers.
+const podSpecImagesQuery = "..spec['containers','initContainers','ephemeralContainers'][*].image"
+
+// Option is a functional option for configuring image detection.
+type Option func(o *options)
+
+type options struct {
+	catalog []sheaf.UserDefinedImage
+}
+
+// WithCatalog sets the catalog user defined images. By default, every entry
+// in the built-in catalog is used.
+func WithCatalog(catalog []sheaf.UserDefinedImage) Option {
+	return func(o *options) {
+		o.catalog = catalog
+	}
+}
+
+func makeOptions(list ...Option) options {
+	opts := options{
+		catalog: sheaf.CatalogUserDefinedImages(sheaf.CatalogSelection{}),
+	}
+
+	for _, o := range list {
+		o(&opts)
+	}
+
+	return opts
+}
+
+// userDefinedImages combines the catalog with user defined images. A user
+// defined image replaces catalog entries for the same api version and kind.
+func (o options) userDefinedImages(userDefinedImages []sheaf.UserDefinedImage) []sheaf.UserDefinedImage {
+	overridden := map[sheaf.UserDefinedImageKey]bool{}
+	for _, udi := range userDefinedImages {
+		overridden[sheaf.UserDefinedImageKey{APIVersion: udi.APIVersion, Kind: udi.Kind}] = true
+	}
+
+	var list []sheaf.UserDefinedImage
+	for _, udi := range o.catalog {
+		if overridden[sheaf.UserDefinedImageKey{APIVersion: udi.APIVersion, Kind: udi.Kind}] {
+			continue
+		}
+		list = append(list, udi)
+	}
+
+	return append(list, userDefinedImages...)
+}
+
 // ContainerImagesFromBytes returns container images referenced in manifest bytes.
-func ContainerImagesFromBytes(data []byte, userDefinedImages []sheaf.UserDefinedImage) (images.Set, error) {
+func ContainerImagesFromBytes(data []byte, userDefinedImages []sheaf.UserDefinedImage, optionList ...Option) (images.Set, error) {
+	opts := makeOptions(optionList...)
+	userDefinedImages = opts.userDefinedImages(userDefinedImages)
+
 	set := images.Empty
 
 	docs, err := manifestDocuments(data)
@@ -31,7 +84,12 @@ func ContainerImagesFromBytes(data []byte, userDefinedImages []sheaf.UserDefined
 	}
 
 	for _, doc := range docs {
-		results, err := jsonPathSearch(doc, "..spec.containers[*].image")
+		// Skip empty documents
+		if doc.Content == nil {
+			continue
+		}
+
+		results, err := jsonPathSearch(doc, podSpecImagesQuery)
 		if err != nil {
 			return images.Empty, fmt.Errorf("json path search: %w", err)
 		}
@@ -42,20 +100,26 @@ func ContainerImagesFromBytes(data []byte, userDefinedImages []sheaf.UserDefined
 		}
 		set = set.Union(bufImages)
 
+		var d map[string]interface{}
+		if err := doc.Decode(&d); err != nil {
+			return images.Empty, fmt.Errorf("YAML decode: %w", err)
+		}
+
 		for _, udi := range userDefinedImages {
-			var d map[string]interface{}
-			if err := doc.Decode(&d); err != nil {
-				return images.Empty, fmt.Errorf("YAML decode: %w", err)
-			}
 			if !(d["apiVersion"] == udi.APIVersion && d["kind"] == udi.Kind) {
 				continue
 			}
 
-			result, err := jsonPathSearch(doc, udi.JSONPath)
+			imageNodes, err := userDefinedImageNodes(doc, udi)
 			if err != nil {
 				return images.Empty, fmt.Errorf("user defined image search %q: %w", udi.JSONPath, err)
 			}
 
+			var result []string
+			for _, i := range imageNodes {
+				result = append(result, i.name)
+			}
+
 			bufImages, err := images.New(result...)
 			if err != nil {
 				return images.Empty, err
@@ -71,13 +135,13 @@ func ContainerImagesFromBytes(data []byte, userDefinedImages []sheaf.UserDefined
 }
 
 // ContainerImages returns images from containers in manifest path
-func ContainerImages(manifestPath string, definedImages []sheaf.UserDefinedImage) (images.Set, error) {
+func ContainerImages(manifestPath string, definedImages []sheaf.UserDefinedImage, optionList ...Option) (images.Set, error) {
 	data, err := ioutil.ReadFile(manifestPath)
 	if err != nil {
 		return images.Empty, fmt.Errorf("read file: %w", err)
 	}
 
-	imagesSet, err := ContainerImagesFromBytes(data, definedImages)
+	imagesSet, err := ContainerImagesFromBytes(data, definedImages, optionList...)
 	if err != nil {
 		return images.Empty, fmt.Errorf("find container images in %s: %w", manifestPath, err)
 	}
@@ -86,7 +150,10 @@ func ContainerImages(manifestPath string, definedImages []sheaf.UserDefinedImage
 }
 
 // MapContainer applies the mapping to the images in the input manifest and returns the modified manifest
-func MapContainer(manifest []byte, userDefinedImages []sheaf.UserDefinedImage, mapping func(originalImage image.Name) (image.Name, error)) ([]byte, error) {
+func MapContainer(manifest []byte, userDefinedImages []sheaf.UserDefinedImage, mapping func(originalImage image.Name) (image.Name, error), optionList ...Option) ([]byte, error) {
+	opts := makeOptions(optionList...)
+	userDefinedImages = opts.userDefinedImages(userDefinedImages)
+
 	refMapping := func(originalImage string) (string, error) {
 		i, err := image.NewName(originalImage)
 		if err != nil {
@@ -110,7 +177,7 @@ func MapContainer(manifest []byte, userDefinedImages []sheaf.UserDefinedImage, m
 		if doc.Content == nil {
 			continue
 		}
-		imageNodes, err := jsonPathSearchNodes(doc, "..spec.containers[*].image")
+		imageNodes, err := jsonPathSearchNodes(doc, podSpecImagesQuery)
 		if err != nil {
 			return nil, fmt.Errorf("json path search: %w", err)
 		}
@@ -123,26 +190,52 @@ func MapContainer(manifest []byte, userDefinedImages []sheaf.UserDefinedImage, m
 			i.Value = newValue
 		}
 
+		var d map[string]interface{}
+		if err := doc.Decode(&d); err != nil {
+			return nil, fmt.Errorf("YAML decode: %w", err)
+		}
+
 		for _, udi := range userDefinedImages {
-			var d map[string]interface{}
-			if err := doc.Decode(&d); err != nil {
-				return nil, fmt.Errorf("YAML decode: %w", err)
-			}
 			if !(d["apiVersion"] == udi.APIVersion && d["kind"] == udi.Kind) {
 				continue
 			}
 
-			imageNodes, err := jsonPathSearchNodes(doc, udi.JSONPath)
+			imageNodes, err := userDefinedImageNodes(doc, udi)
 			if err != nil {
 				return nil, fmt.Errorf("user defined image search %q: %w", udi.JSONPath, err)
 			}
 
+			// Names combined with a hub are replaced by the full mapped
+			// image, which is used as is. Repositories with a separate tag
+			// are replaced by the mapped repository and tag.
 			for _, i := range imageNodes {
-				newValue, err := refMapping(i.Value)
+				if i.tagNode != nil {
+					original, err := image.NewName(i.name)
+					if err != nil {
+						return nil, err
+					}
+
+					mapped, err := mapping(original)
+					if err != nil {
+						return nil, fmt.Errorf("failed to map image: %w", err)
+					}
+
+					repository, tag, err := splitTag(mapped)
+					if err != nil {
+						return nil, fmt.Errorf("failed to map image: %w", err)
+					}
+
+					// Tags such as 1.0 are numbers in YAML, and are kept as
+					// strings once a digest may be appended.
+					i.node.Value, i.tagNode.Value, i.tagNode.Tag = repository, tag, "!!str"
+					continue
+				}
+
+				newValue, err := refMapping(i.name)
 				if err != nil {
 					return nil, fmt.Errorf("failed to map image: %w", err)
 				}
-				i.Value = newValue
+				i.node.Value = newValue
 			}
 		}
 
@@ -162,6 +255,155 @@ func MapContainer(manifest []byte, userDefinedImages []sheaf.UserDefinedImage, m
 	return []byte(strings.Join(newDocs, "\n---\n")), nil // add leading newline since splitting can drop newlines
 }
 
+// userDefinedImageNode is a node holding an image found by a user defined
+// image, and the image name. Repositories with a separate tag also hold the
+// tag node.
+type userDefinedImageNode struct {
+	node    *yaml.Node
+	tagNode *yaml.Node
+	name    string
+}
+
+// userDefinedImageNodes returns the images found by a user defined image. If
+// the user defined image has hub paths, names that don't contain a '/' are
+// combined with the hub and tag, and skipped if there is no hub. If it only
+// has tag paths, repositories are combined with the tag, and skipped if
+// there is no tag.
+func userDefinedImageNodes(doc *yaml.Node, udi sheaf.UserDefinedImage) ([]userDefinedImageNode, error) {
+	nodes, err := jsonPathSearchNodes(doc, udi.JSONPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var list []userDefinedImageNode
+	for _, node := range nodes {
+		name := node.Value
+		if len(udi.HubJSONPaths) == 0 && len(udi.TagJSONPaths) > 0 {
+			if name == "" {
+				continue
+			}
+
+			tagNode, err := firstJSONPathNode(doc, parentMapping(doc, node), udi.TagJSONPaths)
+			if err != nil {
+				return nil, err
+			}
+
+			if tagNode == nil {
+				continue
+			}
+
+			list = append(list, userDefinedImageNode{
+				node:    node,
+				tagNode: tagNode,
+				name:    name + ":" + tagNode.Value,
+			})
+			continue
+		}
+
+		if len(udi.HubJSONPaths) == 0 || strings.Contains(name, "/") {
+			list = append(list, userDefinedImageNode{node: node, name: name})
+			continue
+		}
+
+		if name == "" {
+			continue
+		}
+
+		parent := parentMapping(doc, node)
+
+		hub, err := firstJSONPathValue(doc, parent, udi.HubJSONPaths)
+		if err != nil {
+			return nil, err
+		}
+
+		if hub == "" {
+			continue
+		}
+
+		tag, err := firstJSONPathValue(doc, parent, udi.TagJSONPaths)
+		if err != nil {
+			return nil, err
+		}
+
+		name = strings.TrimSuffix(hub, "/") + "/" + name
+		if tag != "" && !strings.ContainsAny(node.Value, ":@") {
+			name += ":" + tag
+		}
+
+		list = append(list, userDefinedImageNode{node: node, name: name})
+	}
+
+	return list, nil
+}
+
+// splitTag splits a mapped image into the repository and tag written to a
+// repository with a separate tag. A digest is kept with the tag.
+func splitTag(n image.Name) (string, string, error) {
+	tag := n.Tag()
+	if tag == "" {
+		return "", "", fmt.Errorf("%s has no tag to write to a separate tag field", n)
+	}
+
+	if n.Digest() != image.EmptyDigest {
+		tag += "@" + n.Digest().String()
+	}
+
+	return n.WithoutTagOrDigest().String(), tag, nil
+}
+
+// firstJSONPathValue returns the first scalar value found by a list of
+// queries. Queries starting with '@' are relative to the parent node.
+func firstJSONPathValue(doc, parent *yaml.Node, queries []string) (string, error) {
+	node, err := firstJSONPathNode(doc, parent, queries)
+	if err != nil || node == nil {
+		return "", err
+	}
+
+	return node.Value, nil
+}
+
+// firstJSONPathNode returns the first scalar node with a value found by a
+// list of queries. Queries starting with '@' are relative to the parent node.
+func firstJSONPathNode(doc, parent *yaml.Node, queries []string) (*yaml.Node, error) {
+	for _, query := range queries {
+		root := doc
+		if strings.HasPrefix(query, "@") {
+			if parent == nil {
+				continue
+			}
+			root, query = parent, strings.TrimPrefix(query, "@")
+		}
+
+		nodes, err := jsonPathSearchNodes(root, query)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, node := range nodes {
+			if node.Kind == yaml.ScalarNode && node.Value != "" {
+				return node, nil
+			}
+		}
+	}
+
+	return nil, nil
+}
+
+// parentMapping returns the mapping containing a node as a value.
+func parentMapping(root, node *yaml.Node) *yaml.Node {
+	for i, child := range root.Content {
+		if root.Kind == yaml.MappingNode && i%2 == 1 && child == node {
+			return root
+		}
+
+		if parent := parentMapping(child, node); parent != nil {
+			return parent
+		}
+	}
+
+	return nil
+}
+
 func jsonPathSearch(doc *yaml.Node, query string) ([]string, error) {
 	imageNodes, err := jsonPathSearchNodes(doc, query)
 	if err != nil {
@@ -1,3 +1,4 @@
+//go:build !integration
 // +build !integration
 
 /*
@@ -26,6 +27,7 @@ func TestContainerImages(t *testing.T) {
 		name              string
 		path              string
 		userDefinedImages []sheaf.UserDefinedImage
+		options           []manifest.Option
 		wantErr           bool
 		expected          []string
 	}{
@@ -48,6 +50,15 @@ func TestContainerImages(t *testing.T) {
 				"busybox",
 			},
 		},
+		{
+			name: "pod with init and ephemeral containers",
+			path: "pod-all-containers.yaml",
+			expected: []string{
+				"alpine:3.12",
+				"busybox:1.28",
+				"nginx:1.17.8",
+			},
+		},
 		{
 			name:     "service",
 			path:     "service.yaml",
@@ -96,6 +107,57 @@ func TestContainerImages(t *testing.T) {
 				"gcr.io/example/image2",
 			},
 		},
+		{
+			name: "catalog",
+			path: "catalog-tekton.yaml",
+			expected: []string{
+				"docker:dind",
+				"gcr.io/example/builder:1",
+			},
+		},
+		{
+			name: "catalog: image names combined with a hub and tag",
+			path: "catalog-istio.yaml",
+			expected: []string{
+				"docker.io/istio/pilot:1.8.0",
+				"docker.io/istio/proxyv2:1.8.0",
+				"example.com/istio/ztunnel:1.8.0",
+				"gcr.io/istio-release/install-cni:1.8.0-distroless",
+			},
+		},
+		{
+			name: "catalog: repositories combined with a separate tag",
+			path: "catalog-cert-manager.yaml",
+			expected: []string{
+				"quay.io/jetstack/cert-manager-acmesolver:v1.1.0",
+				"quay.io/jetstack/cert-manager-cainjector:v1.1.0",
+				"quay.io/jetstack/cert-manager-controller:v1.1.0",
+				"quay.io/jetstack/cert-manager-webhook:v1.1.0",
+			},
+		},
+		{
+			name: "catalog: image names without a hub",
+			path: "catalog-istio-default-hub.yaml",
+		},
+		{
+			name:    "catalog disabled",
+			path:    "catalog-tekton.yaml",
+			options: []manifest.Option{manifest.WithCatalog(nil)},
+		},
+		{
+			name: "user defined image overrides catalog",
+			path: "catalog-tekton.yaml",
+			userDefinedImages: []sheaf.UserDefinedImage{
+				{
+					APIVersion: "tekton.dev/v1beta1",
+					Kind:       "Task",
+					JSONPath:   ".spec.steps[*].image",
+				},
+			},
+			expected: []string{
+				"gcr.io/example/builder:1",
+			},
+		},
 		{
 			name: "user defined: error",
 			path: "user-defined-single.yaml",
@@ -114,7 +176,7 @@ func TestContainerImages(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			manifestPath := filepath.Join("testdata", tt.path)
 
-			got, err := manifest.ContainerImages(manifestPath, tt.userDefinedImages)
+			got, err := manifest.ContainerImages(manifestPath, tt.userDefinedImages, tt.options...)
 			if tt.wantErr {
 				require.Error(t, err)
 				return
@@ -153,6 +215,39 @@ func TestMapContainer(t *testing.T) {
 			mapping:      map[string]string{"quay.io/jetstack/cert-manager-cainjector@sha256:9ff6923f6c567573103816796df283d03256bc7a9edb7450542e106b349cf34a": "example.com/jetstack/cert-manager-cainjector@sha256:9ff6923f6c567573103816796df283d03256bc7a9edb7450542e106b349cf34a"},
 			expectedPath: "quoted-replaced.yaml",
 		},
+		{
+			name: "pod with init and ephemeral containers",
+			path: "pod-all-containers.yaml",
+			mapping: map[string]string{
+				"busybox:1.28": "example.com/busybox:1.28",
+				"nginx:1.17.8": "example.com/nginx:1.17.8",
+				"alpine:3.12":  "example.com/alpine:3.12",
+			},
+			expectedPath: "pod-all-containers-replaced.yaml",
+		},
+		{
+			name: "catalog: image names combined with a hub and tag",
+			path: "catalog-istio.yaml",
+			mapping: map[string]string{
+				"docker.io/istio/pilot:1.8.0":                       "example.com/istio/pilot:1.8.0",
+				"docker.io/istio/proxyv2:1.8.0":                     "example.com/istio/proxyv2:1.8.0",
+				"example.com/istio/ztunnel:1.8.0":                   "example.com/istio/ztunnel:1.8.0",
+				"gcr.io/istio-release/install-cni:1.8.0-distroless": "example.com/istio/install-cni:1.8.0-distroless",
+			},
+			expectedPath: "catalog-istio-replaced.yaml",
+		},
+		{
+			name: "catalog: repositories with a separate tag",
+			path: "catalog-cert-manager.yaml",
+			mapping: map[string]string{
+				"quay.io/jetstack/cert-manager-acmesolver:v1.1.0": "example.com/jetstack/cert-manager-acmesolver:v1.1.0",
+				"quay.io/jetstack/cert-manager-cainjector:v1.1.0": "example.com/jetstack/cert-manager-cainjector:v1.1.0",
+				"quay.io/jetstack/cert-manager-controller:v1.1.0": "example.com/jetstack/cert-manager-controller:v1.1.0",
+				"quay.io/jetstack/cert-manager-webhook:v1.1.0": "example.com/jetstack/cert-manager-webhook:v1.1.0" +
+					"@sha256:2539d4344dd18e1df02be842ffc435f8e1f699cfc55516e2cf2cb16b7a9aea0b",
+			},
+			expectedPath: "catalog-cert-manager-replaced.yaml",
+		},
 	}
 
 	for _, tt := range tests {
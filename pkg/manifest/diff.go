@@ -0,0 +1,220 @@
+/*
+ * Copyright 2020 Sheaf Authors
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package manifest
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/bryanl/sheaf/internal/yamlutil"
+	"github.com/bryanl/sheaf/pkg/sheaf"
+)
+
+// plainKey matches map keys that can be used in a field path without quoting.
+var plainKey = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// Resource is a resource in a manifest.
+type Resource struct {
+	APIVersion string
+	Kind       string
+	Namespace  string
+	Name       string
+	Object     map[string]interface{}
+}
+
+// key returns the key that identifies a resource.
+func (r Resource) key() string {
+	return fmt.Sprintf("%s\x00%s\x00%s\x00%s", r.APIVersion, r.Kind, r.Namespace, r.Name)
+}
+
+// Resources returns the resources in a manifest. Empty documents are skipped.
+func Resources(data []byte) ([]Resource, error) {
+	docs, err := yamlutil.Split(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var list []Resource
+	for _, doc := range docs {
+		var object map[string]interface{}
+		if err := yaml.Unmarshal(doc, &object); err != nil {
+			return nil, err
+		}
+
+		if len(object) == 0 {
+			continue
+		}
+
+		r := Resource{Object: object}
+		r.APIVersion, _ = object["apiVersion"].(string)
+		r.Kind, _ = object["kind"].(string)
+		if metadata, ok := object["metadata"].(map[string]interface{}); ok {
+			r.Namespace, _ = metadata["namespace"].(string)
+			r.Name, _ = metadata["name"].(string)
+		}
+
+		list = append(list, r)
+	}
+
+	return list, nil
+}
+
+// Diff returns the differences between the resources in two lists of
+// manifests. Resources are matched by API version, kind, namespace, and
+// name, and changed resources list each changed field.
+func Diff(oldManifests, newManifests [][]byte) ([]sheaf.ResourceDifference, error) {
+	oldResources, err := resourceMap(oldManifests)
+	if err != nil {
+		return nil, err
+	}
+
+	newResources, err := resourceMap(newManifests)
+	if err != nil {
+		return nil, err
+	}
+
+	list := []sheaf.ResourceDifference{}
+	for key, oldResource := range oldResources {
+		newResource, ok := newResources[key]
+		if !ok {
+			list = append(list, resourceDifference(sheaf.Removed, oldResource, nil))
+			continue
+		}
+
+		var fields []sheaf.FieldDifference
+		diffValues("", oldResource.Object, newResource.Object, &fields)
+		if len(fields) > 0 {
+			list = append(list, resourceDifference(sheaf.Changed, oldResource, fields))
+		}
+	}
+
+	for key, newResource := range newResources {
+		if _, ok := oldResources[key]; !ok {
+			list = append(list, resourceDifference(sheaf.Added, newResource, nil))
+		}
+	}
+
+	sort.Slice(list, func(i, j int) bool {
+		a, b := list[i], list[j]
+		if a.APIVersion != b.APIVersion {
+			return a.APIVersion < b.APIVersion
+		}
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
+		}
+		return a.Name < b.Name
+	})
+
+	return list, nil
+}
+
+func resourceMap(manifests [][]byte) (map[string]Resource, error) {
+	m := map[string]Resource{}
+	for _, data := range manifests {
+		resources, err := Resources(data)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, r := range resources {
+			m[r.key()] = r
+		}
+	}
+
+	return m, nil
+}
+
+func resourceDifference(change string, r Resource, fields []sheaf.FieldDifference) sheaf.ResourceDifference {
+	return sheaf.ResourceDifference{
+		Change:     change,
+		APIVersion: r.APIVersion,
+		Kind:       r.Kind,
+		Namespace:  r.Namespace,
+		Name:       r.Name,
+		Fields:     fields,
+	}
+}
+
+// diffValues appends the differences between two values. Maps are compared
+// key by key and lists item by item, so only the fields that changed are
+// reported.
+func diffValues(path string, oldValue, newValue interface{}, fields *[]sheaf.FieldDifference) {
+	oldMap, oldIsMap := oldValue.(map[string]interface{})
+	newMap, newIsMap := newValue.(map[string]interface{})
+	if oldIsMap && newIsMap {
+		keys := map[string]bool{}
+		for k := range oldMap {
+			keys[k] = true
+		}
+		for k := range newMap {
+			keys[k] = true
+		}
+
+		var sorted []string
+		for k := range keys {
+			sorted = append(sorted, k)
+		}
+		sort.Strings(sorted)
+
+		for _, k := range sorted {
+			diffValues(fieldPath(path, k), oldMap[k], newMap[k], fields)
+		}
+		return
+	}
+
+	oldList, oldIsList := oldValue.([]interface{})
+	newList, newIsList := newValue.([]interface{})
+	if oldIsList && newIsList {
+		n := len(oldList)
+		if len(newList) > n {
+			n = len(newList)
+		}
+
+		for i := 0; i < n; i++ {
+			var oldItem, newItem interface{}
+			if i < len(oldList) {
+				oldItem = oldList[i]
+			}
+			if i < len(newList) {
+				newItem = newList[i]
+			}
+
+			diffValues(path+"["+strconv.Itoa(i)+"]", oldItem, newItem, fields)
+		}
+		return
+	}
+
+	if !reflect.DeepEqual(oldValue, newValue) {
+		*fields = append(*fields, sheaf.FieldDifference{
+			Path: path,
+			Old:  oldValue,
+			New:  newValue,
+		})
+	}
+}
+
+// fieldPath returns the path of a map key. Keys that aren't plain, such as
+// label names with dots or slashes, are quoted.
+func fieldPath(path, key string) string {
+	if !plainKey.MatchString(key) {
+		return path + "[" + strconv.Quote(key) + "]"
+	}
+
+	if path == "" {
+		return key
+	}
+
+	return path + "." + key
+}
@@ -0,0 +1,143 @@
+/*
+ * Copyright 2020 Sheaf Authors
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package manifest_test
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/bryanl/sheaf/pkg/manifest"
+	"github.com/bryanl/sheaf/pkg/sheaf"
+)
+
+func TestDiff(t *testing.T) {
+	configMap := func(mode string) string {
+		return `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: settings
+  namespace: default
+data:
+  mode: ` + mode + `
+`
+	}
+
+	service := func(port int, label string) string {
+		return `apiVersion: v1
+kind: Service
+metadata:
+  name: web
+  labels:
+    app.kubernetes.io/name: ` + label + `
+spec:
+  ports:
+    - port: ` + strconv.Itoa(port) + `
+`
+	}
+
+	tests := []struct {
+		name         string
+		oldManifests []string
+		newManifests []string
+		wantErr      bool
+		expected     []sheaf.ResourceDifference
+	}{
+		{
+			name:         "no differences",
+			oldManifests: []string{configMap("fast")},
+			newManifests: []string{configMap("fast")},
+			expected:     []sheaf.ResourceDifference{},
+		},
+		{
+			name:         "changed field",
+			oldManifests: []string{configMap("fast")},
+			newManifests: []string{configMap("slow")},
+			expected: []sheaf.ResourceDifference{
+				{
+					Change:     sheaf.Changed,
+					APIVersion: "v1",
+					Kind:       "ConfigMap",
+					Namespace:  "default",
+					Name:       "settings",
+					Fields: []sheaf.FieldDifference{
+						{Path: "data.mode", Old: "fast", New: "slow"},
+					},
+				},
+			},
+		},
+		{
+			name:         "added and removed resources",
+			oldManifests: []string{configMap("fast")},
+			newManifests: []string{service(80, "web")},
+			expected: []sheaf.ResourceDifference{
+				{Change: sheaf.Removed, APIVersion: "v1", Kind: "ConfigMap", Namespace: "default", Name: "settings"},
+				{Change: sheaf.Added, APIVersion: "v1", Kind: "Service", Name: "web"},
+			},
+		},
+		{
+			name:         "list items and quoted keys",
+			oldManifests: []string{service(80, "web")},
+			newManifests: []string{service(81, "frontend")},
+			expected: []sheaf.ResourceDifference{
+				{
+					Change:     sheaf.Changed,
+					APIVersion: "v1",
+					Kind:       "Service",
+					Name:       "web",
+					Fields: []sheaf.FieldDifference{
+						{Path: `metadata.labels["app.kubernetes.io/name"]`, Old: "web", New: "frontend"},
+						{Path: "spec.ports[0].port", Old: 80, New: 81},
+					},
+				},
+			},
+		},
+		{
+			name:         "multiple documents",
+			oldManifests: []string{configMap("fast") + "---\n" + service(80, "web")},
+			newManifests: []string{service(80, "web"), configMap("slow")},
+			expected: []sheaf.ResourceDifference{
+				{
+					Change:     sheaf.Changed,
+					APIVersion: "v1",
+					Kind:       "ConfigMap",
+					Namespace:  "default",
+					Name:       "settings",
+					Fields: []sheaf.FieldDifference{
+						{Path: "data.mode", Old: "fast", New: "slow"},
+					},
+				},
+			},
+		},
+		{
+			name:         "invalid manifest",
+			oldManifests: []string{"{"},
+			wantErr:      true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var oldManifests, newManifests [][]byte
+			for _, m := range test.oldManifests {
+				oldManifests = append(oldManifests, []byte(m))
+			}
+			for _, m := range test.newManifests {
+				newManifests = append(newManifests, []byte(m))
+			}
+
+			actual, err := manifest.Diff(oldManifests, newManifests)
+			if test.wantErr {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, test.expected, actual)
+		})
+	}
+}
@@ -0,0 +1,49 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/bryanl/sheaf/pkg/sheaf (interfaces: ArchiveVerifier)
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	sheaf "github.com/bryanl/sheaf/pkg/sheaf"
+	gomock "github.com/golang/mock/gomock"
+	reflect "reflect"
+)
+
+// MockArchiveVerifier is a mock of ArchiveVerifier interface
+type MockArchiveVerifier struct {
+	ctrl     *gomock.Controller
+	recorder *MockArchiveVerifierMockRecorder
+}
+
+// MockArchiveVerifierMockRecorder is the mock recorder for MockArchiveVerifier
+type MockArchiveVerifierMockRecorder struct {
+	mock *MockArchiveVerifier
+}
+
+// NewMockArchiveVerifier creates a new mock instance
+func NewMockArchiveVerifier(ctrl *gomock.Controller) *MockArchiveVerifier {
+	mock := &MockArchiveVerifier{ctrl: ctrl}
+	mock.recorder = &MockArchiveVerifierMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockArchiveVerifier) EXPECT() *MockArchiveVerifierMockRecorder {
+	return m.recorder
+}
+
+// Verify mocks base method
+func (m *MockArchiveVerifier) Verify(arg0 sheaf.Bundle) ([]sheaf.VerificationResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Verify", arg0)
+	ret0, _ := ret[0].([]sheaf.VerificationResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Verify indicates an expected call of Verify
+func (mr *MockArchiveVerifierMockRecorder) Verify(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Verify", reflect.TypeOf((*MockArchiveVerifier)(nil).Verify), arg0)
+}
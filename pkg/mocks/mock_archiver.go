@@ -47,6 +47,20 @@ func (mr *MockArchiverMockRecorder) Archive(arg0, arg1 interface{}) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Archive", reflect.TypeOf((*MockArchiver)(nil).Archive), arg0, arg1)
 }
 
+// Extension mocks base method
+func (m *MockArchiver) Extension() string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Extension")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// Extension indicates an expected call of Extension
+func (mr *MockArchiverMockRecorder) Extension() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Extension", reflect.TypeOf((*MockArchiver)(nil).Extension))
+}
+
 // Unarchive mocks base method
 func (m *MockArchiver) Unarchive(arg0 io.Reader, arg1 string) error {
 	m.ctrl.T.Helper()
@@ -61,6 +75,20 @@ func (mr *MockArchiverMockRecorder) Unarchive(arg0, arg1 interface{}) *gomock.Ca
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Unarchive", reflect.TypeOf((*MockArchiver)(nil).Unarchive), arg0, arg1)
 }
 
+// UnarchiveMetadataPath mocks base method
+func (m *MockArchiver) UnarchiveMetadataPath(arg0, arg1 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UnarchiveMetadataPath", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UnarchiveMetadataPath indicates an expected call of UnarchiveMetadataPath
+func (mr *MockArchiverMockRecorder) UnarchiveMetadataPath(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UnarchiveMetadataPath", reflect.TypeOf((*MockArchiver)(nil).UnarchiveMetadataPath), arg0, arg1)
+}
+
 // UnarchivePath mocks base method
 func (m *MockArchiver) UnarchivePath(arg0, arg1 string) error {
 	m.ctrl.T.Helper()
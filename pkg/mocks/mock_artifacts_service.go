@@ -33,6 +33,21 @@ func (m *MockArtifactsService) EXPECT() *MockArtifactsServiceMockRecorder {
 	return m.recorder
 }
 
+// Blob mocks base method
+func (m *MockArtifactsService) Blob(arg0 string) ([]byte, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Blob", arg0)
+	ret0, _ := ret[0].([]byte)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Blob indicates an expected call of Blob
+func (mr *MockArtifactsServiceMockRecorder) Blob(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Blob", reflect.TypeOf((*MockArtifactsService)(nil).Blob), arg0)
+}
+
 // Image mocks base method
 func (m *MockArtifactsService) Image() sheaf.ImageService {
 	m.ctrl.T.Helper()
@@ -106,6 +106,21 @@ func (mr *MockBundleMockRecorder) Images() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Images", reflect.TypeOf((*MockBundle)(nil).Images))
 }
 
+// Lock mocks base method
+func (m *MockBundle) Lock() (sheaf.BundleLock, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Lock")
+	ret0, _ := ret[0].(sheaf.BundleLock)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Lock indicates an expected call of Lock
+func (mr *MockBundleMockRecorder) Lock() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Lock", reflect.TypeOf((*MockBundle)(nil).Lock))
+}
+
 // Manifests mocks base method
 func (m *MockBundle) Manifests() (sheaf.ManifestService, error) {
 	m.ctrl.T.Helper()
@@ -134,3 +149,17 @@ func (mr *MockBundleMockRecorder) Path() *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Path", reflect.TypeOf((*MockBundle)(nil).Path))
 }
+
+// WriteLock mocks base method
+func (m *MockBundle) WriteLock(arg0 sheaf.BundleLock) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WriteLock", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// WriteLock indicates an expected call of WriteLock
+func (mr *MockBundleMockRecorder) WriteLock(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WriteLock", reflect.TypeOf((*MockBundle)(nil).WriteLock), arg0)
+}
@@ -33,6 +33,76 @@ func (m *MockBundleConfig) EXPECT() *MockBundleConfigMockRecorder {
 	return m.recorder
 }
 
+// GetCatalog mocks base method
+func (m *MockBundleConfig) GetCatalog() sheaf.CatalogSelection {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCatalog")
+	ret0, _ := ret[0].(sheaf.CatalogSelection)
+	return ret0
+}
+
+// GetCatalog indicates an expected call of GetCatalog
+func (mr *MockBundleConfigMockRecorder) GetCatalog() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCatalog", reflect.TypeOf((*MockBundleConfig)(nil).GetCatalog))
+}
+
+// GetImageExclusions mocks base method
+func (m *MockBundleConfig) GetImageExclusions() []sheaf.ImageExclusion {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetImageExclusions")
+	ret0, _ := ret[0].([]sheaf.ImageExclusion)
+	return ret0
+}
+
+// GetImageExclusions indicates an expected call of GetImageExclusions
+func (mr *MockBundleConfigMockRecorder) GetImageExclusions() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetImageExclusions", reflect.TypeOf((*MockBundleConfig)(nil).GetImageExclusions))
+}
+
+// GetImages mocks base method
+func (m *MockBundleConfig) GetImages() []string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetImages")
+	ret0, _ := ret[0].([]string)
+	return ret0
+}
+
+// GetImages indicates an expected call of GetImages
+func (mr *MockBundleConfigMockRecorder) GetImages() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetImages", reflect.TypeOf((*MockBundleConfig)(nil).GetImages))
+}
+
+// GetManifestSources mocks base method
+func (m *MockBundleConfig) GetManifestSources() []sheaf.ManifestSource {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetManifestSources")
+	ret0, _ := ret[0].([]sheaf.ManifestSource)
+	return ret0
+}
+
+// GetManifestSources indicates an expected call of GetManifestSources
+func (mr *MockBundleConfigMockRecorder) GetManifestSources() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetManifestSources", reflect.TypeOf((*MockBundleConfig)(nil).GetManifestSources))
+}
+
+// GetMappingStrategy mocks base method
+func (m *MockBundleConfig) GetMappingStrategy() sheaf.MappingStrategy {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetMappingStrategy")
+	ret0, _ := ret[0].(sheaf.MappingStrategy)
+	return ret0
+}
+
+// GetMappingStrategy indicates an expected call of GetMappingStrategy
+func (mr *MockBundleConfigMockRecorder) GetMappingStrategy() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMappingStrategy", reflect.TypeOf((*MockBundleConfig)(nil).GetMappingStrategy))
+}
+
 // GetName mocks base method
 func (m *MockBundleConfig) GetName() string {
 	m.ctrl.T.Helper()
@@ -89,6 +159,66 @@ func (mr *MockBundleConfigMockRecorder) GetVersion() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetVersion", reflect.TypeOf((*MockBundleConfig)(nil).GetVersion))
 }
 
+// SetCatalog mocks base method
+func (m *MockBundleConfig) SetCatalog(arg0 sheaf.CatalogSelection) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SetCatalog", arg0)
+}
+
+// SetCatalog indicates an expected call of SetCatalog
+func (mr *MockBundleConfigMockRecorder) SetCatalog(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetCatalog", reflect.TypeOf((*MockBundleConfig)(nil).SetCatalog), arg0)
+}
+
+// SetImageExclusions mocks base method
+func (m *MockBundleConfig) SetImageExclusions(arg0 []sheaf.ImageExclusion) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SetImageExclusions", arg0)
+}
+
+// SetImageExclusions indicates an expected call of SetImageExclusions
+func (mr *MockBundleConfigMockRecorder) SetImageExclusions(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetImageExclusions", reflect.TypeOf((*MockBundleConfig)(nil).SetImageExclusions), arg0)
+}
+
+// SetImages mocks base method
+func (m *MockBundleConfig) SetImages(arg0 []string) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SetImages", arg0)
+}
+
+// SetImages indicates an expected call of SetImages
+func (mr *MockBundleConfigMockRecorder) SetImages(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetImages", reflect.TypeOf((*MockBundleConfig)(nil).SetImages), arg0)
+}
+
+// SetManifestSources mocks base method
+func (m *MockBundleConfig) SetManifestSources(arg0 []sheaf.ManifestSource) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SetManifestSources", arg0)
+}
+
+// SetManifestSources indicates an expected call of SetManifestSources
+func (mr *MockBundleConfigMockRecorder) SetManifestSources(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetManifestSources", reflect.TypeOf((*MockBundleConfig)(nil).SetManifestSources), arg0)
+}
+
+// SetMappingStrategy mocks base method
+func (m *MockBundleConfig) SetMappingStrategy(arg0 sheaf.MappingStrategy) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SetMappingStrategy", arg0)
+}
+
+// SetMappingStrategy indicates an expected call of SetMappingStrategy
+func (mr *MockBundleConfigMockRecorder) SetMappingStrategy(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetMappingStrategy", reflect.TypeOf((*MockBundleConfig)(nil).SetMappingStrategy), arg0)
+}
+
 // SetName mocks base method
 func (m *MockBundleConfig) SetName(arg0 string) {
 	m.ctrl.T.Helper()
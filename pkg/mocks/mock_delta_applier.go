@@ -0,0 +1,63 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/bryanl/sheaf/pkg/sheaf (interfaces: DeltaApplier)
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	sheaf "github.com/bryanl/sheaf/pkg/sheaf"
+	gomock "github.com/golang/mock/gomock"
+	reflect "reflect"
+)
+
+// MockDeltaApplier is a mock of DeltaApplier interface
+type MockDeltaApplier struct {
+	ctrl     *gomock.Controller
+	recorder *MockDeltaApplierMockRecorder
+}
+
+// MockDeltaApplierMockRecorder is the mock recorder for MockDeltaApplier
+type MockDeltaApplierMockRecorder struct {
+	mock *MockDeltaApplier
+}
+
+// NewMockDeltaApplier creates a new mock instance
+func NewMockDeltaApplier(ctrl *gomock.Controller) *MockDeltaApplier {
+	mock := &MockDeltaApplier{ctrl: ctrl}
+	mock.recorder = &MockDeltaApplierMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockDeltaApplier) EXPECT() *MockDeltaApplierMockRecorder {
+	return m.recorder
+}
+
+// ApplyDelta mocks base method
+func (m *MockDeltaApplier) ApplyDelta(arg0 sheaf.Bundle, arg1 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ApplyDelta", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ApplyDelta indicates an expected call of ApplyDelta
+func (mr *MockDeltaApplierMockRecorder) ApplyDelta(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ApplyDelta", reflect.TypeOf((*MockDeltaApplier)(nil).ApplyDelta), arg0, arg1)
+}
+
+// RequiredBase mocks base method
+func (m *MockDeltaApplier) RequiredBase(arg0 sheaf.Bundle) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RequiredBase", arg0)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RequiredBase indicates an expected call of RequiredBase
+func (mr *MockDeltaApplierMockRecorder) RequiredBase(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RequiredBase", reflect.TypeOf((*MockDeltaApplier)(nil).RequiredBase), arg0)
+}
@@ -35,15 +35,16 @@ func (m *MockImageRelocator) EXPECT() *MockImageRelocatorMockRecorder {
 }
 
 // Relocate mocks base method
-func (m *MockImageRelocator) Relocate(arg0, arg1 string, arg2 []image.Name, arg3 sheaf.ImageWriter) error {
+func (m *MockImageRelocator) Relocate(arg0 string, arg1 sheaf.ImageMapping, arg2 sheaf.BundleConfig, arg3 []image.Name, arg4 sheaf.ImageWriter) (sheaf.RelocationMapping, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Relocate", arg0, arg1, arg2, arg3)
-	ret0, _ := ret[0].(error)
-	return ret0
+	ret := m.ctrl.Call(m, "Relocate", arg0, arg1, arg2, arg3, arg4)
+	ret0, _ := ret[0].(sheaf.RelocationMapping)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
 // Relocate indicates an expected call of Relocate
-func (mr *MockImageRelocatorMockRecorder) Relocate(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+func (mr *MockImageRelocatorMockRecorder) Relocate(arg0, arg1, arg2, arg3, arg4 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Relocate", reflect.TypeOf((*MockImageRelocator)(nil).Relocate), arg0, arg1, arg2, arg3)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Relocate", reflect.TypeOf((*MockImageRelocator)(nil).Relocate), arg0, arg1, arg2, arg3, arg4)
 }
@@ -34,7 +34,7 @@ func (m *MockImageReplacer) EXPECT() *MockImageReplacerMockRecorder {
 }
 
 // Replace mocks base method
-func (m *MockImageReplacer) Replace(arg0 sheaf.BundleManifest, arg1 sheaf.BundleConfig, arg2 string) ([]byte, error) {
+func (m *MockImageReplacer) Replace(arg0 sheaf.BundleManifest, arg1 sheaf.BundleConfig, arg2 sheaf.ImageMapping) ([]byte, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "Replace", arg0, arg1, arg2)
 	ret0, _ := ret[0].([]byte)
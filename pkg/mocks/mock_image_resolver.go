@@ -0,0 +1,49 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/bryanl/sheaf/pkg/sheaf (interfaces: ImageResolver)
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	gomock "github.com/golang/mock/gomock"
+	image "github.com/pivotal/image-relocation/pkg/image"
+	reflect "reflect"
+)
+
+// MockImageResolver is a mock of ImageResolver interface
+type MockImageResolver struct {
+	ctrl     *gomock.Controller
+	recorder *MockImageResolverMockRecorder
+}
+
+// MockImageResolverMockRecorder is the mock recorder for MockImageResolver
+type MockImageResolverMockRecorder struct {
+	mock *MockImageResolver
+}
+
+// NewMockImageResolver creates a new mock instance
+func NewMockImageResolver(ctrl *gomock.Controller) *MockImageResolver {
+	mock := &MockImageResolver{ctrl: ctrl}
+	mock.recorder = &MockImageResolverMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockImageResolver) EXPECT() *MockImageResolverMockRecorder {
+	return m.recorder
+}
+
+// Resolve mocks base method
+func (m *MockImageResolver) Resolve(arg0 image.Name) (image.Digest, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Resolve", arg0)
+	ret0, _ := ret[0].(image.Digest)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Resolve indicates an expected call of Resolve
+func (mr *MockImageResolverMockRecorder) Resolve(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Resolve", reflect.TypeOf((*MockImageResolver)(nil).Resolve), arg0)
+}
@@ -0,0 +1,49 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/bryanl/sheaf/pkg/sheaf (interfaces: ManifestDiffer)
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	sheaf "github.com/bryanl/sheaf/pkg/sheaf"
+	gomock "github.com/golang/mock/gomock"
+	reflect "reflect"
+)
+
+// MockManifestDiffer is a mock of ManifestDiffer interface
+type MockManifestDiffer struct {
+	ctrl     *gomock.Controller
+	recorder *MockManifestDifferMockRecorder
+}
+
+// MockManifestDifferMockRecorder is the mock recorder for MockManifestDiffer
+type MockManifestDifferMockRecorder struct {
+	mock *MockManifestDiffer
+}
+
+// NewMockManifestDiffer creates a new mock instance
+func NewMockManifestDiffer(ctrl *gomock.Controller) *MockManifestDiffer {
+	mock := &MockManifestDiffer{ctrl: ctrl}
+	mock.recorder = &MockManifestDifferMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockManifestDiffer) EXPECT() *MockManifestDifferMockRecorder {
+	return m.recorder
+}
+
+// Diff mocks base method
+func (m *MockManifestDiffer) Diff(arg0, arg1 []sheaf.BundleManifest) ([]sheaf.ResourceDifference, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Diff", arg0, arg1)
+	ret0, _ := ret[0].([]sheaf.ResourceDifference)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Diff indicates an expected call of Diff
+func (mr *MockManifestDifferMockRecorder) Diff(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Diff", reflect.TypeOf((*MockManifestDiffer)(nil).Diff), arg0, arg1)
+}
@@ -0,0 +1,49 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/bryanl/sheaf/pkg/sheaf (interfaces: ManifestRenderer)
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	sheaf "github.com/bryanl/sheaf/pkg/sheaf"
+	gomock "github.com/golang/mock/gomock"
+	reflect "reflect"
+)
+
+// MockManifestRenderer is a mock of ManifestRenderer interface
+type MockManifestRenderer struct {
+	ctrl     *gomock.Controller
+	recorder *MockManifestRendererMockRecorder
+}
+
+// MockManifestRendererMockRecorder is the mock recorder for MockManifestRenderer
+type MockManifestRendererMockRecorder struct {
+	mock *MockManifestRenderer
+}
+
+// NewMockManifestRenderer creates a new mock instance
+func NewMockManifestRenderer(ctrl *gomock.Controller) *MockManifestRenderer {
+	mock := &MockManifestRenderer{ctrl: ctrl}
+	mock.recorder = &MockManifestRendererMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockManifestRenderer) EXPECT() *MockManifestRendererMockRecorder {
+	return m.recorder
+}
+
+// Render mocks base method
+func (m *MockManifestRenderer) Render(arg0 sheaf.ManifestSource) ([]byte, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Render", arg0)
+	ret0, _ := ret[0].([]byte)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Render indicates an expected call of Render
+func (mr *MockManifestRendererMockRecorder) Render(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Render", reflect.TypeOf((*MockManifestRenderer)(nil).Render), arg0)
+}
@@ -52,6 +52,20 @@ func (mr *MockManifestServiceMockRecorder) Add(arg0 interface{}, arg1 ...interfa
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Add", reflect.TypeOf((*MockManifestService)(nil).Add), varargs...)
 }
 
+// AddSource mocks base method
+func (m *MockManifestService) AddSource(arg0 bool, arg1 sheaf.ManifestSource) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddSource", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddSource indicates an expected call of AddSource
+func (mr *MockManifestServiceMockRecorder) AddSource(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddSource", reflect.TypeOf((*MockManifestService)(nil).AddSource), arg0, arg1)
+}
+
 // List mocks base method
 func (m *MockManifestService) List() ([]sheaf.BundleManifest, error) {
 	m.ctrl.T.Helper()
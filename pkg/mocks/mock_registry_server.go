@@ -0,0 +1,48 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/bryanl/sheaf/pkg/sheaf (interfaces: RegistryServer)
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	gomock "github.com/golang/mock/gomock"
+	reflect "reflect"
+)
+
+// MockRegistryServer is a mock of RegistryServer interface
+type MockRegistryServer struct {
+	ctrl     *gomock.Controller
+	recorder *MockRegistryServerMockRecorder
+}
+
+// MockRegistryServerMockRecorder is the mock recorder for MockRegistryServer
+type MockRegistryServerMockRecorder struct {
+	mock *MockRegistryServer
+}
+
+// NewMockRegistryServer creates a new mock instance
+func NewMockRegistryServer(ctrl *gomock.Controller) *MockRegistryServer {
+	mock := &MockRegistryServer{ctrl: ctrl}
+	mock.recorder = &MockRegistryServerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockRegistryServer) EXPECT() *MockRegistryServerMockRecorder {
+	return m.recorder
+}
+
+// Serve mocks base method
+func (m *MockRegistryServer) Serve(arg0 context.Context, arg1, arg2 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Serve", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Serve indicates an expected call of Serve
+func (mr *MockRegistryServerMockRecorder) Serve(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Serve", reflect.TypeOf((*MockRegistryServer)(nil).Serve), arg0, arg1, arg2)
+}
@@ -0,0 +1,48 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/bryanl/sheaf/pkg/sheaf (interfaces: SignatureVerifier)
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	sheaf "github.com/bryanl/sheaf/pkg/sheaf"
+	gomock "github.com/golang/mock/gomock"
+	reflect "reflect"
+)
+
+// MockSignatureVerifier is a mock of SignatureVerifier interface
+type MockSignatureVerifier struct {
+	ctrl     *gomock.Controller
+	recorder *MockSignatureVerifierMockRecorder
+}
+
+// MockSignatureVerifierMockRecorder is the mock recorder for MockSignatureVerifier
+type MockSignatureVerifierMockRecorder struct {
+	mock *MockSignatureVerifier
+}
+
+// NewMockSignatureVerifier creates a new mock instance
+func NewMockSignatureVerifier(ctrl *gomock.Controller) *MockSignatureVerifier {
+	mock := &MockSignatureVerifier{ctrl: ctrl}
+	mock.recorder = &MockSignatureVerifierMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockSignatureVerifier) EXPECT() *MockSignatureVerifierMockRecorder {
+	return m.recorder
+}
+
+// VerifySignature mocks base method
+func (m *MockSignatureVerifier) VerifySignature(arg0 sheaf.Bundle) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "VerifySignature", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// VerifySignature indicates an expected call of VerifySignature
+func (mr *MockSignatureVerifierMockRecorder) VerifySignature(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VerifySignature", reflect.TypeOf((*MockSignatureVerifier)(nil).VerifySignature), arg0)
+}
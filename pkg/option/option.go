@@ -8,8 +8,12 @@ package option
 
 import (
 	"fmt"
+	"net/http"
 	"os"
+	"strings"
+	"time"
 
+	"github.com/google/go-containerregistry/pkg/name"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 
@@ -35,9 +39,12 @@ func Options(user ...sheaf.Option) []sheaf.Option {
 
 // Generator generates options for a sheaf command.
 type Generator struct {
-	cmd    *cobra.Command
-	prefix string
-	m      map[string]func() []sheaf.Option
+	cmd            *cobra.Command
+	prefix         string
+	m              map[string]func() ([]sheaf.Option, error)
+	bundlePacker   map[string]func() ([]fs.BundlePackerOption, error)
+	imageRelocator map[string]func() []fs.ImageRelocatorOption
+	args           *[]string
 }
 
 // NewGenerator creates an instance of Generator.
@@ -46,43 +53,59 @@ func NewGenerator(cmd *cobra.Command, runner Runner, prefix string) *Generator {
 	bundleImager := fs.NewBundleImager(fs.BundleImagerReporter(r))
 
 	f := Generator{
-		cmd:    cmd,
-		prefix: prefix,
-		m: map[string]func() []sheaf.Option{
-			"default": func() []sheaf.Option {
+		cmd:            cmd,
+		prefix:         prefix,
+		args:           &[]string{},
+		bundlePacker:   map[string]func() ([]fs.BundlePackerOption, error){},
+		imageRelocator: map[string]func() []fs.ImageRelocatorOption{},
+		m: map[string]func() ([]sheaf.Option, error){
+			"default": func() ([]sheaf.Option, error) {
 				return []sheaf.Option{
 					sheaf.WithBundleConfigCodec(fs.NewBundleConfigCodec()),
 					sheaf.WithImageReplacer(fs.NewImageReplacer()),
+					sheaf.WithManifestDiffer(fs.NewManifestDiffer()),
 					sheaf.WithBundleConfigWriter(fs.NewBundleConfigWriter()),
 					sheaf.WithArchiver(archiver.New()),
 					sheaf.WithBundleImager(bundleImager),
-					sheaf.WithBundlePacker(fs.NewBundlePacker()),
 					sheaf.WithCodec(codec.Default),
-				}
+				}, nil
 			},
 		},
 	}
 
 	cmd.RunE = func(cmd *cobra.Command, args []string) error {
-		return runner(f.Options()...)
+		*f.args = args
+
+		options, err := f.Options()
+		if err != nil {
+			return err
+		}
+
+		return runner(options...)
 	}
 
 	return &f
 }
 
-// Options returns options for the Generator.
-func (g Generator) Options() []sheaf.Option {
+// Options returns options for the Generator. It returns an error if a flag
+// has an invalid value.
+func (g Generator) Options() ([]sheaf.Option, error) {
 	var list []sheaf.Option
 	for _, v := range g.m {
-		list = append(list, v()...)
+		options, err := v()
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, options...)
 	}
-	return Options(list...)
+	return Options(list...), nil
 }
 
-// WithArchive sets up the archive flag.
+// WithArchive sets up the archive, work directory, and keep extracted flags.
 func (g Generator) WithArchive() {
 	name := "archive"
-	g.stringFlag(name, "", "archive path")
+	g.stringFlag(name, "", "archive path, or the index or first volume of an archive split into volumes")
+	g.withWorkdir()
 	g.setOptions(name, func() []sheaf.Option {
 		archive := viper.GetString(g.flagName(name))
 		return []sheaf.Option{
@@ -91,6 +114,47 @@ func (g Generator) WithArchive() {
 	})
 }
 
+// WithArchiveArgs sets up archive options from the old and new archive
+// arguments, and the work directory and keep extracted flags.
+func (g Generator) WithArchiveArgs() {
+	g.withWorkdir()
+	g.setOptions("archive-args", func() []sheaf.Option {
+		args := *g.args
+		if len(args) != 2 {
+			return nil
+		}
+
+		return []sheaf.Option{
+			sheaf.WithArchive(args[0]),
+			sheaf.WithNewArchive(args[1]),
+		}
+	})
+}
+
+// withWorkdir sets up the work directory and keep extracted flags.
+func (g Generator) withWorkdir() {
+	g.stringFlag("workdir", "", "directory where archives are extracted (or $SHEAF_WORKDIR); defaults to the system temporary directory")
+	g.bindEnv("workdir", "SHEAF_WORKDIR")
+	g.boolFlag("keep-extracted", false, "keep extracted archives in the work directory so later commands can reuse them")
+	g.setOptions("workdir", func() []sheaf.Option {
+		return []sheaf.Option{
+			sheaf.WithWorkdir(viper.GetString(g.flagName("workdir"))),
+			sheaf.WithKeepExtracted(viper.GetBool(g.flagName("keep-extracted"))),
+		}
+	})
+}
+
+// WithOutputFormat sets up an output format option.
+func (g Generator) WithOutputFormat() {
+	name := "output"
+	g.stringFlag(name, sheaf.TextOutput, fmt.Sprintf("output format (%s)", strings.Join(sheaf.OutputFormats, ", ")))
+	g.setOptions(name, func() []sheaf.Option {
+		return []sheaf.Option{
+			sheaf.WithOutputFormat(viper.GetString(g.flagName(name))),
+		}
+	})
+}
+
 // WithBundleConfigFactory sets up options for creating a bundle config.
 func (g Generator) WithBundleConfigFactory() {
 	name := "bundle-config-factory"
@@ -106,6 +170,118 @@ func (g Generator) WithBundleConfigFactory() {
 	})
 }
 
+// WithBundlePacker sets up a bundle packer option. The packer is configured
+// by the staging concurrency, platform, signing key, archive format, split
+// size, and base options.
+func (g Generator) WithBundlePacker() {
+	g.setOptionsE("bundle-packer", func() ([]sheaf.Option, error) {
+		options := []fs.BundlePackerOption{
+			fs.BundlePackerLayoutFactory(fs.DefaultLayoutFactory(g.layoutFactoryOptions()...)),
+		}
+
+		for _, fn := range g.bundlePacker {
+			list, err := fn()
+			if err != nil {
+				return nil, err
+			}
+			options = append(options, list...)
+		}
+
+		return []sheaf.Option{
+			sheaf.WithBundlePacker(fs.NewBundlePacker(options...)),
+		}, nil
+	})
+}
+
+// WithStagingConcurrency sets up a concurrency option for staging images.
+func (g Generator) WithStagingConcurrency() {
+	name := "concurrency"
+	g.intFlag(name, fs.DefaultBundlePackerConcurrency, "number of images to stage at the same time")
+	g.setBundlePackerOptions(name, func() ([]fs.BundlePackerOption, error) {
+		return []fs.BundlePackerOption{
+			fs.BundlePackerConcurrency(viper.GetInt(g.flagName(name))),
+		}, nil
+	})
+}
+
+// WithPlatforms sets up the platforms kept from multi-architecture images.
+func (g Generator) WithPlatforms() {
+	name := "platform"
+	g.stringSliceP(name, "", nil, "platform kept from multi-architecture images, e.g. linux/arm64 (can specify multiple times)")
+	g.setBundlePackerOptions(name, func() ([]fs.BundlePackerOption, error) {
+		platforms := viper.GetStringSlice(g.flagName(name))
+		if len(platforms) == 0 {
+			return nil, nil
+		}
+
+		for _, s := range platforms {
+			if _, err := fs.ParsePlatform(s); err != nil {
+				return nil, err
+			}
+		}
+
+		layoutFactoryOptions := append(g.layoutFactoryOptions(),
+			fs.DefaultLayoutFactoryPlatforms(platforms...))
+
+		return []fs.BundlePackerOption{
+			fs.BundlePackerLayoutFactory(fs.DefaultLayoutFactory(layoutFactoryOptions...)),
+		}, nil
+	})
+}
+
+// WithSigningKey sets up an archive signing key option.
+func (g Generator) WithSigningKey() {
+	name := "sign-key"
+	g.stringFlag(name, "", "ed25519 or ECDSA private key (PEM) used to sign the archive")
+	g.setBundlePackerOptions(name, func() ([]fs.BundlePackerOption, error) {
+		return []fs.BundlePackerOption{
+			fs.BundlePackerSigningKey(viper.GetString(g.flagName(name))),
+		}, nil
+	})
+}
+
+// WithArchiveFormat sets up archive format options: the format and the
+// modification time of archive entries.
+func (g Generator) WithArchiveFormat() {
+	name := "format"
+	g.stringFlag(name, string(archiver.Gzip), fmt.Sprintf("archive format (%s)", strings.Join(archiver.FormatNames(), ", ")))
+	g.intFlag("source-date-epoch", 0, "modification time of archive entries in seconds since the Unix epoch (or $SOURCE_DATE_EPOCH)")
+	g.bindEnv("source-date-epoch", "SOURCE_DATE_EPOCH")
+	g.setBundlePackerOptions(name, func() ([]fs.BundlePackerOption, error) {
+		format, err := archiver.ParseFormat(viper.GetString(g.flagName(name)))
+		if err != nil {
+			return nil, err
+		}
+
+		modTime := time.Unix(viper.GetInt64(g.flagName("source-date-epoch")), 0)
+
+		return []fs.BundlePackerOption{
+			fs.BundlePackerArchiver(archiver.New(
+				archiver.WithModTime(modTime),
+				archiver.WithFormat(format))),
+		}, nil
+	})
+}
+
+// WithSplitSize sets up an option for splitting archives into volumes.
+func (g Generator) WithSplitSize() {
+	name := "split-size"
+	g.stringFlag(name, "", "split the archive into volumes of this size, e.g. 4G or 512Mi")
+	g.setBundlePackerOptions(name, func() ([]fs.BundlePackerOption, error) {
+		s := viper.GetString(g.flagName(name))
+		if s == "" {
+			return nil, nil
+		}
+
+		size, err := archiver.ParseSize(s)
+		if err != nil {
+			return nil, fmt.Errorf("split size: %w", err)
+		}
+
+		return []fs.BundlePackerOption{fs.BundlePackerSplitSize(size)}, nil
+	})
+}
+
 // WithBundlePath sets a bundle path option.
 func (g Generator) WithBundlePath() {
 	name := "bundle-path"
@@ -165,6 +341,33 @@ func (g Generator) WithForce() {
 	})
 }
 
+// WithHelmChart sets up Helm chart options.
+func (g Generator) WithHelmChart() {
+	g.stringFlag("chart", "", "helm chart directory or packaged chart")
+	g.stringSliceP("values", "", nil, "helm values file (can specify multiple times)")
+	g.stringFlag("release-name", "", "helm release name (defaults to chart name)")
+	g.stringFlag("namespace", "", "helm release namespace")
+	g.setOptions("helm-chart", func() []sheaf.Option {
+		chart := sheaf.HelmChart{
+			Chart:       viper.GetString(g.flagName("chart")),
+			ValuesFiles: viper.GetStringSlice(g.flagName("values")),
+			ReleaseName: viper.GetString(g.flagName("release-name")),
+			Namespace:   viper.GetString(g.flagName("namespace")),
+		}
+
+		return []sheaf.Option{sheaf.WithHelmChart(chart)}
+	})
+}
+
+// WithKustomization sets up kustomization options.
+func (g Generator) WithKustomization() {
+	name := "kustomization"
+	g.stringFlag(name, "", "kustomization directory")
+	g.setOptions(name, func() []sheaf.Option {
+		return []sheaf.Option{sheaf.WithKustomization(viper.GetString(g.flagName(name)))}
+	})
+}
+
 // WithImages sets up image options.
 func (g Generator) WithImages() {
 	name := "image"
@@ -175,6 +378,27 @@ func (g Generator) WithImages() {
 	})
 }
 
+// WithImageExclusions sets up image exclusion options.
+func (g Generator) WithImageExclusions() {
+	g.stringSliceP("image", "i", nil, "exclude an image reference (can specify multiple times)")
+	g.stringSliceP("repository", "", nil, "exclude repositories matching a glob (can specify multiple times)")
+	g.stringSliceP("registry", "", nil, "exclude images hosted by a registry (can specify multiple times)")
+	g.setOptions("exclusions", func() []sheaf.Option {
+		var list []sheaf.ImageExclusion
+		for _, s := range viper.GetStringSlice(g.flagName("image")) {
+			list = append(list, sheaf.ImageExclusion{Image: s})
+		}
+		for _, s := range viper.GetStringSlice(g.flagName("repository")) {
+			list = append(list, sheaf.ImageExclusion{Repository: s})
+		}
+		for _, s := range viper.GetStringSlice(g.flagName("registry")) {
+			list = append(list, sheaf.ImageExclusion{Registry: s})
+		}
+
+		return []sheaf.Option{sheaf.WithImageExclusions(list)}
+	})
+}
+
 // WithInitBundlePath sets up bundle path for a sheaf init.
 func (g Generator) WithInitBundlePath() {
 	name := "bundle-path"
@@ -235,43 +459,261 @@ func (g Generator) WithPrefix() {
 	})
 }
 
+// WithMapping sets up a relocation mapping option.
+func (g Generator) WithMapping(usage string) {
+	name := "mapping"
+	g.stringFlag(name, "", usage)
+	g.setOptions(name, func() []sheaf.Option {
+		return []sheaf.Option{
+			sheaf.WithMappingPath(viper.GetString(g.flagName(name))),
+		}
+	})
+}
+
+// WithMappingStrategy sets up repository mapping strategy options.
+func (g Generator) WithMappingStrategy() {
+	g.stringFlag("mapping-strategy", "", fmt.Sprintf("repository mapping strategy (%s)",
+		strings.Join(sheaf.MappingStrategyNames, ", ")))
+	g.stringFlag("mapping-template", "", "repository name template for the template mapping strategy")
+	g.setOptions("mapping-strategy", func() []sheaf.Option {
+		strategy := sheaf.MappingStrategy{
+			Name:     viper.GetString(g.flagName("mapping-strategy")),
+			Template: viper.GetString(g.flagName("mapping-template")),
+		}
+
+		if strategy.Name == "" && strategy.Template != "" {
+			strategy.Name = sheaf.TemplateMappingStrategy
+		}
+
+		return []sheaf.Option{sheaf.WithMappingStrategy(strategy)}
+	})
+}
+
 // WithInsecureRegistry sets up insecure registry option.
 func (g Generator) WithInsecureRegistry() {
 	name := "insecure-registry"
 	g.boolFlag(name, false, "insecure registry")
 	g.setOptions(name, func() []sheaf.Option {
 		forceInsecure := viper.GetBool(g.flagName(name))
+		keychain := g.keychain()
+		transport := g.transport()
+		registries := g.registries()
+
 		irOpts := []remote.ImageReaderOption{
 			remote.WithInsecure(forceInsecure),
+			remote.WithReaderKeychain(keychain),
+			remote.WithReaderTransport(transport),
+			remote.WithReaderRegistries(registries),
 		}
 
 		opts := []remote.Option{
 			remote.WithInsecureRegistry(forceInsecure),
+			remote.WithKeychain(keychain),
+			remote.WithTransport(transport),
+			remote.WithRegistries(registries),
 		}
 
 		ir := remote.NewImageReader(irOpts...)
 		iw := remote.NewImageWriter(opts...)
 
+		resolver := remote.NewImageResolver(opts...)
+
 		dryRun := viper.GetBool(g.flagName("dry-run"))
 
-		var layoutFactoryOptions []fs.LayoutOptionFunc
+		layoutFactoryOptions := g.layoutFactoryOptions()
 		if forceInsecure {
 			layoutFactoryOptions = append(layoutFactoryOptions,
 				fs.DefaultLayoutFactoryInsecureSkipVerify())
 		}
 
+		relocatorOptions := []fs.ImageRelocatorOption{
+			fs.ImageRelocatorLayoutFactory(fs.DefaultLayoutFactory(
+				layoutFactoryOptions...)),
+			fs.ImageRelocatorDryRun(dryRun),
+			fs.ImageRelocatorImageResolver(resolver),
+		}
+
+		for _, fn := range g.imageRelocator {
+			relocatorOptions = append(relocatorOptions, fn()...)
+		}
+
 		return []sheaf.Option{
 			sheaf.WithImageReader(ir),
 			sheaf.WithImageWriter(iw),
-			sheaf.WithImageRelocator(
-				fs.NewImageRelocator(
-					fs.ImageRelocatorLayoutFactory(fs.DefaultLayoutFactory(
-						layoutFactoryOptions...)),
-					fs.ImageRelocatorDryRun(dryRun))),
+			sheaf.WithImageResolver(resolver),
+			sheaf.WithImageRelocator(fs.NewImageRelocator(relocatorOptions...)),
 		}
 	})
 }
 
+// WithRegistryServer sets up registry server options.
+func (g Generator) WithRegistryServer() {
+	name := "addr"
+	g.stringFlag(name, ":5000", "address to listen on")
+	g.setOptions("registry-server", func() []sheaf.Option {
+		r := reporter.New(reporter.WithWriter(os.Stdout))
+
+		return []sheaf.Option{
+			sheaf.WithAddress(viper.GetString(g.flagName(name))),
+			sheaf.WithRegistryServer(fs.NewRegistryServer(fs.RegistryServerReporter(r))),
+		}
+	})
+}
+
+// WithArchiveVerifier sets up archive verifier options.
+func (g Generator) WithArchiveVerifier() {
+	g.setOptions("archive-verifier", func() []sheaf.Option {
+		return []sheaf.Option{
+			sheaf.WithArchiveVerifier(fs.NewArchiveVerifier()),
+		}
+	})
+}
+
+// WithBase sets up base archive options. Commands reading delta archives use
+// the base with the delta applier, and the bundle packer leaves its blobs out.
+func (g Generator) WithBase(usage string) {
+	name := "base"
+	g.stringFlag(name, "", usage)
+	g.setOptions(name, func() []sheaf.Option {
+		return []sheaf.Option{
+			sheaf.WithBase(viper.GetString(g.flagName(name))),
+			sheaf.WithDeltaApplier(fs.NewDeltaApplier()),
+		}
+	})
+	g.setBundlePackerOptions(name, func() ([]fs.BundlePackerOption, error) {
+		return []fs.BundlePackerOption{
+			fs.BundlePackerBase(viper.GetString(g.flagName(name))),
+		}, nil
+	})
+}
+
+// WithSignatureVerifier sets up archive signature verification options.
+// Signatures are verified only if a public key is set.
+func (g Generator) WithSignatureVerifier(usage string) {
+	name := "key"
+	g.stringFlag(name, "", usage)
+	g.setOptions("signature-verifier", func() []sheaf.Option {
+		keyPath := viper.GetString(g.flagName(name))
+		if keyPath == "" {
+			return nil
+		}
+
+		return []sheaf.Option{
+			sheaf.WithSignatureVerifier(fs.NewSignatureVerifier(keyPath)),
+		}
+	})
+}
+
+// WithRegistryAuth sets up registry authentication options. The options are
+// used by options that access registries.
+func (g Generator) WithRegistryAuth() {
+	g.stringFlag("username", "", "registry username (or $SHEAF_REGISTRY_USERNAME)")
+	g.bindEnv("username", "SHEAF_REGISTRY_USERNAME")
+	g.stringFlag("password", "", "registry password (or $SHEAF_REGISTRY_PASSWORD)")
+	g.bindEnv("password", "SHEAF_REGISTRY_PASSWORD")
+	g.stringFlag("token", "", "registry bearer token (or $SHEAF_REGISTRY_TOKEN)")
+	g.bindEnv("token", "SHEAF_REGISTRY_TOKEN")
+	g.stringFlag("registry", "", "registry host the username, password, and token are used for (or $SHEAF_REGISTRY); defaults to the host of the push or relocation target")
+	g.bindEnv("registry", "SHEAF_REGISTRY")
+	g.stringFlag("docker-config", "", "docker config file or directory used for registry credentials")
+	g.stringFlag("credentials-file", "", "sheaf credentials file with per registry credentials (or $SHEAF_CREDENTIALS_FILE)")
+	g.bindEnv("credentials-file", "SHEAF_CREDENTIALS_FILE")
+	g.stringSliceP("registry-ca", "", nil,
+		"CA certificate file trusted for registries, or <host>=<file> to trust it for one registry (can specify multiple times)")
+	g.stringFlag("registries-config", "", "registries config file with insecure, mirrored, and blocked registries (or $SHEAF_REGISTRIES_CONFIG)")
+	g.bindEnv("registries-config", "SHEAF_REGISTRIES_CONFIG")
+}
+
+// keychain creates a keychain from the registry authentication options.
+func (g Generator) keychain() *remote.Keychain {
+	return remote.NewKeychain(
+		remote.KeychainCredentials(g.credentialsRegistry(), remote.Credentials{
+			Username: viper.GetString(g.flagName("username")),
+			Password: viper.GetString(g.flagName("password")),
+			Token:    viper.GetString(g.flagName("token")),
+		}),
+		remote.KeychainCredentialsFile(viper.GetString(g.flagName("credentials-file"))),
+		remote.KeychainDockerConfig(viper.GetString(g.flagName("docker-config"))))
+}
+
+// credentialsRegistry returns the registry host the username, password, and
+// token are used for. Without a registry flag it is the host of the reference
+// or prefix the command pushes to.
+func (g Generator) credentialsRegistry() string {
+	if host := viper.GetString(g.flagName("registry")); host != "" {
+		return host
+	}
+
+	if ref := viper.GetString(g.flagName("ref")); ref != "" {
+		if r, err := name.ParseReference(ref); err == nil {
+			return r.Context().RegistryStr()
+		}
+	}
+
+	if prefix := viper.GetString(g.flagName("prefix")); prefix != "" {
+		if r, err := name.NewRepository(prefix); err == nil {
+			return r.RegistryStr()
+		}
+	}
+
+	return ""
+}
+
+// transport creates a transport from the registry CA options. It returns nil
+// if no CAs are configured, so the default transport is used. If the CAs
+// can't be loaded, the transport returns the error for every request.
+func (g Generator) transport() http.RoundTripper {
+	cas, err := remote.ParseCertificateAuthorities(viper.GetStringSlice(g.flagName("registry-ca")))
+	if err != nil {
+		return errTransport{err: err}
+	}
+
+	if cas.IsZero() {
+		return nil
+	}
+
+	t, err := remote.NewTransport(cas)
+	if err != nil {
+		return errTransport{err: fmt.Errorf("registry CA: %w", err)}
+	}
+
+	return t
+}
+
+// registries creates a registries config from the registry options.
+func (g Generator) registries() *remote.Registries {
+	return remote.NewRegistries(viper.GetString(g.flagName("registries-config")))
+}
+
+// layoutFactoryOptions creates layout factory options from the registry
+// authentication, CA, and registries config options.
+func (g Generator) layoutFactoryOptions() []fs.LayoutOptionFunc {
+	var list []fs.LayoutOptionFunc
+
+	if keychain := g.keychain(); !keychain.IsDefault() {
+		list = append(list, fs.DefaultLayoutFactoryKeychain(keychain))
+	}
+
+	if transport := g.transport(); transport != nil {
+		list = append(list, fs.DefaultLayoutFactoryTransport(transport))
+	}
+
+	if registries := g.registries(); !registries.IsDefault() {
+		list = append(list, fs.DefaultLayoutFactoryRegistries(registries))
+	}
+
+	return list
+}
+
+// errTransport is a transport that fails every request with an error.
+type errTransport struct {
+	err error
+}
+
+func (t errTransport) RoundTrip(*http.Request) (*http.Response, error) {
+	return nil, t.err
+}
+
 // WithReference sets up a registry reference option.
 func (g Generator) WithReference() {
 	name := "ref"
@@ -283,6 +725,16 @@ func (g Generator) WithReference() {
 	})
 }
 
+// WithCatalogEntryNames sets up catalog entry name options.
+func (g Generator) WithCatalogEntryNames() {
+	name := "name"
+	g.stringSliceP(name, "", nil, "catalog entry name (can specify multiple times)")
+	g.setOptions(name, func() []sheaf.Option {
+		s := viper.GetStringSlice(g.flagName(name))
+		return []sheaf.Option{sheaf.WithCatalogEntryNames(s)}
+	})
+}
+
 // WithDestination sets up a destination option.
 func (g Generator) WithDestination() {
 	name := "dest"
@@ -294,6 +746,53 @@ func (g Generator) WithDestination() {
 	})
 }
 
+// WithLocked sets up a locked option.
+func (g Generator) WithLocked() {
+	name := "locked"
+	g.boolFlag(name, false, "fail if the bundle lock is stale")
+	g.setOptions(name, func() []sheaf.Option {
+		return []sheaf.Option{
+			sheaf.WithLocked(viper.GetBool(g.flagName(name))),
+		}
+	})
+}
+
+// WithConcurrency sets up a concurrency option for image relocation.
+func (g Generator) WithConcurrency() {
+	name := "concurrency"
+	g.intFlag(name, fs.DefaultImageRelocatorConcurrency, "number of images to push at the same time")
+	g.setImageRelocatorOptions(name, func() []fs.ImageRelocatorOption {
+		return []fs.ImageRelocatorOption{
+			fs.ImageRelocatorConcurrency(viper.GetInt(g.flagName(name))),
+		}
+	})
+}
+
+// WithRelocationOutput sets up an output option for image relocation.
+func (g Generator) WithRelocationOutput() {
+	name := "output"
+	g.stringFlag(name, "", fmt.Sprintf("write images to %s<dir> (OCI image layout) or %s<file> (docker save tarball) instead of a registry",
+		fs.LayoutRelocationOutput, fs.TarballRelocationOutput))
+	g.setImageRelocatorOptions(name, func() []fs.ImageRelocatorOption {
+		return []fs.ImageRelocatorOption{
+			fs.ImageRelocatorOutput(viper.GetString(g.flagName(name))),
+		}
+	})
+}
+
+// WithRelocationPlatform sets up the platform written for image indexes when
+// images are relocated to a docker archive.
+func (g Generator) WithRelocationPlatform() {
+	name := "platform"
+	g.stringFlag(name, "", fmt.Sprintf("platform of the image written for multi-architecture images with %s<file>, e.g. linux/arm64",
+		fs.TarballRelocationOutput))
+	g.setImageRelocatorOptions(name, func() []fs.ImageRelocatorOption {
+		return []fs.ImageRelocatorOption{
+			fs.ImageRelocatorPlatform(viper.GetString(g.flagName(name))),
+		}
+	})
+}
+
 // WithDryRun sets up a dry run option.
 func (g Generator) WithDryRun() {
 	name := "dry-run"
@@ -305,6 +804,11 @@ func (g Generator) WithDryRun() {
 	})
 }
 
+func (g Generator) intFlag(name string, value int, usage string) {
+	g.cmd.Flags().Int(name, value, usage)
+	g.bindFlag(name)
+}
+
 func (g Generator) boolFlag(name string, value bool, usage string) {
 	g.cmd.Flags().Bool(name, value, usage)
 	g.bindFlag(name)
@@ -320,14 +824,40 @@ func (g Generator) stringSliceP(name, shorthand string, value []string, usage st
 	g.bindFlag(name)
 }
 
+func (g Generator) bindEnv(name, env string) {
+	if err := viper.BindEnv(g.flagName(name), env); err != nil {
+		panic(fmt.Sprintf("unable to bind %s to %s in %s", name, env, g.prefix))
+	}
+}
+
 func (g Generator) flagName(name string) string {
 	return fmt.Sprintf("%s-%s", g.prefix, name)
 }
 
 func (g Generator) setOptions(name string, fn func() []sheaf.Option) {
+	g.m[name] = func() ([]sheaf.Option, error) {
+		return fn(), nil
+	}
+}
+
+// setOptionsE sets options created by a function that fails if a flag has an
+// invalid value.
+func (g Generator) setOptionsE(name string, fn func() ([]sheaf.Option, error)) {
 	g.m[name] = fn
 }
 
+// setBundlePackerOptions sets options for the packer created by
+// WithBundlePacker.
+func (g Generator) setBundlePackerOptions(name string, fn func() ([]fs.BundlePackerOption, error)) {
+	g.bundlePacker[name] = fn
+}
+
+// setImageRelocatorOptions sets options for the relocator created by
+// WithInsecureRegistry.
+func (g Generator) setImageRelocatorOptions(name string, fn func() []fs.ImageRelocatorOption) {
+	g.imageRelocator[name] = fn
+}
+
 func (g Generator) bindFlag(name string) {
 	if err := viper.BindPFlag(g.flagName(name), g.cmd.Flags().Lookup(name)); err != nil {
 		panic(fmt.Sprintf("unable to bind %s in %s", name, g.prefix))
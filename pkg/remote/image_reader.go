@@ -8,11 +8,13 @@ package remote
 
 import (
 	"fmt"
+	"net/http"
 
 	"github.com/google/go-containerregistry/pkg/authn"
 	"github.com/google/go-containerregistry/pkg/name"
 	v1 "github.com/google/go-containerregistry/pkg/v1"
 	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"go.uber.org/multierr"
 
 	"github.com/bryanl/sheaf/pkg/sheaf"
 )
@@ -28,11 +30,35 @@ func WithInsecure(forceInsecure bool) ImageReaderOption {
 	}
 }
 
+// WithReaderKeychain sets the keychain used to authenticate with registries.
+func WithReaderKeychain(keychain authn.Keychain) ImageReaderOption {
+	return func(ir *ImageReader) {
+		ir.keychain = keychain
+	}
+}
+
+// WithReaderTransport sets the transport used to connect to registries.
+func WithReaderTransport(transport http.RoundTripper) ImageReaderOption {
+	return func(ir *ImageReader) {
+		ir.transport = transport
+	}
+}
+
+// WithReaderRegistries sets the registries config applied to registry references.
+func WithReaderRegistries(registries *Registries) ImageReaderOption {
+	return func(ir *ImageReader) {
+		ir.registries = registries
+	}
+}
+
 // ImageReader reads images from a remote registry.
 type ImageReader struct {
 	fetcher         Fetcher
 	referenceParser ReferenceParser
 	forceInsecure   bool
+	keychain        authn.Keychain
+	transport       http.RoundTripper
+	registries      *Registries
 }
 
 var _ sheaf.ImageReader = &ImageReader{}
@@ -40,7 +66,6 @@ var _ sheaf.ImageReader = &ImageReader{}
 // NewImageReader creates an instance of ImageReader.
 func NewImageReader(options ...ImageReaderOption) *ImageReader {
 	ir := ImageReader{
-		fetcher:         &ggcrFetcher{},
 		referenceParser: &ggcrReferenceParser{},
 	}
 
@@ -48,6 +73,10 @@ func NewImageReader(options ...ImageReaderOption) *ImageReader {
 		option(&ir)
 	}
 
+	if ir.fetcher == nil {
+		ir.fetcher = &ggcrFetcher{keychain: ir.keychain, transport: ir.transport}
+	}
+
 	return &ir
 }
 
@@ -58,12 +87,24 @@ func (i ImageReader) Read(refStr string) (v1.Image, error) {
 		return nil, fmt.Errorf("parse remote reference: %w", err)
 	}
 
-	image, err := i.fetcher.Fetch(ref)
+	refs, err := i.registries.PullReferences(ref)
 	if err != nil {
 		return nil, fmt.Errorf("unable to fetch image %s: %w", refStr, err)
 	}
 
-	return image, nil
+	// Mirrors are tried before the reference itself.
+	var errs error
+	for _, ref := range refs {
+		image, err := i.fetcher.Fetch(ref)
+		if err != nil {
+			errs = multierr.Append(errs, err)
+			continue
+		}
+
+		return image, nil
+	}
+
+	return nil, fmt.Errorf("unable to fetch image %s: %w", refStr, errs)
 }
 
 // Fetcher is an interface for fetching a ref.
@@ -72,12 +113,15 @@ type Fetcher interface {
 	Fetch(ref name.Reference) (v1.Image, error)
 }
 
-type ggcrFetcher struct{}
+type ggcrFetcher struct {
+	keychain  authn.Keychain
+	transport http.RoundTripper
+}
 
 var _ Fetcher = &ggcrFetcher{}
 
 func (g ggcrFetcher) Fetch(ref name.Reference) (v1.Image, error) {
-	return remote.Image(ref, remote.WithAuthFromKeychain(authn.DefaultKeychain))
+	return remote.Image(ref, remoteOptions(g.keychain, g.transport)...)
 }
 
 // ReferenceParser parsers a reference.
@@ -8,6 +8,8 @@ package remote
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/google/go-containerregistry/pkg/name"
@@ -73,6 +75,54 @@ func TestImageReader_Read(t *testing.T) {
 	}
 }
 
+func TestImageReader_Read_mirrors(t *testing.T) {
+	config := writeRegistriesConfig(t, `
+[[registry]]
+location = "registry.example.com"
+
+[[registry.mirror]]
+location = "unavailable.example.com"
+
+[[registry.mirror]]
+location = "mirror.example.com"
+`)
+	defer os.RemoveAll(filepath.Dir(config))
+
+	fetcher := &mirrorFetcher{
+		unavailable: map[string]bool{"unavailable.example.com/app:1.0": true},
+	}
+
+	ir := NewImageReader(WithReaderRegistries(NewRegistries(config)), func(ir *ImageReader) {
+		ir.fetcher = fetcher
+	})
+
+	_, err := ir.Read("registry.example.com/app:1.0")
+	require.NoError(t, err)
+
+	require.Equal(t, []string{
+		"unavailable.example.com/app:1.0",
+		"mirror.example.com/app:1.0",
+	}, fetcher.requested)
+}
+
+// mirrorFetcher fails to fetch unavailable references.
+type mirrorFetcher struct {
+	unavailable map[string]bool
+
+	requested []string
+}
+
+var _ Fetcher = &mirrorFetcher{}
+
+func (f *mirrorFetcher) Fetch(ref name.Reference) (v1.Image, error) {
+	f.requested = append(f.requested, ref.Name())
+	if f.unavailable[ref.Name()] {
+		return nil, fmt.Errorf("%s is unavailable", ref)
+	}
+
+	return nil, nil
+}
+
 type fakeFetcher struct {
 	image v1.Image
 	err   error
@@ -0,0 +1,76 @@
+/*
+ * Copyright 2020 Sheaf Authors
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package remote
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/pivotal/image-relocation/pkg/image"
+	"go.uber.org/multierr"
+
+	"github.com/bryanl/sheaf/pkg/sheaf"
+)
+
+// ImageResolver resolves image names to digests using a remote registry.
+type ImageResolver struct {
+	insecureRegistry bool
+	keychain         authn.Keychain
+	transport        http.RoundTripper
+	registries       *Registries
+}
+
+var _ sheaf.ImageResolver = &ImageResolver{}
+
+// NewImageResolver creates an instance of ImageResolver.
+func NewImageResolver(optionList ...Option) *ImageResolver {
+	var opts options
+	for _, option := range optionList {
+		option(&opts)
+	}
+
+	return &ImageResolver{
+		insecureRegistry: opts.insecureRegistry,
+		keychain:         keychainOrDefault(opts.keychain),
+		transport:        opts.transport,
+		registries:       opts.registries,
+	}
+}
+
+// Resolve resolves an image name to the digest of its manifest or index.
+func (i *ImageResolver) Resolve(n image.Name) (image.Digest, error) {
+	var nameOptions []name.Option
+	if i.insecureRegistry {
+		nameOptions = append(nameOptions, name.Insecure)
+	}
+
+	ref, err := name.ParseReference(n.String(), nameOptions...)
+	if err != nil {
+		return image.EmptyDigest, fmt.Errorf("parse remote reference: %w", err)
+	}
+
+	refs, err := i.registries.PullReferences(ref)
+	if err != nil {
+		return image.EmptyDigest, fmt.Errorf("fetch %s: %w", n, err)
+	}
+
+	var errs error
+	for _, ref := range refs {
+		desc, err := remote.Get(ref, remoteOptions(i.keychain, i.transport)...)
+		if err != nil {
+			errs = multierr.Append(errs, err)
+			continue
+		}
+
+		return image.NewDigest(desc.Digest.String())
+	}
+
+	return image.EmptyDigest, fmt.Errorf("fetch %s: %w", n, errs)
+}
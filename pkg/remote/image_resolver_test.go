@@ -0,0 +1,74 @@
+/*
+ * Copyright 2020 Sheaf Authors
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package remote
+
+import (
+	"fmt"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/registry"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/pivotal/image-relocation/pkg/image"
+	"github.com/stretchr/testify/require"
+)
+
+func TestImageResolver_Resolve(t *testing.T) {
+	server := httptest.NewServer(registry.New())
+	defer server.Close()
+
+	u, err := url.Parse(server.URL)
+	require.NoError(t, err)
+
+	img, err := random.Image(1024, 1)
+	require.NoError(t, err)
+
+	ref, err := name.ParseReference(fmt.Sprintf("%s/app:1.0", u.Host), name.Insecure)
+	require.NoError(t, err)
+	require.NoError(t, remote.Write(ref, img))
+
+	wanted, err := img.Digest()
+	require.NoError(t, err)
+
+	cases := []struct {
+		name    string
+		image   string
+		want    string
+		wantErr bool
+	}{
+		{
+			name:  "in general",
+			image: fmt.Sprintf("%s/app:1.0", u.Host),
+			want:  wanted.String(),
+		},
+		{
+			name:    "missing tag",
+			image:   fmt.Sprintf("%s/app:2.0", u.Host),
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			n, err := image.NewName(tc.image)
+			require.NoError(t, err)
+
+			ir := NewImageResolver(WithInsecureRegistry(true))
+			got, err := ir.Resolve(n)
+			if tc.wantErr {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tc.want, got.String())
+		})
+	}
+}
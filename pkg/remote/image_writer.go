@@ -8,6 +8,7 @@ package remote
 
 import (
 	"fmt"
+	"net/http"
 
 	"github.com/google/go-containerregistry/pkg/authn"
 	"github.com/google/go-containerregistry/pkg/name"
@@ -20,6 +21,9 @@ import (
 // ImageWriter is a remote image writer.
 type ImageWriter struct {
 	insecureRegistry bool
+	keychain         authn.Keychain
+	transport        http.RoundTripper
+	registries       *Registries
 }
 
 var _ sheaf.ImageWriter = &ImageWriter{}
@@ -33,6 +37,9 @@ func NewImageWriter(optionList ...Option) *ImageWriter {
 
 	iw := &ImageWriter{
 		insecureRegistry: opts.insecureRegistry,
+		keychain:         keychainOrDefault(opts.keychain),
+		transport:        opts.transport,
+		registries:       opts.registries,
 	}
 
 	return iw
@@ -50,7 +57,12 @@ func (i *ImageWriter) Write(ref string, image v1.Image) error {
 		return fmt.Errorf("parse remote reference: %w", err)
 	}
 
-	return remote.Write(dstRef, image, remote.WithAuthFromKeychain(authn.DefaultKeychain))
+	dstRef, err = i.registries.PushReference(dstRef)
+	if err != nil {
+		return fmt.Errorf("push %s: %w", ref, err)
+	}
+
+	return remote.Write(dstRef, image, remoteOptions(i.keychain, i.transport)...)
 }
 
 // WriteIndex writes an index to a remote registry.
@@ -65,7 +77,12 @@ func (i *ImageWriter) WriteIndex(ref string, imageIndex v1.ImageIndex) error {
 		return fmt.Errorf("parse remote reference: %w", err)
 	}
 
-	if err := remote.WriteIndex(dstRef, imageIndex, remote.WithAuthFromKeychain(authn.DefaultKeychain)); err != nil {
+	dstRef, err = i.registries.PushReference(dstRef)
+	if err != nil {
+		return fmt.Errorf("push %s: %w", ref, err)
+	}
+
+	if err := remote.WriteIndex(dstRef, imageIndex, remoteOptions(i.keychain, i.transport)...); err != nil {
 		return fmt.Errorf("write index: %w", err)
 	}
 
@@ -0,0 +1,261 @@
+/*
+ * Copyright 2020 Sheaf Authors
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package remote
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/docker/cli/cli/config"
+	"github.com/docker/cli/cli/config/configfile"
+	"github.com/docker/cli/cli/config/types"
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	"sigs.k8s.io/yaml"
+)
+
+// Credentials are credentials for a registry. Set either a username and
+// password or a bearer token.
+type Credentials struct {
+	// Username is the registry username.
+	Username string `json:"username,omitempty"`
+	// Password is the registry password.
+	Password string `json:"password,omitempty"`
+	// Token is a registry bearer token.
+	Token string `json:"token,omitempty"`
+}
+
+// IsZero returns true if no credentials are set.
+func (c Credentials) IsZero() bool {
+	return c == Credentials{}
+}
+
+// Validate validates credentials.
+func (c Credentials) Validate() error {
+	if c.Token != "" && (c.Username != "" || c.Password != "") {
+		return fmt.Errorf("token can't be combined with a username or password")
+	}
+
+	if c.Token == "" && (c.Username == "") != (c.Password == "") {
+		return fmt.Errorf("username and password must be set together")
+	}
+
+	return nil
+}
+
+func (c Credentials) authenticator() authn.Authenticator {
+	if c.Token != "" {
+		return &authn.Bearer{Token: c.Token}
+	}
+
+	return &authn.Basic{Username: c.Username, Password: c.Password}
+}
+
+// CredentialsFile is a sheaf credentials file. It maps registry hosts to
+// credentials.
+type CredentialsFile struct {
+	// Registries are credentials by registry host, e.g. registry.example.com:5000.
+	Registries map[string]Credentials `json:"registries"`
+}
+
+// ReadCredentialsFile reads a JSON or YAML credentials file.
+func ReadCredentialsFile(path string) (CredentialsFile, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return CredentialsFile{}, err
+	}
+
+	var cf CredentialsFile
+	if err := yaml.Unmarshal(data, &cf); err != nil {
+		return CredentialsFile{}, fmt.Errorf("decode credentials file %s: %w", path, err)
+	}
+
+	for host, c := range cf.Registries {
+		if err := c.Validate(); err != nil {
+			return CredentialsFile{}, fmt.Errorf("credentials for %s: %w", host, err)
+		}
+	}
+
+	return cf, nil
+}
+
+// lookup returns the credentials for a registry host.
+func (cf CredentialsFile) lookup(host string) (Credentials, bool) {
+	host = normalizeRegistryHost(host)
+	for k, c := range cf.Registries {
+		if normalizeRegistryHost(k) == host {
+			return c, true
+		}
+	}
+
+	return Credentials{}, false
+}
+
+// KeychainOption is a functional option for configuring Keychain.
+type KeychainOption func(k *Keychain)
+
+// KeychainCredentials configures credentials used for one registry host if
+// the credentials file has no entry for it. Other registries never receive
+// them.
+func KeychainCredentials(host string, c Credentials) KeychainOption {
+	return func(k *Keychain) {
+		k.credentialsHost = host
+		k.credentials = c
+	}
+}
+
+// KeychainCredentialsFile configures a credentials file with per registry
+// credentials. The file is read the first time credentials are resolved.
+func KeychainCredentialsFile(path string) KeychainOption {
+	return func(k *Keychain) {
+		k.credentialsPath = path
+	}
+}
+
+// KeychainDockerConfig configures the docker config file used instead of the
+// default docker config. The path can be a config file or a directory
+// containing config.json.
+func KeychainDockerConfig(path string) KeychainOption {
+	return func(k *Keychain) {
+		k.dockerConfig = path
+	}
+}
+
+// Keychain resolves registry credentials. Credentials are looked up in the
+// credentials file, then in the explicit credentials if they are for the
+// registry, and finally in the docker config.
+type Keychain struct {
+	credentials     Credentials
+	credentialsHost string
+	credentialsPath string
+	dockerConfig    string
+
+	once            sync.Once
+	credentialsFile CredentialsFile
+	err             error
+}
+
+var _ authn.Keychain = &Keychain{}
+
+// NewKeychain creates an instance of Keychain.
+func NewKeychain(options ...KeychainOption) *Keychain {
+	var k Keychain
+	for _, option := range options {
+		option(&k)
+	}
+
+	return &k
+}
+
+// IsDefault returns true if the keychain only uses the default docker config.
+func (k *Keychain) IsDefault() bool {
+	return k.credentials.IsZero() && k.credentialsPath == "" && k.dockerConfig == ""
+}
+
+// Resolve resolves the authenticator for a registry.
+func (k *Keychain) Resolve(target authn.Resource) (authn.Authenticator, error) {
+	k.once.Do(func() {
+		if err := k.credentials.Validate(); err != nil {
+			k.err = fmt.Errorf("registry credentials: %w", err)
+			return
+		}
+
+		if !k.credentials.IsZero() && k.credentialsHost == "" {
+			k.err = fmt.Errorf("registry credentials: registry host is required")
+			return
+		}
+
+		if k.credentialsPath != "" {
+			k.credentialsFile, k.err = ReadCredentialsFile(k.credentialsPath)
+		}
+	})
+
+	if k.err != nil {
+		return nil, k.err
+	}
+
+	if c, ok := k.credentialsFile.lookup(target.RegistryStr()); ok {
+		return c.authenticator(), nil
+	}
+
+	if !k.credentials.IsZero() && normalizeRegistryHost(k.credentialsHost) == normalizeRegistryHost(target.RegistryStr()) {
+		return k.credentials.authenticator(), nil
+	}
+
+	if k.dockerConfig == "" {
+		return authn.DefaultKeychain.Resolve(target)
+	}
+
+	cf, err := loadDockerConfig(k.dockerConfig)
+	if err != nil {
+		return nil, fmt.Errorf("load docker config %s: %w", k.dockerConfig, err)
+	}
+
+	// Docker Hub credentials are stored under its legacy index address.
+	key := target.RegistryStr()
+	if key == name.DefaultRegistry {
+		key = "https://" + name.DefaultRegistry + "/v1/"
+	}
+
+	ac, err := cf.GetAuthConfig(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if ac == (types.AuthConfig{}) {
+		return authn.Anonymous, nil
+	}
+
+	return authn.FromConfig(authn.AuthConfig{
+		Username:      ac.Username,
+		Password:      ac.Password,
+		Auth:          ac.Auth,
+		IdentityToken: ac.IdentityToken,
+		RegistryToken: ac.RegistryToken,
+	}), nil
+}
+
+// loadDockerConfig loads a docker config file or a directory containing one.
+func loadDockerConfig(path string) (*configfile.ConfigFile, error) {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if fi.IsDir() {
+		return config.Load(path)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	cf, err := config.LoadFromReader(f)
+	if err != nil {
+		return nil, err
+	}
+	cf.Filename = path
+
+	return cf, nil
+}
+
+// normalizeRegistryHost returns the host Docker Hub is resolved as for its
+// aliases.
+func normalizeRegistryHost(host string) string {
+	host = strings.ToLower(host)
+	switch host {
+	case "docker.io", "registry-1.docker.io":
+		return name.DefaultRegistry
+	default:
+		return host
+	}
+}
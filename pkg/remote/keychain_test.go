@@ -0,0 +1,170 @@
+/*
+ * Copyright 2020 Sheaf Authors
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package remote
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeychain_Resolve(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sheaf-test")
+	require.NoError(t, err)
+
+	defer func() {
+		require.NoError(t, os.RemoveAll(dir))
+	}()
+
+	// Keep the default keychain from reading the user's docker config.
+	emptyDockerConfig := filepath.Join(dir, "empty")
+	require.NoError(t, os.Mkdir(emptyDockerConfig, 0700))
+	defer os.Setenv("DOCKER_CONFIG", os.Getenv("DOCKER_CONFIG"))
+	require.NoError(t, os.Setenv("DOCKER_CONFIG", emptyDockerConfig))
+
+	credentialsFile := filepath.Join(dir, "credentials.yaml")
+	require.NoError(t, ioutil.WriteFile(credentialsFile, []byte(`registries:
+  registry.example.com:
+    username: file-user
+    password: file-password
+  docker.io:
+    token: hub-token
+`), 0600))
+
+	invalidCredentialsFile := filepath.Join(dir, "invalid.yaml")
+	require.NoError(t, ioutil.WriteFile(invalidCredentialsFile, []byte(`registries:
+  registry.example.com:
+    username: user
+`), 0600))
+
+	dockerConfig := filepath.Join(dir, "config.json")
+	require.NoError(t, ioutil.WriteFile(dockerConfig, []byte(`{
+  "auths": {
+    "registry.example.com": {"auth": "ZG9ja2VyLXVzZXI6ZG9ja2VyLXBhc3N3b3Jk"}
+  }
+}`), 0600))
+
+	cases := []struct {
+		name     string
+		options  []KeychainOption
+		registry string
+		want     authn.AuthConfig
+		wantErr  bool
+	}{
+		{
+			name:     "default",
+			registry: "registry.example.com",
+		},
+		{
+			name:     "credentials",
+			options:  []KeychainOption{KeychainCredentials("registry.example.com", Credentials{Username: "user", Password: "password"})},
+			registry: "registry.example.com",
+			want:     authn.AuthConfig{Username: "user", Password: "password"},
+		},
+		{
+			name:     "token",
+			options:  []KeychainOption{KeychainCredentials("registry.example.com", Credentials{Token: "token"})},
+			registry: "registry.example.com",
+			want:     authn.AuthConfig{RegistryToken: "token"},
+		},
+		{
+			name: "credentials file entry is used before credentials",
+			options: []KeychainOption{
+				KeychainCredentials("registry.example.com", Credentials{Username: "user", Password: "password"}),
+				KeychainCredentialsFile(credentialsFile),
+			},
+			registry: "registry.example.com",
+			want:     authn.AuthConfig{Username: "file-user", Password: "file-password"},
+		},
+		{
+			name:     "credentials file with docker hub alias",
+			options:  []KeychainOption{KeychainCredentialsFile(credentialsFile)},
+			registry: "index.docker.io",
+			want:     authn.AuthConfig{RegistryToken: "hub-token"},
+		},
+		{
+			name: "registry not in credentials file",
+			options: []KeychainOption{
+				KeychainCredentials("registry.example.com", Credentials{Username: "user", Password: "password"}),
+				KeychainCredentialsFile(credentialsFile),
+			},
+			registry: "other.example.com",
+		},
+		{
+			name: "credentials for another registry",
+			options: []KeychainOption{
+				KeychainCredentials("registry.example.com", Credentials{Username: "user", Password: "password"}),
+				KeychainDockerConfig(dockerConfig),
+			},
+			registry: "docker.io",
+		},
+		{
+			name:     "credentials for docker hub alias",
+			options:  []KeychainOption{KeychainCredentials("docker.io", Credentials{Token: "token"})},
+			registry: "index.docker.io",
+			want:     authn.AuthConfig{RegistryToken: "token"},
+		},
+		{
+			name:     "credentials without registry",
+			options:  []KeychainOption{KeychainCredentials("", Credentials{Token: "token"})},
+			registry: "registry.example.com",
+			wantErr:  true,
+		},
+		{
+			name:     "docker config",
+			options:  []KeychainOption{KeychainDockerConfig(dockerConfig)},
+			registry: "registry.example.com",
+			want:     authn.AuthConfig{Username: "docker-user", Password: "docker-password"},
+		},
+		{
+			name:     "registry not in docker config",
+			options:  []KeychainOption{KeychainDockerConfig(dockerConfig)},
+			registry: "other.example.com",
+		},
+		{
+			name:     "missing docker config",
+			options:  []KeychainOption{KeychainDockerConfig(filepath.Join(dir, "missing.json"))},
+			registry: "registry.example.com",
+			wantErr:  true,
+		},
+		{
+			name:     "invalid credentials",
+			options:  []KeychainOption{KeychainCredentials("registry.example.com", Credentials{Username: "user"})},
+			registry: "registry.example.com",
+			wantErr:  true,
+		},
+		{
+			name:     "invalid credentials file",
+			options:  []KeychainOption{KeychainCredentialsFile(invalidCredentialsFile)},
+			registry: "registry.example.com",
+			wantErr:  true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			reg, err := name.NewRegistry(tc.registry)
+			require.NoError(t, err)
+
+			auth, err := NewKeychain(tc.options...).Resolve(reg)
+			if tc.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+
+			got, err := auth.Authorization()
+			require.NoError(t, err)
+			require.Equal(t, tc.want, *got)
+		})
+	}
+}
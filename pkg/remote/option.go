@@ -6,8 +6,18 @@
 
 package remote
 
+import (
+	"net/http"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+)
+
 type options struct {
 	insecureRegistry bool
+	keychain         authn.Keychain
+	transport        http.RoundTripper
+	registries       *Registries
 }
 
 // Option is a functional option for configuring remote.
@@ -19,3 +29,49 @@ func WithInsecureRegistry(forceInsecure bool) Option {
 		o.insecureRegistry = forceInsecure
 	}
 }
+
+// WithKeychain sets the keychain used to authenticate with registries. The
+// default keychain uses the docker config.
+func WithKeychain(keychain authn.Keychain) Option {
+	return func(o *options) {
+		o.keychain = keychain
+	}
+}
+
+// WithTransport sets the transport used to connect to registries, e.g. one
+// created by NewTransport.
+func WithTransport(transport http.RoundTripper) Option {
+	return func(o *options) {
+		o.transport = transport
+	}
+}
+
+// WithRegistries sets the registries config applied to registry references.
+func WithRegistries(registries *Registries) Option {
+	return func(o *options) {
+		o.registries = registries
+	}
+}
+
+// remoteOptions returns remote options for a keychain and transport. A nil
+// transport uses the default transport.
+func remoteOptions(keychain authn.Keychain, transport http.RoundTripper) []remote.Option {
+	list := []remote.Option{
+		remote.WithAuthFromKeychain(keychainOrDefault(keychain)),
+	}
+
+	if transport != nil {
+		list = append(list, remote.WithTransport(transport))
+	}
+
+	return list
+}
+
+// keychainOrDefault returns a keychain, or the default keychain if it is nil.
+func keychainOrDefault(keychain authn.Keychain) authn.Keychain {
+	if keychain == nil {
+		return authn.DefaultKeychain
+	}
+
+	return keychain
+}
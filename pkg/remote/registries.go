@@ -0,0 +1,307 @@
+/*
+ * Copyright 2020 Sheaf Authors
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package remote
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"sync"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/pelletier/go-toml"
+)
+
+// RegistriesConfig is a registries config file. It is modeled on
+// containers-registries.conf(5):
+//
+//	[[registry]]
+//	location = "registry.example.com:5000"
+//	insecure = true
+//
+//	[[registry]]
+//	location = "docker.io"
+//
+//	[[registry.mirror]]
+//	location = "mirror.example.com/docker.io"
+type RegistriesConfig struct {
+	// Registries are the configured registries.
+	Registries []RegistryConfig `toml:"registry"`
+}
+
+// RegistryConfig configures a registry, or a namespace in a registry.
+type RegistryConfig struct {
+	// Location is a registry host, e.g. registry.example.com:5000, optionally
+	// followed by a namespace, e.g. registry.example.com/team.
+	Location string `toml:"location"`
+	// Insecure allows plain HTTP connections to the registry host.
+	Insecure bool `toml:"insecure"`
+	// Blocked refuses pulls from and pushes to the location.
+	Blocked bool `toml:"blocked"`
+	// Mirrors are tried in order before the location when pulling images.
+	Mirrors []MirrorConfig `toml:"mirror"`
+}
+
+// MirrorConfig configures a registry mirror.
+type MirrorConfig struct {
+	// Location is the mirror host, optionally followed by a namespace. The
+	// image's path below the registry location is appended to it.
+	Location string `toml:"location"`
+	// Insecure allows plain HTTP connections to the mirror host.
+	Insecure bool `toml:"insecure"`
+}
+
+// ReadRegistriesConfig reads a TOML registries config file.
+func ReadRegistriesConfig(path string) (RegistriesConfig, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return RegistriesConfig{}, err
+	}
+
+	var rc RegistriesConfig
+	if err := toml.Unmarshal(data, &rc); err != nil {
+		return RegistriesConfig{}, fmt.Errorf("decode registries config %s: %w", path, err)
+	}
+
+	if err := rc.Validate(); err != nil {
+		return RegistriesConfig{}, fmt.Errorf("registries config %s: %w", path, err)
+	}
+
+	return rc, nil
+}
+
+// Validate validates a registries config.
+func (rc RegistriesConfig) Validate() error {
+	seen := map[string]bool{}
+	for _, r := range rc.Registries {
+		location, err := normalizeLocation(r.Location)
+		if err != nil {
+			return err
+		}
+
+		if seen[location] {
+			return fmt.Errorf("registry %s is configured more than once", r.Location)
+		}
+		seen[location] = true
+
+		for _, m := range r.Mirrors {
+			if _, err := normalizeLocation(m.Location); err != nil {
+				return fmt.Errorf("mirror for %s: %w", r.Location, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// lookup returns the registry config with the longest location matching a
+// repository.
+func (rc RegistriesConfig) lookup(repo name.Repository) (RegistryConfig, string, bool) {
+	repository := repositoryLocation(repo)
+
+	var (
+		found    RegistryConfig
+		matched  string
+		foundAny bool
+	)
+
+	for _, r := range rc.Registries {
+		location, err := normalizeLocation(r.Location)
+		if err != nil {
+			continue
+		}
+
+		if repository != location && !strings.HasPrefix(repository, location+"/") {
+			continue
+		}
+
+		if len(location) > len(matched) {
+			found, matched, foundAny = r, location, true
+		}
+	}
+
+	return found, matched, foundAny
+}
+
+// insecure returns true if a registry host allows plain HTTP connections.
+func (rc RegistriesConfig) insecure(host string) bool {
+	host = normalizeRegistryHost(host)
+
+	for _, r := range rc.Registries {
+		if r.Insecure && locationHost(r.Location) == host {
+			return true
+		}
+
+		for _, m := range r.Mirrors {
+			if m.Insecure && locationHost(m.Location) == host {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// Registries applies a registries config file to registry references. The
+// file is read the first time it is used. A nil Registries, or one without a
+// file, leaves references unchanged.
+type Registries struct {
+	path string
+
+	once   sync.Once
+	config RegistriesConfig
+	err    error
+}
+
+// NewRegistries creates an instance of Registries for a registries config file.
+func NewRegistries(path string) *Registries {
+	return &Registries{path: path}
+}
+
+// IsDefault returns true if no registries config file is used.
+func (r *Registries) IsDefault() bool {
+	return r == nil || r.path == ""
+}
+
+func (r *Registries) load() (RegistriesConfig, error) {
+	if r.IsDefault() {
+		return RegistriesConfig{}, nil
+	}
+
+	r.once.Do(func() {
+		r.config, r.err = ReadRegistriesConfig(r.path)
+	})
+
+	return r.config, r.err
+}
+
+// PullReferences returns the references to try in order when pulling a
+// reference: its mirrors, followed by the reference itself. It returns an
+// error if the reference's location is blocked.
+func (r *Registries) PullReferences(ref name.Reference) ([]name.Reference, error) {
+	rc, err := r.load()
+	if err != nil {
+		return nil, err
+	}
+
+	repo := ref.Context()
+	registryConfig, location, ok := rc.lookup(repo)
+	if !ok {
+		return []name.Reference{ref}, nil
+	}
+
+	if registryConfig.Blocked {
+		return nil, fmt.Errorf("registry %s is blocked", registryConfig.Location)
+	}
+
+	var list []name.Reference
+	for _, m := range registryConfig.Mirrors {
+		mirrorLocation, err := normalizeLocation(m.Location)
+		if err != nil {
+			return nil, err
+		}
+
+		mirrored := mirrorLocation + strings.TrimPrefix(repositoryLocation(repo), location)
+
+		mirrorRef, err := rebaseReference(ref, mirrored, rc.insecure(locationHost(mirrorLocation)))
+		if err != nil {
+			return nil, fmt.Errorf("mirror %s for %s: %w", m.Location, ref, err)
+		}
+
+		list = append(list, mirrorRef)
+	}
+
+	ref, err = insecureReference(rc, ref)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(list, ref), nil
+}
+
+// PushReference returns the reference to push a reference to. It returns an
+// error if the reference's location is blocked.
+func (r *Registries) PushReference(ref name.Reference) (name.Reference, error) {
+	rc, err := r.load()
+	if err != nil {
+		return nil, err
+	}
+
+	repo := ref.Context()
+	if registryConfig, _, ok := rc.lookup(repo); ok && registryConfig.Blocked {
+		return nil, fmt.Errorf("registry %s is blocked", registryConfig.Location)
+	}
+
+	return insecureReference(rc, ref)
+}
+
+// Insecure returns true if a registry host allows plain HTTP connections.
+func (r *Registries) Insecure(host string) (bool, error) {
+	rc, err := r.load()
+	if err != nil {
+		return false, err
+	}
+
+	return rc.insecure(host), nil
+}
+
+// insecureReference returns a reference that allows plain HTTP if its
+// registry host is insecure. Other references are returned unchanged.
+func insecureReference(rc RegistriesConfig, ref name.Reference) (name.Reference, error) {
+	repo := ref.Context()
+	if !rc.insecure(repo.RegistryStr()) {
+		return ref, nil
+	}
+
+	return rebaseReference(ref, repo.Name(), true)
+}
+
+// rebaseReference returns a reference with the tag or digest of ref in
+// another repository.
+func rebaseReference(ref name.Reference, repository string, insecure bool) (name.Reference, error) {
+	var nameOptions []name.Option
+	if insecure {
+		nameOptions = append(nameOptions, name.Insecure)
+	}
+
+	switch r := ref.(type) {
+	case name.Digest:
+		return name.NewDigest(repository+"@"+r.DigestStr(), nameOptions...)
+	default:
+		return name.NewTag(repository+":"+ref.Identifier(), nameOptions...)
+	}
+}
+
+// normalizeLocation returns the canonical form of a registry location.
+func normalizeLocation(location string) (string, error) {
+	location = strings.TrimSuffix(strings.TrimSpace(location), "/")
+	if location == "" {
+		return "", fmt.Errorf("location is required")
+	}
+
+	parts := strings.SplitN(location, "/", 2)
+	host := normalizeRegistryHost(parts[0])
+	if _, err := name.NewRegistry(host); err != nil {
+		return "", fmt.Errorf("invalid location %q: %w", location, err)
+	}
+
+	if len(parts) == 1 {
+		return host, nil
+	}
+
+	return host + "/" + parts[1], nil
+}
+
+// locationHost returns the registry host of a location.
+func locationHost(location string) string {
+	return normalizeRegistryHost(strings.SplitN(strings.TrimSpace(location), "/", 2)[0])
+}
+
+// repositoryLocation returns a repository as a location.
+func repositoryLocation(repo name.Repository) string {
+	return normalizeRegistryHost(repo.RegistryStr()) + "/" + repo.RepositoryStr()
+}
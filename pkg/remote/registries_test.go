@@ -0,0 +1,256 @@
+/*
+ * Copyright 2020 Sheaf Authors
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package remote
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/stretchr/testify/require"
+)
+
+const testRegistriesConfig = `
+[[registry]]
+location = "docker.io"
+
+[[registry.mirror]]
+location = "mirror.example.com/hub"
+
+[[registry.mirror]]
+location = "lab.example.com:5000"
+insecure = true
+
+[[registry]]
+location = "registry.example.com/team"
+
+[[registry.mirror]]
+location = "mirror.example.com/team"
+
+[[registry]]
+location = "registry.example.com/team/blocked"
+blocked = true
+
+[[registry]]
+location = "lab.example.com:5000"
+insecure = true
+`
+
+func TestRegistries_PullReferences(t *testing.T) {
+	config := writeRegistriesConfig(t, testRegistriesConfig)
+	defer os.RemoveAll(filepath.Dir(config))
+
+	cases := []struct {
+		name       string
+		registries *Registries
+		ref        string
+		want       []string
+		wantScheme []string
+		wantErr    bool
+	}{
+		{
+			name:       "no config",
+			ref:        "registry.example.com/team/app:1.0",
+			want:       []string{"registry.example.com/team/app:1.0"},
+			wantScheme: []string{"https"},
+		},
+		{
+			name:       "unconfigured registry",
+			registries: NewRegistries(config),
+			ref:        "other.example.com/app:1.0",
+			want:       []string{"other.example.com/app:1.0"},
+			wantScheme: []string{"https"},
+		},
+		{
+			name:       "mirrors",
+			registries: NewRegistries(config),
+			ref:        "nginx:1.17",
+			want: []string{
+				"mirror.example.com/hub/library/nginx:1.17",
+				"lab.example.com:5000/library/nginx:1.17",
+				"index.docker.io/library/nginx:1.17",
+			},
+			wantScheme: []string{"https", "http", "https"},
+		},
+		{
+			name:       "namespace mirror",
+			registries: NewRegistries(config),
+			ref:        "registry.example.com/team/app@sha256:0000000000000000000000000000000000000000000000000000000000000000",
+			want: []string{
+				"mirror.example.com/team/app@sha256:0000000000000000000000000000000000000000000000000000000000000000",
+				"registry.example.com/team/app@sha256:0000000000000000000000000000000000000000000000000000000000000000",
+			},
+			wantScheme: []string{"https", "https"},
+		},
+		{
+			name:       "insecure registry",
+			registries: NewRegistries(config),
+			ref:        "lab.example.com:5000/app:1.0",
+			want:       []string{"lab.example.com:5000/app:1.0"},
+			wantScheme: []string{"http"},
+		},
+		{
+			name:       "blocked",
+			registries: NewRegistries(config),
+			ref:        "registry.example.com/team/blocked/app:1.0",
+			wantErr:    true,
+		},
+		{
+			name:       "missing config",
+			registries: NewRegistries(filepath.Join(filepath.Dir(config), "missing.conf")),
+			ref:        "registry.example.com/team/app:1.0",
+			wantErr:    true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			ref, err := name.ParseReference(tc.ref)
+			require.NoError(t, err)
+
+			refs, err := tc.registries.PullReferences(ref)
+			if tc.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+
+			var got, gotScheme []string
+			for _, r := range refs {
+				got = append(got, r.Name())
+				gotScheme = append(gotScheme, r.Context().Registry.Scheme())
+			}
+
+			require.Equal(t, tc.want, got)
+			require.Equal(t, tc.wantScheme, gotScheme)
+		})
+	}
+}
+
+func TestRegistries_PushReference(t *testing.T) {
+	config := writeRegistriesConfig(t, testRegistriesConfig)
+	defer os.RemoveAll(filepath.Dir(config))
+
+	cases := []struct {
+		name       string
+		ref        string
+		wantScheme string
+		wantErr    bool
+	}{
+		{
+			name:       "registry",
+			ref:        "registry.example.com/team/app:1.0",
+			wantScheme: "https",
+		},
+		{
+			name:       "insecure registry",
+			ref:        "lab.example.com:5000/app:1.0",
+			wantScheme: "http",
+		},
+		{
+			name:    "blocked",
+			ref:     "registry.example.com/team/blocked/app:1.0",
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			ref, err := name.ParseReference(tc.ref)
+			require.NoError(t, err)
+
+			got, err := NewRegistries(config).PushReference(ref)
+			if tc.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+
+			require.Equal(t, ref.Name(), got.Name())
+			require.Equal(t, tc.wantScheme, got.Context().Registry.Scheme())
+		})
+	}
+}
+
+func TestReadRegistriesConfig(t *testing.T) {
+	cases := []struct {
+		name    string
+		config  string
+		want    RegistriesConfig
+		wantErr bool
+	}{
+		{
+			name: "valid",
+			config: `
+[[registry]]
+location = "registry.example.com"
+blocked = true
+
+[[registry.mirror]]
+location = "mirror.example.com"
+insecure = true
+`,
+			want: RegistriesConfig{
+				Registries: []RegistryConfig{
+					{
+						Location: "registry.example.com",
+						Blocked:  true,
+						Mirrors:  []MirrorConfig{{Location: "mirror.example.com", Insecure: true}},
+					},
+				},
+			},
+		},
+		{
+			name:    "missing location",
+			config:  "[[registry]]\ninsecure = true\n",
+			wantErr: true,
+		},
+		{
+			name:    "missing mirror location",
+			config:  "[[registry]]\nlocation = \"registry.example.com\"\n[[registry.mirror]]\ninsecure = true\n",
+			wantErr: true,
+		},
+		{
+			name:    "duplicate location",
+			config:  "[[registry]]\nlocation = \"docker.io\"\n[[registry]]\nlocation = \"index.docker.io\"\n",
+			wantErr: true,
+		},
+		{
+			name:    "invalid toml",
+			config:  "[[registry]\n",
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			config := writeRegistriesConfig(t, tc.config)
+			defer os.RemoveAll(filepath.Dir(config))
+
+			got, err := ReadRegistriesConfig(config)
+			if tc.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+
+			require.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func writeRegistriesConfig(t *testing.T, config string) string {
+	dir, err := ioutil.TempDir("", "sheaf-test")
+	require.NoError(t, err)
+
+	path := filepath.Join(dir, "registries.conf")
+	require.NoError(t, ioutil.WriteFile(path, []byte(config), 0600))
+
+	return path
+}
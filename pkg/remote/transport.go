@@ -0,0 +1,134 @@
+/*
+ * Copyright 2020 Sheaf Authors
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package remote
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// CertificateAuthorities are CA certificate files trusted when connecting to
+// registries, in addition to the system CAs.
+type CertificateAuthorities struct {
+	// Global are trusted for every registry.
+	Global []string
+	// Registries are trusted for a registry host, e.g. registry.example.com:5000.
+	Registries map[string][]string
+}
+
+// ParseCertificateAuthorities parses CA flag values. A value is a CA file
+// trusted for every registry, or <host>=<CA file> to trust a CA for one
+// registry.
+func ParseCertificateAuthorities(values []string) (CertificateAuthorities, error) {
+	var cas CertificateAuthorities
+	for _, value := range values {
+		parts := strings.SplitN(value, "=", 2)
+		if len(parts) == 1 {
+			cas.Global = append(cas.Global, value)
+			continue
+		}
+
+		host, path := strings.ToLower(parts[0]), parts[1]
+		if host == "" || path == "" {
+			return CertificateAuthorities{}, fmt.Errorf("invalid registry CA %q (use <file> or <host>=<file>)", value)
+		}
+
+		if cas.Registries == nil {
+			cas.Registries = map[string][]string{}
+		}
+		cas.Registries[host] = append(cas.Registries[host], path)
+	}
+
+	return cas, nil
+}
+
+// IsZero returns true if no CAs are configured.
+func (cas CertificateAuthorities) IsZero() bool {
+	return len(cas.Global) == 0 && len(cas.Registries) == 0
+}
+
+// NewTransport creates a transport that trusts CAs. Requests to a registry
+// with its own CAs trust those CAs as well as the global CAs.
+func NewTransport(cas CertificateAuthorities) (http.RoundTripper, error) {
+	global, err := newTLSTransport(cas.Global)
+	if err != nil {
+		return nil, err
+	}
+
+	t := &registryTransport{
+		global:     global,
+		registries: map[string]http.RoundTripper{},
+	}
+
+	for host, paths := range cas.Registries {
+		rt, err := newTLSTransport(append(append([]string{}, cas.Global...), paths...))
+		if err != nil {
+			return nil, fmt.Errorf("CA for %s: %w", host, err)
+		}
+
+		t.registries[host] = rt
+	}
+
+	return t, nil
+}
+
+// newTLSTransport creates a transport that trusts the system CAs and CA files.
+func newTLSTransport(paths []string) (*http.Transport, error) {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	if len(paths) == 0 {
+		return t, nil
+	}
+
+	pool, err := x509.SystemCertPool()
+	if err != nil {
+		pool = x509.NewCertPool()
+	}
+
+	for _, path := range paths {
+		pem, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("read CA: %w", err)
+		}
+
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in %s", path)
+		}
+	}
+
+	t.TLSClientConfig = &tls.Config{RootCAs: pool}
+	return t, nil
+}
+
+// registryTransport sends requests with the transport for their host.
+type registryTransport struct {
+	global     http.RoundTripper
+	registries map[string]http.RoundTripper
+}
+
+var _ http.RoundTripper = &registryTransport{}
+
+// RoundTrip sends a request.
+func (t *registryTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	host := strings.ToLower(r.URL.Host)
+	if rt, ok := t.registries[host]; ok {
+		return rt.RoundTrip(r)
+	}
+
+	// A CA configured for a host without a port is used for every port.
+	if hostname, _, err := net.SplitHostPort(host); err == nil {
+		if rt, ok := t.registries[hostname]; ok {
+			return rt.RoundTrip(r)
+		}
+	}
+
+	return t.global.RoundTrip(r)
+}
@@ -0,0 +1,153 @@
+/*
+ * Copyright 2020 Sheaf Authors
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package remote
+
+import (
+	"encoding/pem"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCertificateAuthorities(t *testing.T) {
+	cases := []struct {
+		name    string
+		values  []string
+		want    CertificateAuthorities
+		wantErr bool
+	}{
+		{
+			name: "none",
+		},
+		{
+			name:   "global and per registry",
+			values: []string{"ca.pem", "Registry.example.com:5000=registry.pem", "registry.example.com:5000=other.pem"},
+			want: CertificateAuthorities{
+				Global: []string{"ca.pem"},
+				Registries: map[string][]string{
+					"registry.example.com:5000": {"registry.pem", "other.pem"},
+				},
+			},
+		},
+		{
+			name:    "missing host",
+			values:  []string{"=ca.pem"},
+			wantErr: true,
+		},
+		{
+			name:    "missing file",
+			values:  []string{"registry.example.com="},
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := ParseCertificateAuthorities(tc.values)
+			if tc.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+
+			require.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestNewTransport(t *testing.T) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer ts.Close()
+
+	u, err := url.Parse(ts.URL)
+	require.NoError(t, err)
+
+	dir, err := ioutil.TempDir("", "sheaf-test")
+	require.NoError(t, err)
+
+	defer func() {
+		require.NoError(t, os.RemoveAll(dir))
+	}()
+
+	ca := filepath.Join(dir, "ca.pem")
+	require.NoError(t, ioutil.WriteFile(ca, pem.EncodeToMemory(&pem.Block{
+		Type:  "CERTIFICATE",
+		Bytes: ts.Certificate().Raw,
+	}), 0600))
+
+	invalidCA := filepath.Join(dir, "invalid.pem")
+	require.NoError(t, ioutil.WriteFile(invalidCA, []byte("invalid"), 0600))
+
+	cases := []struct {
+		name       string
+		cas        CertificateAuthorities
+		wantErr    bool
+		wantReqErr bool
+	}{
+		{
+			name:       "no CAs",
+			wantReqErr: true,
+		},
+		{
+			name: "global CA",
+			cas:  CertificateAuthorities{Global: []string{ca}},
+		},
+		{
+			name: "registry CA",
+			cas:  CertificateAuthorities{Registries: map[string][]string{u.Host: {ca}}},
+		},
+		{
+			name: "registry CA without port",
+			cas:  CertificateAuthorities{Registries: map[string][]string{u.Hostname(): {ca}}},
+		},
+		{
+			name:       "CA for other registry",
+			cas:        CertificateAuthorities{Registries: map[string][]string{"registry.example.com": {ca}}},
+			wantReqErr: true,
+		},
+		{
+			name:    "missing CA file",
+			cas:     CertificateAuthorities{Global: []string{filepath.Join(dir, "missing.pem")}},
+			wantErr: true,
+		},
+		{
+			name:    "invalid CA file",
+			cas:     CertificateAuthorities{Registries: map[string][]string{u.Host: {invalidCA}}},
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			transport, err := NewTransport(tc.cas)
+			if tc.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+
+			client := &http.Client{Transport: transport}
+			resp, err := client.Get(ts.URL)
+			if tc.wantReqErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.NoError(t, resp.Body.Close())
+
+			require.Equal(t, http.StatusOK, resp.StatusCode)
+		})
+	}
+}
@@ -0,0 +1,106 @@
+/*
+ * Copyright 2020 Sheaf Authors
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package sheaf
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/bryanl/sheaf/internal/goutil"
+)
+
+// ArchiveApplyDelta applies a delta archive to its base archive and writes
+// the complete archive to a destination directory. The archive keeps the
+// delta's signature, which is verified first if a signature verifier is
+// configured. It also keeps delta.json: the signature covers it and leaves
+// out the blobs it lists, so the signature would no longer verify without
+// it. Once the blobs are added, delta.json doesn't require the base archive.
+func ArchiveApplyDelta(optionList ...Option) error {
+	opts := makeDefaultOptions(optionList...)
+
+	if opts.base == "" {
+		return fmt.Errorf("base archive is required")
+	}
+
+	opts.reporter.Headerf("Applying delta archive %s to %s", opts.archive, opts.base)
+
+	return withExplodedArchive(opts, func(b Bundle) error {
+		if opts.signatureVerifier != nil {
+			opts.reporter.Report("Verifying archive signature")
+			if err := opts.signatureVerifier.VerifySignature(b); err != nil {
+				return fmt.Errorf("verify archive signature: %w", err)
+			}
+		}
+
+		if err := applyDelta(opts, b); err != nil {
+			return err
+		}
+
+		config := b.Config()
+		filename := fmt.Sprintf("%s-%s%s", config.GetName(), config.GetVersion(), opts.archiver.Extension())
+		dest := filepath.Join(opts.destination, filename)
+
+		if _, err := os.Stat(dest); err == nil {
+			if !opts.force {
+				return fmt.Errorf("destination %s exists", dest)
+			}
+		} else if !os.IsNotExist(err) {
+			return err
+		}
+
+		opts.reporter.Headerf("Creating archive: %s", dest)
+
+		f, err := os.Create(dest)
+		if err != nil {
+			return fmt.Errorf("create archive file: %w", err)
+		}
+
+		if err := opts.archiver.Archive(b.Path(), f); err != nil {
+			goutil.Close(f)
+			return fmt.Errorf("create archive: %w", err)
+		}
+
+		if err := f.Close(); err != nil {
+			return fmt.Errorf("close archive: %w", err)
+		}
+
+		return nil
+	})
+}
+
+// applyDelta adds the blobs a delta archive takes from its base archive if a
+// base archive is configured. Without one, it fails if the archive is a delta
+// archive that still needs its base.
+func applyDelta(opts options, b Bundle) error {
+	if opts.deltaApplier == nil {
+		if opts.base != "" {
+			return fmt.Errorf("delta applier is not configured")
+		}
+		return nil
+	}
+
+	if opts.base == "" {
+		base, err := opts.deltaApplier.RequiredBase(b)
+		if err != nil {
+			return fmt.Errorf("read delta: %w", err)
+		}
+
+		if base != "" {
+			return fmt.Errorf("%s is a delta archive and requires its base archive %s", opts.archive, base)
+		}
+
+		return nil
+	}
+
+	opts.reporter.Reportf("Adding blobs from base archive %s", opts.base)
+	if err := opts.deltaApplier.ApplyDelta(b, opts.base); err != nil {
+		return fmt.Errorf("apply delta archive: %w", err)
+	}
+
+	return nil
+}
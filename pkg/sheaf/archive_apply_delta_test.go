@@ -0,0 +1,195 @@
+/*
+ * Copyright 2020 Sheaf Authors
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package sheaf_test
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bryanl/sheaf/internal/testutil"
+	"github.com/bryanl/sheaf/pkg/mocks"
+	"github.com/bryanl/sheaf/pkg/reporter"
+	"github.com/bryanl/sheaf/pkg/sheaf"
+)
+
+func TestArchiveApplyDelta(t *testing.T) {
+	genArchiver := func(controller *gomock.Controller) *mocks.MockArchiver {
+		a := mocks.NewMockArchiver(controller)
+		a.EXPECT().UnarchivePath("delta.tgz", gomock.Any()).Return(nil)
+		a.EXPECT().Extension().Return(".tgz").AnyTimes()
+		a.EXPECT().Archive("/bundle", gomock.Any()).
+			DoAndReturn(func(src string, w io.Writer) error {
+				_, err := w.Write([]byte("archive"))
+				return err
+			}).AnyTimes()
+		return a
+	}
+
+	genDeltaApplier := func(err error) func(controller *gomock.Controller) sheaf.DeltaApplier {
+		return func(controller *gomock.Controller) sheaf.DeltaApplier {
+			da := mocks.NewMockDeltaApplier(controller)
+			da.EXPECT().ApplyDelta(gomock.Any(), "base.tgz").Return(err)
+			return da
+		}
+	}
+
+	tests := []struct {
+		name         string
+		base         string
+		existing     bool
+		force        bool
+		archiver     func(controller *gomock.Controller) *mocks.MockArchiver
+		deltaApplier func(controller *gomock.Controller) sheaf.DeltaApplier
+		signature    func(controller *gomock.Controller) sheaf.SignatureVerifier
+		wantErr      string
+	}{
+		{
+			name:         "in general",
+			base:         "base.tgz",
+			archiver:     genArchiver,
+			deltaApplier: genDeltaApplier(nil),
+		},
+		{
+			name:         "valid signature",
+			base:         "base.tgz",
+			archiver:     genArchiver,
+			deltaApplier: genDeltaApplier(nil),
+			signature: func(controller *gomock.Controller) sheaf.SignatureVerifier {
+				sv := mocks.NewMockSignatureVerifier(controller)
+				sv.EXPECT().VerifySignature(gomock.Any()).Return(nil)
+				return sv
+			},
+		},
+		{
+			name:     "invalid signature",
+			base:     "base.tgz",
+			archiver: genArchiver,
+			deltaApplier: func(controller *gomock.Controller) sheaf.DeltaApplier {
+				return mocks.NewMockDeltaApplier(controller)
+			},
+			signature: func(controller *gomock.Controller) sheaf.SignatureVerifier {
+				sv := mocks.NewMockSignatureVerifier(controller)
+				sv.EXPECT().VerifySignature(gomock.Any()).Return(fmt.Errorf("archive is not signed"))
+				return sv
+			},
+			wantErr: "verify archive signature",
+		},
+		{
+			name:         "mismatched base",
+			base:         "base.tgz",
+			archiver:     genArchiver,
+			deltaApplier: genDeltaApplier(fmt.Errorf("base.tgz is not the base archive of the delta")),
+			wantErr:      "is not the base archive",
+		},
+		{
+			name:         "destination exists",
+			base:         "base.tgz",
+			existing:     true,
+			archiver:     genArchiver,
+			deltaApplier: genDeltaApplier(nil),
+			wantErr:      "exists",
+		},
+		{
+			name:         "overwrite destination",
+			base:         "base.tgz",
+			existing:     true,
+			force:        true,
+			archiver:     genArchiver,
+			deltaApplier: genDeltaApplier(nil),
+		},
+		{
+			name: "no base",
+			archiver: func(controller *gomock.Controller) *mocks.MockArchiver {
+				return mocks.NewMockArchiver(controller)
+			},
+			deltaApplier: func(controller *gomock.Controller) sheaf.DeltaApplier {
+				return mocks.NewMockDeltaApplier(controller)
+			},
+			wantErr: "base archive is required",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			controller := gomock.NewController(t)
+			defer controller.Finish()
+
+			dest, err := ioutil.TempDir("", "sheaf-test")
+			require.NoError(t, err)
+			defer func() {
+				require.NoError(t, os.RemoveAll(dest))
+			}()
+
+			archive := filepath.Join(dest, "project-0.1.0.tgz")
+			if test.existing {
+				require.NoError(t, ioutil.WriteFile(archive, []byte("existing"), 0600))
+			}
+
+			bundle := testutil.GenerateBundle(t, controller)
+			bundle.EXPECT().Path().Return("/bundle").AnyTimes()
+
+			options := []sheaf.Option{
+				sheaf.WithArchive("delta.tgz"),
+				sheaf.WithBase(test.base),
+				sheaf.WithDestination(dest),
+				sheaf.WithForce(test.force),
+				sheaf.WithArchiver(test.archiver(controller)),
+				sheaf.WithDeltaApplier(test.deltaApplier(controller)),
+				sheaf.WithBundleFactory(func(string) (sheaf.Bundle, error) {
+					return bundle, nil
+				}),
+				sheaf.WithReporter(reporter.Nop{}),
+			}
+			if test.signature != nil {
+				options = append(options, sheaf.WithSignatureVerifier(test.signature(controller)))
+			}
+
+			err = sheaf.ArchiveApplyDelta(options...)
+			if test.wantErr != "" {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), test.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+
+			data, err := ioutil.ReadFile(archive)
+			require.NoError(t, err)
+			require.Equal(t, "archive", string(data))
+		})
+	}
+}
+
+func TestArchiveRelocate_delta(t *testing.T) {
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	a := mocks.NewMockArchiver(controller)
+	a.EXPECT().UnarchivePath("delta.tgz", gomock.Any()).Return(nil)
+
+	bundle := testutil.GenerateBundle(t, controller)
+
+	da := mocks.NewMockDeltaApplier(controller)
+	da.EXPECT().RequiredBase(bundle).Return("base.tgz", nil)
+
+	err := sheaf.ArchiveRelocate(
+		sheaf.WithArchive("delta.tgz"),
+		sheaf.WithArchiver(a),
+		sheaf.WithDeltaApplier(da),
+		sheaf.WithBundleFactory(func(string) (sheaf.Bundle, error) {
+			return bundle, nil
+		}),
+		sheaf.WithReporter(reporter.Nop{}))
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "delta.tgz is a delta archive and requires its base archive base.tgz")
+}
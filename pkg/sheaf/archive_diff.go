@@ -0,0 +1,414 @@
+/*
+ * Copyright 2020 Sheaf Authors
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package sheaf
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+)
+
+//go:generate mockgen -destination=../mocks/mock_manifest_differ.go -package mocks github.com/bryanl/sheaf/pkg/sheaf ManifestDiffer
+
+const (
+	// TextOutput is the plain text output format.
+	TextOutput = "text"
+	// JSONOutput is the JSON output format.
+	JSONOutput = "json"
+)
+
+// OutputFormats are the supported output formats.
+var OutputFormats = []string{TextOutput, JSONOutput}
+
+const (
+	// Added is the change for an item that is only in the new archive.
+	Added = "added"
+	// Removed is the change for an item that is only in the old archive.
+	Removed = "removed"
+	// Changed is the change for an item that is in both archives but differs.
+	Changed = "changed"
+)
+
+// ArchiveDifference is the difference between two archives.
+type ArchiveDifference struct {
+	// Images are the differences in images.
+	Images []ImageDifference `json:"images"`
+	// Config are the differences in the bundle config.
+	Config []ConfigDifference `json:"config"`
+	// Manifests are the differences in manifest resources.
+	Manifests []ResourceDifference `json:"manifests"`
+}
+
+// Empty returns true if the archives have no differences.
+func (d ArchiveDifference) Empty() bool {
+	return len(d.Images) == 0 && len(d.Config) == 0 && len(d.Manifests) == 0
+}
+
+// ImageDifference is a difference in an image.
+type ImageDifference struct {
+	// Change is the change.
+	Change string `json:"change"`
+	// Name is the image name.
+	Name string `json:"name"`
+	// OldDigest is the digest in the old archive.
+	OldDigest string `json:"oldDigest,omitempty"`
+	// NewDigest is the digest in the new archive.
+	NewDigest string `json:"newDigest,omitempty"`
+}
+
+// ConfigDifference is a difference in a bundle config field. List fields have
+// a difference for each added or removed item.
+type ConfigDifference struct {
+	// Change is the change.
+	Change string `json:"change"`
+	// Field is the bundle config field.
+	Field string `json:"field"`
+	// Old is the value in the old archive. It is nil if the value was added.
+	Old interface{} `json:"old,omitempty"`
+	// New is the value in the new archive. It is nil if the value was removed.
+	New interface{} `json:"new,omitempty"`
+}
+
+// ResourceDifference is a difference in a manifest resource. Resources are
+// identified by API version, kind, namespace, and name.
+type ResourceDifference struct {
+	// Change is the change.
+	Change string `json:"change"`
+	// APIVersion is the API version of the resource.
+	APIVersion string `json:"apiVersion"`
+	// Kind is the kind of the resource.
+	Kind string `json:"kind"`
+	// Namespace is the namespace of the resource.
+	Namespace string `json:"namespace,omitempty"`
+	// Name is the name of the resource.
+	Name string `json:"name"`
+	// Fields are the changed fields of a changed resource.
+	Fields []FieldDifference `json:"fields,omitempty"`
+}
+
+// ID returns a description of the resource.
+func (d ResourceDifference) ID() string {
+	name := d.Name
+	if d.Namespace != "" {
+		name = d.Namespace + "/" + name
+	}
+
+	return fmt.Sprintf("%s %s %s", d.APIVersion, d.Kind, name)
+}
+
+// FieldDifference is a difference in a resource field.
+type FieldDifference struct {
+	// Path is the path of the field, e.g. spec.template.spec.containers[0].image.
+	Path string `json:"path"`
+	// Old is the value in the old archive. It is nil if the field was added.
+	Old interface{} `json:"old,omitempty"`
+	// New is the value in the new archive. It is nil if the field was removed.
+	New interface{} `json:"new,omitempty"`
+}
+
+// ManifestDiffer compares manifests.
+type ManifestDiffer interface {
+	// Diff returns the differences between the resources in two lists of
+	// manifests.
+	Diff(oldManifests, newManifests []BundleManifest) ([]ResourceDifference, error)
+}
+
+// ArchiveDiff reports the differences in images, bundle config, and manifest
+// resources between an old and a new archive. The archives are streamed, so
+// their image layers are never extracted.
+func ArchiveDiff(optionList ...Option) error {
+	opts := makeDefaultOptions(optionList...)
+
+	if opts.manifestDiffer == nil {
+		return fmt.Errorf("manifest differ is not configured")
+	}
+
+	if opts.newArchive == "" {
+		return fmt.Errorf("new archive path is required")
+	}
+
+	switch opts.outputFormat {
+	case "", TextOutput, JSONOutput:
+	default:
+		return fmt.Errorf("unsupported output format %q", opts.outputFormat)
+	}
+
+	newOpts := opts
+	newOpts.archive = opts.newArchive
+
+	return withArchiveMetadata(opts, func(oldBundle Bundle) error {
+		return withArchiveMetadata(newOpts, func(newBundle Bundle) error {
+			diff, err := diffBundles(opts, oldBundle, newBundle)
+			if err != nil {
+				return err
+			}
+
+			if opts.outputFormat == JSONOutput {
+				data, err := json.MarshalIndent(diff, "", "  ")
+				if err != nil {
+					return fmt.Errorf("encode archive difference: %w", err)
+				}
+
+				_, err = fmt.Fprintln(opts.writer, string(data))
+				return err
+			}
+
+			return writeArchiveDifference(opts.writer, diff)
+		})
+	})
+}
+
+func diffBundles(opts options, oldBundle, newBundle Bundle) (ArchiveDifference, error) {
+	oldImages, err := oldBundle.Artifacts().Image().List()
+	if err != nil {
+		return ArchiveDifference{}, fmt.Errorf("list images in %s: %w", opts.archive, err)
+	}
+
+	newImages, err := newBundle.Artifacts().Image().List()
+	if err != nil {
+		return ArchiveDifference{}, fmt.Errorf("list images in %s: %w", opts.newArchive, err)
+	}
+
+	oldManifests, err := bundleManifests(oldBundle)
+	if err != nil {
+		return ArchiveDifference{}, fmt.Errorf("list manifests in %s: %w", opts.archive, err)
+	}
+
+	newManifests, err := bundleManifests(newBundle)
+	if err != nil {
+		return ArchiveDifference{}, fmt.Errorf("list manifests in %s: %w", opts.newArchive, err)
+	}
+
+	manifests, err := opts.manifestDiffer.Diff(oldManifests, newManifests)
+	if err != nil {
+		return ArchiveDifference{}, fmt.Errorf("compare manifests: %w", err)
+	}
+
+	return ArchiveDifference{
+		Images:    diffImages(oldImages, newImages),
+		Config:    diffConfig(oldBundle.Config(), newBundle.Config()),
+		Manifests: manifests,
+	}, nil
+}
+
+func bundleManifests(b Bundle) ([]BundleManifest, error) {
+	ms, err := b.Manifests()
+	if err != nil {
+		return nil, err
+	}
+
+	return ms.List()
+}
+
+// diffImages compares images by name and digest.
+func diffImages(oldImages, newImages []BundleImage) []ImageDifference {
+	oldDigests := map[string]string{}
+	for _, image := range oldImages {
+		oldDigests[image.Name] = image.Digest
+	}
+
+	newDigests := map[string]string{}
+	for _, image := range newImages {
+		newDigests[image.Name] = image.Digest
+	}
+
+	list := []ImageDifference{}
+	for name, oldDigest := range oldDigests {
+		newDigest, ok := newDigests[name]
+		switch {
+		case !ok:
+			list = append(list, ImageDifference{Change: Removed, Name: name, OldDigest: oldDigest})
+		case newDigest != oldDigest:
+			list = append(list, ImageDifference{Change: Changed, Name: name, OldDigest: oldDigest, NewDigest: newDigest})
+		}
+	}
+
+	for name, newDigest := range newDigests {
+		if _, ok := oldDigests[name]; !ok {
+			list = append(list, ImageDifference{Change: Added, Name: name, NewDigest: newDigest})
+		}
+	}
+
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Name < list[j].Name
+	})
+
+	return list
+}
+
+// diffConfig compares bundle configs.
+func diffConfig(oldConfig, newConfig BundleConfig) []ConfigDifference {
+	list := []ConfigDifference{}
+
+	diffValue := func(field string, oldValue, newValue interface{}) {
+		if !reflect.DeepEqual(oldValue, newValue) {
+			list = append(list, ConfigDifference{Change: Changed, Field: field, Old: oldValue, New: newValue})
+		}
+	}
+
+	// List items are compared by their JSON encoding.
+	diffList := func(field string, oldValues, newValues interface{}) {
+		oldItems, newItems := configItems(oldValues), configItems(newValues)
+
+		var keys []string
+		for key := range oldItems {
+			if _, ok := newItems[key]; !ok {
+				keys = append(keys, key)
+			}
+		}
+		for key := range newItems {
+			if _, ok := oldItems[key]; !ok {
+				keys = append(keys, key)
+			}
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			if item, ok := oldItems[key]; ok {
+				list = append(list, ConfigDifference{Change: Removed, Field: field, Old: item})
+			} else {
+				list = append(list, ConfigDifference{Change: Added, Field: field, New: newItems[key]})
+			}
+		}
+	}
+
+	var oldStrategy, newStrategy interface{}
+	if ms := oldConfig.GetMappingStrategy(); ms != (MappingStrategy{}) {
+		oldStrategy = ms
+	}
+	if ms := newConfig.GetMappingStrategy(); ms != (MappingStrategy{}) {
+		newStrategy = ms
+	}
+
+	diffValue("name", oldConfig.GetName(), newConfig.GetName())
+	diffValue("version", oldConfig.GetVersion(), newConfig.GetVersion())
+	diffValue("mappingStrategy", oldStrategy, newStrategy)
+	diffList("images", oldConfig.GetImages(), newConfig.GetImages())
+	diffList("imageExclusions", oldConfig.GetImageExclusions(), newConfig.GetImageExclusions())
+	diffList("userDefinedImages", oldConfig.GetUserDefinedImages(), newConfig.GetUserDefinedImages())
+	diffList("catalog.disabled", oldConfig.GetCatalog().Disabled, newConfig.GetCatalog().Disabled)
+
+	return list
+}
+
+// configItems returns the items of a bundle config list keyed by their JSON
+// encoding.
+func configItems(values interface{}) map[string]interface{} {
+	items := map[string]interface{}{}
+
+	v := reflect.ValueOf(values)
+	for i := 0; i < v.Len(); i++ {
+		item := v.Index(i).Interface()
+
+		data, err := json.Marshal(item)
+		if err != nil {
+			data = []byte(fmt.Sprintf("%v", item))
+		}
+
+		items[string(data)] = item
+	}
+
+	return items
+}
+
+// writeArchiveDifference writes an archive difference as text.
+func writeArchiveDifference(w io.Writer, diff ArchiveDifference) error {
+	ew := &errWriter{w: w}
+
+	if diff.Empty() {
+		ew.printf("No differences\n")
+		return ew.err
+	}
+
+	if len(diff.Images) > 0 {
+		ew.printf("Images:\n")
+		for _, d := range diff.Images {
+			switch d.Change {
+			case Added:
+				ew.printf("  + %s (%s)\n", d.Name, d.NewDigest)
+			case Removed:
+				ew.printf("  - %s (%s)\n", d.Name, d.OldDigest)
+			default:
+				ew.printf("  ~ %s (%s -> %s)\n", d.Name, d.OldDigest, d.NewDigest)
+			}
+		}
+	}
+
+	if len(diff.Config) > 0 {
+		ew.printf("Bundle config:\n")
+		for _, d := range diff.Config {
+			switch d.Change {
+			case Added:
+				ew.printf("  + %s: %s\n", d.Field, encodeConfigValue(d.New))
+			case Removed:
+				ew.printf("  - %s: %s\n", d.Field, encodeConfigValue(d.Old))
+			default:
+				ew.printf("  ~ %s: %s -> %s\n", d.Field, encodeConfigValue(d.Old), encodeConfigValue(d.New))
+			}
+		}
+	}
+
+	if len(diff.Manifests) > 0 {
+		ew.printf("Manifests:\n")
+		for _, d := range diff.Manifests {
+			switch d.Change {
+			case Added:
+				ew.printf("  + %s\n", d.ID())
+			case Removed:
+				ew.printf("  - %s\n", d.ID())
+			default:
+				ew.printf("  ~ %s\n", d.ID())
+				for _, f := range d.Fields {
+					ew.printf("      %s: %s -> %s\n", f.Path, encodeFieldValue(f.Old), encodeFieldValue(f.New))
+				}
+			}
+		}
+	}
+
+	return ew.err
+}
+
+// encodeConfigValue encodes a bundle config value for text output. Strings
+// are shown as they are, and other values as compact JSON.
+func encodeConfigValue(v interface{}) string {
+	if s, ok := v.(string); ok {
+		return s
+	}
+
+	return encodeFieldValue(v)
+}
+
+// encodeFieldValue encodes a resource field value as compact JSON. A missing
+// value is shown as <none>.
+func encodeFieldValue(v interface{}) string {
+	if v == nil {
+		return "<none>"
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("%v", v)
+	}
+
+	return string(data)
+}
+
+// errWriter writes formatted text until a write fails.
+type errWriter struct {
+	w   io.Writer
+	err error
+}
+
+func (ew *errWriter) printf(format string, args ...interface{}) {
+	if ew.err != nil {
+		return
+	}
+
+	_, ew.err = fmt.Fprintf(ew.w, format, args...)
+}
@@ -0,0 +1,250 @@
+/*
+ * Copyright 2020 Sheaf Authors
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package sheaf_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bryanl/sheaf/internal/testutil"
+	"github.com/bryanl/sheaf/pkg/mocks"
+	"github.com/bryanl/sheaf/pkg/sheaf"
+)
+
+func TestArchiveDiff(t *testing.T) {
+	genConfig := func(controller *gomock.Controller, version string, imageNames []string) *mocks.MockBundleConfig {
+		bc := mocks.NewMockBundleConfig(controller)
+		bc.EXPECT().GetName().Return("project").AnyTimes()
+		bc.EXPECT().GetVersion().Return(version).AnyTimes()
+		bc.EXPECT().GetMappingStrategy().Return(sheaf.MappingStrategy{}).AnyTimes()
+		bc.EXPECT().GetImages().Return(imageNames).AnyTimes()
+		bc.EXPECT().GetImageExclusions().Return(nil).AnyTimes()
+		bc.EXPECT().GetUserDefinedImages().Return(nil).AnyTimes()
+		bc.EXPECT().GetCatalog().Return(sheaf.CatalogSelection{}).AnyTimes()
+		return bc
+	}
+
+	genBundle := func(controller *gomock.Controller, version string, bundleImages []sheaf.BundleImage) sheaf.Bundle {
+		var imageNames []string
+		for _, image := range bundleImages {
+			imageNames = append(imageNames, image.Name)
+		}
+
+		is := mocks.NewMockImageService(controller)
+		is.EXPECT().List().Return(bundleImages, nil)
+
+		as := mocks.NewMockArtifactsService(controller)
+		as.EXPECT().Image().Return(is)
+
+		bundle := testutil.GenerateBundle(t, controller,
+			testutil.BundleGeneratorConfig(genConfig(controller, version, imageNames)))
+		bundle.EXPECT().Artifacts().Return(as)
+
+		return bundle
+	}
+
+	genBundleFactory := func(controller *gomock.Controller) sheaf.BundleFactoryFunc {
+		bundles := []sheaf.Bundle{
+			genBundle(controller, "0.1.0", []sheaf.BundleImage{
+				{Name: "nginx:1.17", Digest: "sha256:1"},
+				{Name: "redis:5", Digest: "sha256:2"},
+			}),
+			genBundle(controller, "0.2.0", []sheaf.BundleImage{
+				{Name: "nginx:1.17", Digest: "sha256:3"},
+				{Name: "postgres:12", Digest: "sha256:4"},
+			}),
+		}
+
+		return func(string) (sheaf.Bundle, error) {
+			b := bundles[0]
+			bundles = bundles[1:]
+			return b, nil
+		}
+	}
+
+	genArchiver := func(controller *gomock.Controller) *mocks.MockArchiver {
+		a := mocks.NewMockArchiver(controller)
+		gomock.InOrder(
+			a.EXPECT().UnarchiveMetadataPath("old.tgz", gomock.Any()).Return(nil),
+			a.EXPECT().UnarchiveMetadataPath("new.tgz", gomock.Any()).Return(nil),
+		)
+		return a
+	}
+
+	resources := []sheaf.ResourceDifference{
+		{
+			Change:     sheaf.Changed,
+			APIVersion: "apps/v1",
+			Kind:       "Deployment",
+			Namespace:  "default",
+			Name:       "web",
+			Fields: []sheaf.FieldDifference{
+				{Path: "spec.replicas", Old: 1, New: 2},
+			},
+		},
+		{
+			Change:     sheaf.Added,
+			APIVersion: "v1",
+			Kind:       "Service",
+			Name:       "web",
+		},
+	}
+
+	genManifestDiffer := func(controller *gomock.Controller) *mocks.MockManifestDiffer {
+		md := mocks.NewMockManifestDiffer(controller)
+		md.EXPECT().Diff(gomock.Any(), gomock.Any()).Return(resources, nil)
+		return md
+	}
+
+	tests := []struct {
+		name           string
+		newArchive     string
+		outputFormat   string
+		archiver       func(controller *gomock.Controller) *mocks.MockArchiver
+		bundleFactory  bundleFactoryFunc
+		manifestDiffer func(controller *gomock.Controller) *mocks.MockManifestDiffer
+		wantErr        bool
+		wantOutput     string
+		wantDifference *sheaf.ArchiveDifference
+	}{
+		{
+			name:           "text output",
+			newArchive:     "new.tgz",
+			archiver:       genArchiver,
+			bundleFactory:  genBundleFactory,
+			manifestDiffer: genManifestDiffer,
+			wantOutput: `Images:
+  ~ nginx:1.17 (sha256:1 -> sha256:3)
+  + postgres:12 (sha256:4)
+  - redis:5 (sha256:2)
+Bundle config:
+  ~ version: 0.1.0 -> 0.2.0
+  + images: postgres:12
+  - images: redis:5
+Manifests:
+  ~ apps/v1 Deployment default/web
+      spec.replicas: 1 -> 2
+  + v1 Service web
+`,
+		},
+		{
+			name:           "json output",
+			newArchive:     "new.tgz",
+			outputFormat:   sheaf.JSONOutput,
+			archiver:       genArchiver,
+			bundleFactory:  genBundleFactory,
+			manifestDiffer: genManifestDiffer,
+			wantDifference: &sheaf.ArchiveDifference{
+				Images: []sheaf.ImageDifference{
+					{Change: sheaf.Changed, Name: "nginx:1.17", OldDigest: "sha256:1", NewDigest: "sha256:3"},
+					{Change: sheaf.Added, Name: "postgres:12", NewDigest: "sha256:4"},
+					{Change: sheaf.Removed, Name: "redis:5", OldDigest: "sha256:2"},
+				},
+				Config: []sheaf.ConfigDifference{
+					{Change: sheaf.Changed, Field: "version", Old: "0.1.0", New: "0.2.0"},
+					{Change: sheaf.Added, Field: "images", New: "postgres:12"},
+					{Change: sheaf.Removed, Field: "images", Old: "redis:5"},
+				},
+				Manifests: []sheaf.ResourceDifference{
+					{
+						Change:     sheaf.Changed,
+						APIVersion: "apps/v1",
+						Kind:       "Deployment",
+						Namespace:  "default",
+						Name:       "web",
+						Fields: []sheaf.FieldDifference{
+							{Path: "spec.replicas", Old: float64(1), New: float64(2)},
+						},
+					},
+					{Change: sheaf.Added, APIVersion: "v1", Kind: "Service", Name: "web"},
+				},
+			},
+		},
+		{
+			name:         "invalid output format",
+			newArchive:   "new.tgz",
+			outputFormat: "yaml",
+			manifestDiffer: func(controller *gomock.Controller) *mocks.MockManifestDiffer {
+				return mocks.NewMockManifestDiffer(controller)
+			},
+			wantErr: true,
+		},
+		{
+			name: "new archive is missing",
+			manifestDiffer: func(controller *gomock.Controller) *mocks.MockManifestDiffer {
+				return mocks.NewMockManifestDiffer(controller)
+			},
+			wantErr: true,
+		},
+		{
+			name:       "manifest differ is not configured",
+			newArchive: "new.tgz",
+			wantErr:    true,
+		},
+		{
+			name:          "manifest differ failed",
+			newArchive:    "new.tgz",
+			archiver:      genArchiver,
+			bundleFactory: genBundleFactory,
+			manifestDiffer: func(controller *gomock.Controller) *mocks.MockManifestDiffer {
+				md := mocks.NewMockManifestDiffer(controller)
+				md.EXPECT().Diff(gomock.Any(), gomock.Any()).Return(nil, fmt.Errorf("error"))
+				return md
+			},
+			wantErr: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			controller := gomock.NewController(t)
+			defer controller.Finish()
+
+			var buf bytes.Buffer
+
+			options := []sheaf.Option{
+				sheaf.WithArchive("old.tgz"),
+				sheaf.WithNewArchive(test.newArchive),
+				sheaf.WithOutputFormat(test.outputFormat),
+				sheaf.WithWriter(&buf),
+			}
+
+			if test.archiver != nil {
+				options = append(options, sheaf.WithArchiver(test.archiver(controller)))
+			}
+
+			if test.bundleFactory != nil {
+				options = append(options, sheaf.WithBundleFactory(test.bundleFactory(controller)))
+			}
+
+			if test.manifestDiffer != nil {
+				options = append(options, sheaf.WithManifestDiffer(test.manifestDiffer(controller)))
+			}
+
+			err := sheaf.ArchiveDiff(options...)
+			if test.wantErr {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+
+			if test.wantDifference != nil {
+				var got sheaf.ArchiveDifference
+				require.NoError(t, json.Unmarshal(buf.Bytes(), &got))
+				require.Equal(t, *test.wantDifference, got)
+				return
+			}
+
+			require.Equal(t, test.wantOutput, buf.String())
+		})
+	}
+}
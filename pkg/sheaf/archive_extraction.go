@@ -0,0 +1,159 @@
+/*
+ * Copyright 2020 Sheaf Authors
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package sheaf
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+)
+
+// extractionCompleteSuffix is the suffix of the file that marks a kept
+// extraction as complete. It is next to the extraction rather than in it, so
+// it is not part of the archive contents.
+const extractionCompleteSuffix = ".complete"
+
+// withExplodedArchive runs a function with the bundle in an archive. The
+// archive is unarchived to a temporary directory in the work directory, or
+// to a kept extraction that is reused by later commands.
+func withExplodedArchive(opts options, f func(b Bundle) error) error {
+	if opts.archive == "" {
+		return fmt.Errorf("archive path is required")
+	}
+
+	if opts.keepExtracted {
+		b, err := keptExtraction(opts)
+		if err != nil {
+			return fmt.Errorf("explode archive %s: %w", opts.archive, err)
+		}
+
+		return f(b)
+	}
+
+	return withTemporaryExtraction(opts, opts.archiver.UnarchivePath, f)
+}
+
+// withArchiveMetadata runs a function with a bundle that has the config,
+// lock, and manifests of an archive, and the index, manifests, and configs
+// of its images, but not their layers. The archive is streamed, so only
+// this metadata is written to the work directory. A kept extraction of the
+// archive is used if there is one.
+func withArchiveMetadata(opts options, f func(b Bundle) error) error {
+	if opts.archive == "" {
+		return fmt.Errorf("archive path is required")
+	}
+
+	if opts.keepExtracted {
+		dir, err := extractionPath(opts)
+		if err != nil {
+			return err
+		}
+
+		if extractionComplete(dir) {
+			b, err := opts.bundleFactory(dir)
+			if err != nil {
+				return fmt.Errorf("load bundle: %w", err)
+			}
+
+			return f(b)
+		}
+	}
+
+	return withTemporaryExtraction(opts, opts.archiver.UnarchiveMetadataPath, f)
+}
+
+// withTemporaryExtraction unarchives an archive to a temporary directory in
+// the work directory and removes it when the function returns.
+func withTemporaryExtraction(opts options, unarchive func(src, dest string) error, f func(b Bundle) error) error {
+	dir, err := ioutil.TempDir(opts.workdir, "sheaf")
+	if err != nil {
+		return fmt.Errorf("create temporary directory: %w", err)
+	}
+
+	defer func() {
+		if rErr := os.RemoveAll(dir); rErr != nil {
+			log.Printf("remove temporary directory: %v", rErr)
+		}
+	}()
+
+	if err := unarchive(opts.archive, dir); err != nil {
+		return fmt.Errorf("explode archive %s: unable to unarchive %s: %w", opts.archive, opts.archive, err)
+	}
+
+	b, err := opts.bundleFactory(dir)
+	if err != nil {
+		return fmt.Errorf("explode archive %s: load bundle: %w", opts.archive, err)
+	}
+
+	return f(b)
+}
+
+// keptExtraction returns the bundle in the kept extraction of an archive,
+// unarchiving it first if needed. An incomplete extraction is replaced.
+func keptExtraction(opts options) (Bundle, error) {
+	dir, err := extractionPath(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	if !extractionComplete(dir) {
+		if err := os.RemoveAll(dir); err != nil {
+			return nil, fmt.Errorf("remove incomplete extraction: %w", err)
+		}
+
+		if err := os.MkdirAll(dir, 0700); err != nil {
+			return nil, err
+		}
+
+		if err := opts.archiver.UnarchivePath(opts.archive, dir); err != nil {
+			return nil, fmt.Errorf("unable to unarchive %s: %w", opts.archive, err)
+		}
+
+		if err := ioutil.WriteFile(dir+extractionCompleteSuffix, nil, 0600); err != nil {
+			return nil, fmt.Errorf("mark extraction complete: %w", err)
+		}
+	}
+
+	b, err := opts.bundleFactory(dir)
+	if err != nil {
+		return nil, fmt.Errorf("load bundle: %w", err)
+	}
+
+	return b, nil
+}
+
+// extractionPath returns the path of the kept extraction of an archive in
+// the work directory. It depends on the archive's path, size, and
+// modification time, so a changed archive is extracted again.
+func extractionPath(opts options) (string, error) {
+	path, err := filepath.Abs(opts.archive)
+	if err != nil {
+		return "", err
+	}
+
+	fi, err := os.Stat(path)
+	if err != nil {
+		return "", err
+	}
+
+	workdir := opts.workdir
+	if workdir == "" {
+		workdir = os.TempDir()
+	}
+
+	key := sha256.Sum256([]byte(fmt.Sprintf("%s\n%d\n%d", path, fi.Size(), fi.ModTime().UnixNano())))
+	return filepath.Join(workdir, fmt.Sprintf("sheaf-extracted-%x", key[:8])), nil
+}
+
+// extractionComplete returns true if a kept extraction is complete.
+func extractionComplete(dir string) bool {
+	_, err := os.Stat(dir + extractionCompleteSuffix)
+	return err == nil
+}
@@ -0,0 +1,172 @@
+/*
+ * Copyright 2020 Sheaf Authors
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package sheaf_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bryanl/sheaf/internal/testutil"
+	"github.com/bryanl/sheaf/pkg/mocks"
+	"github.com/bryanl/sheaf/pkg/sheaf"
+)
+
+func TestArchiveExtraction(t *testing.T) {
+	setup := func(t *testing.T) (string, string) {
+		workdir, err := ioutil.TempDir("", "sheaf-test")
+		require.NoError(t, err)
+
+		archive := filepath.Join(workdir, "archive.tgz")
+		require.NoError(t, ioutil.WriteFile(archive, []byte("archive"), 0600))
+
+		return workdir, archive
+	}
+
+	genBundleFactory := func(t *testing.T, controller *gomock.Controller, dirs *[]string) sheaf.BundleFactoryFunc {
+		is := mocks.NewMockImageService(controller)
+		is.EXPECT().List().Return(nil, nil).AnyTimes()
+
+		as := mocks.NewMockArtifactsService(controller)
+		as.EXPECT().Image().Return(is).AnyTimes()
+
+		bundle := testutil.GenerateBundle(t, controller)
+		bundle.EXPECT().Artifacts().Return(as).AnyTimes()
+
+		return func(dir string) (sheaf.Bundle, error) {
+			*dirs = append(*dirs, dir)
+			return bundle, nil
+		}
+	}
+
+	genCodec := func(controller *gomock.Controller) sheaf.Codec {
+		codec := mocks.NewMockCodec(controller)
+		codec.EXPECT().Encode(gomock.Any()).Return([]byte("[]"), nil).AnyTimes()
+		return codec
+	}
+
+	t.Run("metadata is extracted to the work directory and removed", func(t *testing.T) {
+		controller := gomock.NewController(t)
+		defer controller.Finish()
+
+		workdir, archive := setup(t)
+		defer func() {
+			require.NoError(t, os.RemoveAll(workdir))
+		}()
+
+		a := mocks.NewMockArchiver(controller)
+		a.EXPECT().UnarchiveMetadataPath(archive, gomock.Any()).
+			DoAndReturn(func(src, dest string) error {
+				require.True(t, strings.HasPrefix(dest, workdir))
+				return nil
+			})
+
+		var dirs []string
+		require.NoError(t, sheaf.ArchiveListImages(
+			sheaf.WithArchive(archive),
+			sheaf.WithWorkdir(workdir),
+			sheaf.WithArchiver(a),
+			sheaf.WithBundleFactory(genBundleFactory(t, controller, &dirs)),
+			sheaf.WithCodec(genCodec(controller)),
+			sheaf.WithWriter(&bytes.Buffer{})))
+
+		require.Len(t, dirs, 1)
+		_, err := os.Stat(dirs[0])
+		require.True(t, os.IsNotExist(err))
+	})
+
+	t.Run("kept extraction is reused", func(t *testing.T) {
+		controller := gomock.NewController(t)
+		defer controller.Finish()
+
+		workdir, archive := setup(t)
+		defer func() {
+			require.NoError(t, os.RemoveAll(workdir))
+		}()
+
+		// The archive is unarchived once, and metadata is never streamed
+		// because the kept extraction has it.
+		a := mocks.NewMockArchiver(controller)
+		a.EXPECT().UnarchivePath(archive, gomock.Any()).Return(nil).Times(1)
+
+		av := mocks.NewMockArchiveVerifier(controller)
+		av.EXPECT().Verify(gomock.Any()).Return(nil, nil).Times(2)
+
+		var dirs []string
+		options := []sheaf.Option{
+			sheaf.WithArchive(archive),
+			sheaf.WithWorkdir(workdir),
+			sheaf.WithKeepExtracted(true),
+			sheaf.WithArchiver(a),
+			sheaf.WithArchiveVerifier(av),
+			sheaf.WithBundleFactory(genBundleFactory(t, controller, &dirs)),
+			sheaf.WithCodec(genCodec(controller)),
+			sheaf.WithWriter(&bytes.Buffer{}),
+		}
+
+		require.NoError(t, sheaf.ArchiveVerify(options...))
+		require.NoError(t, sheaf.ArchiveVerify(options...))
+		require.NoError(t, sheaf.ArchiveListImages(options...))
+
+		require.Len(t, dirs, 3)
+		require.Equal(t, dirs[0], dirs[1])
+		require.Equal(t, dirs[0], dirs[2])
+		require.Equal(t, workdir, filepath.Dir(dirs[0]))
+
+		fi, err := os.Stat(dirs[0])
+		require.NoError(t, err)
+		require.True(t, fi.IsDir())
+	})
+
+	t.Run("incomplete extraction is replaced", func(t *testing.T) {
+		controller := gomock.NewController(t)
+		defer controller.Finish()
+
+		workdir, archive := setup(t)
+		defer func() {
+			require.NoError(t, os.RemoveAll(workdir))
+		}()
+
+		a := mocks.NewMockArchiver(controller)
+		gomock.InOrder(
+			a.EXPECT().UnarchivePath(archive, gomock.Any()).
+				DoAndReturn(func(src, dest string) error {
+					require.NoError(t, ioutil.WriteFile(filepath.Join(dest, "partial"), nil, 0600))
+					return os.ErrClosed
+				}),
+			a.EXPECT().UnarchivePath(archive, gomock.Any()).
+				DoAndReturn(func(src, dest string) error {
+					_, err := os.Stat(filepath.Join(dest, "partial"))
+					require.True(t, os.IsNotExist(err))
+					return nil
+				}),
+		)
+
+		av := mocks.NewMockArchiveVerifier(controller)
+		av.EXPECT().Verify(gomock.Any()).Return(nil, nil)
+
+		var dirs []string
+		options := []sheaf.Option{
+			sheaf.WithArchive(archive),
+			sheaf.WithWorkdir(workdir),
+			sheaf.WithKeepExtracted(true),
+			sheaf.WithArchiver(a),
+			sheaf.WithArchiveVerifier(av),
+			sheaf.WithBundleFactory(genBundleFactory(t, controller, &dirs)),
+			sheaf.WithWriter(&bytes.Buffer{}),
+		}
+
+		require.Error(t, sheaf.ArchiveVerify(options...))
+		require.NoError(t, sheaf.ArchiveVerify(options...))
+	})
+}
@@ -29,7 +29,6 @@ func TestArchiveListImages(t *testing.T) {
 
 		bundle := testutil.GenerateBundle(t, controller)
 		bundle.EXPECT().Artifacts().Return(as)
-		bundle.EXPECT().Path().Return("")
 
 		return func(string) (sheaf.Bundle, error) {
 			return bundle, nil
@@ -50,7 +49,7 @@ func TestArchiveListImages(t *testing.T) {
 			archiver: func(controller *gomock.Controller) *mocks.MockArchiver {
 				a := mocks.NewMockArchiver(controller)
 				a.EXPECT().
-					UnarchivePath("archive.tgz", gomock.Any()).
+					UnarchiveMetadataPath("archive.tgz", gomock.Any()).
 					Return(nil)
 
 				return a
@@ -19,6 +19,12 @@ func ArchivePack(optionList ...Option) error {
 		return fmt.Errorf("load bundle: %w", err)
 	}
 
+	if opts.locked {
+		if err := checkBundleLock(b); err != nil {
+			return err
+		}
+	}
+
 	bp, err := opts.bundlePacker()
 	if err != nil {
 		return fmt.Errorf("load bundle packer: %w", err)
@@ -10,6 +10,7 @@ import (
 	"testing"
 
 	"github.com/golang/mock/gomock"
+	"github.com/pivotal/image-relocation/pkg/images"
 	"github.com/stretchr/testify/require"
 
 	"github.com/bryanl/sheaf/internal/testutil"
@@ -25,10 +26,23 @@ func TestArchivePack(t *testing.T) {
 		}
 	}
 
+	genLockedBundleFactory := func(t *testing.T, controller *gomock.Controller, lock sheaf.BundleLock) sheaf.BundleFactoryFunc {
+		set, err := images.New("nginx:1.17")
+		require.NoError(t, err)
+
+		bundle := mocks.NewMockBundle(controller)
+		bundle.EXPECT().Images().Return(set, nil)
+		bundle.EXPECT().Lock().Return(lock, nil)
+		return func(string) (sheaf.Bundle, error) {
+			return bundle, nil
+		}
+	}
+
 	cases := []struct {
 		name          string
 		destination   string
 		force         bool
+		locked        bool
 		bundleFactory bundleFactoryFunc
 		bundlePacker  func(controller *gomock.Controller) *mocks.MockBundlePacker
 		wantErr       bool
@@ -62,6 +76,33 @@ func TestArchivePack(t *testing.T) {
 				return bp
 			},
 		},
+		{
+			name:        "locked with current lock",
+			destination: "dest",
+			locked:      true,
+			bundleFactory: func(controller *gomock.Controller) sheaf.BundleFactoryFunc {
+				return genLockedBundleFactory(t, controller, genBundleLock(t, "nginx:1.17", testDigest1))
+			},
+			bundlePacker: func(controller *gomock.Controller) *mocks.MockBundlePacker {
+				bp := mocks.NewMockBundlePacker(controller)
+				bp.EXPECT().
+					Pack(gomock.Any(), "dest", false).
+					Return(nil)
+				return bp
+			},
+		},
+		{
+			name:        "locked with stale lock",
+			destination: "dest",
+			locked:      true,
+			bundleFactory: func(controller *gomock.Controller) sheaf.BundleFactoryFunc {
+				return genLockedBundleFactory(t, controller, genBundleLock(t, "nginx:1.16", testDigest1))
+			},
+			bundlePacker: func(controller *gomock.Controller) *mocks.MockBundlePacker {
+				return mocks.NewMockBundlePacker(controller)
+			},
+			wantErr: true,
+		},
 	}
 
 	for _, tc := range cases {
@@ -74,6 +115,7 @@ func TestArchivePack(t *testing.T) {
 				sheaf.WithBundlePacker(tc.bundlePacker(controller)),
 				sheaf.WithDestination(tc.destination),
 				sheaf.WithForce(tc.force),
+				sheaf.WithLocked(tc.locked),
 			}
 
 			err := sheaf.ArchivePack(options...)
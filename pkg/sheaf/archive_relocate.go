@@ -17,18 +17,54 @@ func ArchiveRelocate(optionList ...Option) error {
 	opts.reporter.Headerf("Relocating images in %s", opts.archive)
 
 	return withExplodedArchive(opts, func(b Bundle) error {
+		if opts.signatureVerifier != nil {
+			opts.reporter.Report("Verifying archive signature")
+			if err := opts.signatureVerifier.VerifySignature(b); err != nil {
+				return fmt.Errorf("verify archive signature: %w", err)
+			}
+		}
+
+		if err := applyDelta(opts, b); err != nil {
+			return err
+		}
+
 		opts.reporter.Report("Locating images in archive")
 		list, err := b.Images()
 		if err != nil {
 			return fmt.Errorf("load images from fs: %w", err)
 		}
 
+		lock, err := b.Lock()
+		if err != nil {
+			return fmt.Errorf("load bundle lock: %w", err)
+		}
+
+		list, err = lock.PinSet(list)
+		if err != nil {
+			return fmt.Errorf("pin images: %w", err)
+		}
+
+		strategy := resolveMappingStrategy(opts, b.Config())
+		imageMapping, err := strategy.ImageMapping(opts.repositoryPrefix)
+		if err != nil {
+			return fmt.Errorf("mapping strategy: %w", err)
+		}
+
 		opts.reporter.Header("Moving images to new location")
 
-		if err := opts.imageRelocator.Relocate(b.Path(), opts.repositoryPrefix, list.Slice(), opts.imageWriter); err != nil {
+		mapping, err := opts.imageRelocator.Relocate(b.Path(), imageMapping, b.Config(), list.Slice(), opts.imageWriter)
+		if err != nil {
 			return fmt.Errorf("stage images: %w", err)
 		}
 
+		if opts.mappingPath != "" {
+			if err := WriteRelocationMapping(opts.mappingPath, mapping); err != nil {
+				return fmt.Errorf("write relocation mapping: %w", err)
+			}
+
+			opts.reporter.Reportf("Wrote relocation mapping to %s", opts.mappingPath)
+		}
+
 		return nil
 	})
 }
@@ -0,0 +1,54 @@
+/*
+ * Copyright 2020 Sheaf Authors
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package sheaf
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// ArchiveServe serves the images in an archive as a read-only registry until
+// it is interrupted.
+func ArchiveServe(optionList ...Option) error {
+	opts := makeDefaultOptions(optionList...)
+
+	if opts.registryServer == nil {
+		return fmt.Errorf("registry server is not configured")
+	}
+
+	if opts.address == "" {
+		return fmt.Errorf("address is required")
+	}
+
+	opts.reporter.Headerf("Serving images in %s", opts.archive)
+
+	return withExplodedArchive(opts, func(b Bundle) error {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		signals := make(chan os.Signal, 1)
+		signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+		defer signal.Stop(signals)
+
+		go func() {
+			select {
+			case <-signals:
+				cancel()
+			case <-ctx.Done():
+			}
+		}()
+
+		if err := opts.registryServer.Serve(ctx, b.Path(), opts.address); err != nil {
+			return fmt.Errorf("serve images: %w", err)
+		}
+
+		return nil
+	})
+}
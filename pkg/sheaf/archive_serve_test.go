@@ -0,0 +1,109 @@
+/*
+ * Copyright 2020 Sheaf Authors
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package sheaf_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bryanl/sheaf/internal/testutil"
+	"github.com/bryanl/sheaf/pkg/mocks"
+	"github.com/bryanl/sheaf/pkg/reporter"
+	"github.com/bryanl/sheaf/pkg/sheaf"
+)
+
+func TestArchiveServe(t *testing.T) {
+	genArchiver := func(controller *gomock.Controller) *mocks.MockArchiver {
+		a := mocks.NewMockArchiver(controller)
+		a.EXPECT().
+			UnarchivePath("archive.tgz", gomock.Any()).
+			Return(nil)
+
+		return a
+	}
+
+	genBundleFactory := func(controller *gomock.Controller) sheaf.BundleFactoryFunc {
+		bundle := testutil.GenerateBundle(t, controller)
+		bundle.EXPECT().Path().Return("/bundle").AnyTimes()
+
+		return func(string) (sheaf.Bundle, error) {
+			return bundle, nil
+		}
+	}
+
+	tests := []struct {
+		name           string
+		address        string
+		archiver       func(controller *gomock.Controller) *mocks.MockArchiver
+		bundleFactory  bundleFactoryFunc
+		registryServer func(controller *gomock.Controller) sheaf.RegistryServer
+		wantErr        bool
+	}{
+		{
+			name:          "in general",
+			address:       ":5000",
+			archiver:      genArchiver,
+			bundleFactory: genBundleFactory,
+			registryServer: func(controller *gomock.Controller) sheaf.RegistryServer {
+				rs := mocks.NewMockRegistryServer(controller)
+				rs.EXPECT().Serve(gomock.Any(), "/bundle", ":5000").Return(nil)
+				return rs
+			},
+		},
+		{
+			name:          "server failed",
+			address:       ":5000",
+			archiver:      genArchiver,
+			bundleFactory: genBundleFactory,
+			registryServer: func(controller *gomock.Controller) sheaf.RegistryServer {
+				rs := mocks.NewMockRegistryServer(controller)
+				rs.EXPECT().Serve(gomock.Any(), "/bundle", ":5000").Return(fmt.Errorf("error"))
+				return rs
+			},
+			wantErr: true,
+		},
+		{
+			name: "no address",
+			archiver: func(controller *gomock.Controller) *mocks.MockArchiver {
+				return mocks.NewMockArchiver(controller)
+			},
+			bundleFactory: func(controller *gomock.Controller) sheaf.BundleFactoryFunc {
+				return nil
+			},
+			registryServer: func(controller *gomock.Controller) sheaf.RegistryServer {
+				return mocks.NewMockRegistryServer(controller)
+			},
+			wantErr: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			controller := gomock.NewController(t)
+			defer controller.Finish()
+
+			options := []sheaf.Option{
+				sheaf.WithArchive("archive.tgz"),
+				sheaf.WithAddress(test.address),
+				sheaf.WithArchiver(test.archiver(controller)),
+				sheaf.WithBundleFactory(test.bundleFactory(controller)),
+				sheaf.WithRegistryServer(test.registryServer(controller)),
+				sheaf.WithReporter(reporter.Nop{}),
+			}
+
+			err := sheaf.ArchiveServe(options...)
+			if test.wantErr {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+		})
+	}
+}
@@ -10,7 +10,7 @@ package sheaf
 func ArchiveShowManifests(optionList ...Option) error {
 	opts := makeDefaultOptions(optionList...)
 
-	return withExplodedArchive(opts, func(b Bundle) error {
+	return withArchiveMetadata(opts, func(b Bundle) error {
 		optionList = append(optionList, WithBundleFactory(func(rootPath string) (bundle Bundle, err error) {
 			return b, nil
 		}))
@@ -0,0 +1,97 @@
+/*
+ * Copyright 2020 Sheaf Authors
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package sheaf
+
+import (
+	"fmt"
+)
+
+//go:generate mockgen -destination=../mocks/mock_archive_verifier.go -package mocks github.com/bryanl/sheaf/pkg/sheaf ArchiveVerifier
+
+const (
+	// ImageVerification is the kind of result for an image.
+	ImageVerification = "image"
+	// ManifestVerification is the kind of result for a manifest file.
+	ManifestVerification = "manifest"
+	// LayoutVerification is the kind of result for the image layout.
+	LayoutVerification = "layout"
+	// SignatureVerification is the kind of result for the archive signature.
+	SignatureVerification = "signature"
+)
+
+// VerificationResult is the result of verifying an image, manifest file, or
+// the image layout in an archive.
+type VerificationResult struct {
+	// Kind is the kind of item verified.
+	Kind string `json:"kind"`
+	// Name is the image name or file path.
+	Name string `json:"name"`
+	// Problems are the problems found. The item passed if there are none.
+	Problems []string `json:"problems,omitempty"`
+}
+
+// Passed returns true if no problems were found.
+func (r VerificationResult) Passed() bool {
+	return len(r.Problems) == 0
+}
+
+// ArchiveVerifier verifies the contents of an archive.
+type ArchiveVerifier interface {
+	// Verify verifies an exploded archive.
+	Verify(b Bundle) ([]VerificationResult, error)
+}
+
+// ArchiveVerify verifies that the images and manifests in an archive have not
+// been corrupted or modified since it was packed, and verifies the archive
+// signature if a signature verifier is configured. It reports whether each
+// check passed, and returns an error if any check failed.
+func ArchiveVerify(optionList ...Option) error {
+	opts := makeDefaultOptions(optionList...)
+
+	if opts.archiveVerifier == nil {
+		return fmt.Errorf("archive verifier is not configured")
+	}
+
+	return withExplodedArchive(opts, func(b Bundle) error {
+		results, err := opts.archiveVerifier.Verify(b)
+		if err != nil {
+			return fmt.Errorf("verify archive: %w", err)
+		}
+
+		if opts.signatureVerifier != nil {
+			result := VerificationResult{
+				Kind: SignatureVerification,
+				Name: opts.archive,
+			}
+			if err := opts.signatureVerifier.VerifySignature(b); err != nil {
+				result.Problems = append(result.Problems, err.Error())
+			}
+
+			results = append([]VerificationResult{result}, results...)
+		}
+
+		failed := 0
+		for _, result := range results {
+			status := "PASS"
+			if !result.Passed() {
+				status = "FAIL"
+				failed++
+			}
+
+			fmt.Fprintf(opts.writer, "%s %s %s\n", status, result.Kind, result.Name)
+			for _, problem := range result.Problems {
+				fmt.Fprintf(opts.writer, "  - %s\n", problem)
+			}
+		}
+
+		if failed > 0 {
+			return fmt.Errorf("archive verification failed: %d of %d checks failed", failed, len(results))
+		}
+
+		return nil
+	})
+}
@@ -0,0 +1,179 @@
+/*
+ * Copyright 2020 Sheaf Authors
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package sheaf_test
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bryanl/sheaf/internal/testutil"
+	"github.com/bryanl/sheaf/pkg/mocks"
+	"github.com/bryanl/sheaf/pkg/reporter"
+	"github.com/bryanl/sheaf/pkg/sheaf"
+)
+
+func TestArchiveVerify(t *testing.T) {
+	genArchiver := func(controller *gomock.Controller) *mocks.MockArchiver {
+		a := mocks.NewMockArchiver(controller)
+		a.EXPECT().
+			UnarchivePath("archive.tgz", gomock.Any()).
+			Return(nil)
+
+		return a
+	}
+
+	genBundleFactory := func(controller *gomock.Controller) sheaf.BundleFactoryFunc {
+		bundle := testutil.GenerateBundle(t, controller)
+		bundle.EXPECT().Path().Return("/bundle").AnyTimes()
+
+		return func(string) (sheaf.Bundle, error) {
+			return bundle, nil
+		}
+	}
+
+	tests := []struct {
+		name            string
+		archiver        func(controller *gomock.Controller) *mocks.MockArchiver
+		bundleFactory   bundleFactoryFunc
+		archiveVerifier func(controller *gomock.Controller) sheaf.ArchiveVerifier
+		signature       func(controller *gomock.Controller) sheaf.SignatureVerifier
+		wantOutput      string
+		wantErr         bool
+	}{
+		{
+			name:          "in general",
+			archiver:      genArchiver,
+			bundleFactory: genBundleFactory,
+			archiveVerifier: func(controller *gomock.Controller) sheaf.ArchiveVerifier {
+				av := mocks.NewMockArchiveVerifier(controller)
+				av.EXPECT().Verify(gomock.Any()).Return([]sheaf.VerificationResult{
+					{Kind: sheaf.LayoutVerification, Name: "artifacts/layout"},
+					{Kind: sheaf.ImageVerification, Name: "docker.io/library/nginx:1.17"},
+					{Kind: sheaf.ManifestVerification, Name: "app/manifests/deploy.yaml"},
+				}, nil)
+				return av
+			},
+			wantOutput: "PASS layout artifacts/layout\n" +
+				"PASS image docker.io/library/nginx:1.17\n" +
+				"PASS manifest app/manifests/deploy.yaml\n",
+		},
+		{
+			name:          "check failed",
+			archiver:      genArchiver,
+			bundleFactory: genBundleFactory,
+			archiveVerifier: func(controller *gomock.Controller) sheaf.ArchiveVerifier {
+				av := mocks.NewMockArchiveVerifier(controller)
+				av.EXPECT().Verify(gomock.Any()).Return([]sheaf.VerificationResult{
+					{Kind: sheaf.ImageVerification, Name: "docker.io/library/nginx:1.17", Problems: []string{"image is not in the layout index"}},
+					{Kind: sheaf.ManifestVerification, Name: "app/manifests/deploy.yaml"},
+				}, nil)
+				return av
+			},
+			wantOutput: "FAIL image docker.io/library/nginx:1.17\n" +
+				"  - image is not in the layout index\n" +
+				"PASS manifest app/manifests/deploy.yaml\n",
+			wantErr: true,
+		},
+		{
+			name:          "valid signature",
+			archiver:      genArchiver,
+			bundleFactory: genBundleFactory,
+			archiveVerifier: func(controller *gomock.Controller) sheaf.ArchiveVerifier {
+				av := mocks.NewMockArchiveVerifier(controller)
+				av.EXPECT().Verify(gomock.Any()).Return([]sheaf.VerificationResult{
+					{Kind: sheaf.ImageVerification, Name: "docker.io/library/nginx:1.17"},
+				}, nil)
+				return av
+			},
+			signature: func(controller *gomock.Controller) sheaf.SignatureVerifier {
+				sv := mocks.NewMockSignatureVerifier(controller)
+				sv.EXPECT().VerifySignature(gomock.Any()).Return(nil)
+				return sv
+			},
+			wantOutput: "PASS signature archive.tgz\n" +
+				"PASS image docker.io/library/nginx:1.17\n",
+		},
+		{
+			name:          "invalid signature",
+			archiver:      genArchiver,
+			bundleFactory: genBundleFactory,
+			archiveVerifier: func(controller *gomock.Controller) sheaf.ArchiveVerifier {
+				av := mocks.NewMockArchiveVerifier(controller)
+				av.EXPECT().Verify(gomock.Any()).Return([]sheaf.VerificationResult{
+					{Kind: sheaf.ImageVerification, Name: "docker.io/library/nginx:1.17"},
+				}, nil)
+				return av
+			},
+			signature: func(controller *gomock.Controller) sheaf.SignatureVerifier {
+				sv := mocks.NewMockSignatureVerifier(controller)
+				sv.EXPECT().VerifySignature(gomock.Any()).Return(fmt.Errorf("archive is not signed"))
+				return sv
+			},
+			wantOutput: "FAIL signature archive.tgz\n" +
+				"  - archive is not signed\n" +
+				"PASS image docker.io/library/nginx:1.17\n",
+			wantErr: true,
+		},
+		{
+			name:          "verifier failed",
+			archiver:      genArchiver,
+			bundleFactory: genBundleFactory,
+			archiveVerifier: func(controller *gomock.Controller) sheaf.ArchiveVerifier {
+				av := mocks.NewMockArchiveVerifier(controller)
+				av.EXPECT().Verify(gomock.Any()).Return(nil, fmt.Errorf("error"))
+				return av
+			},
+			wantErr: true,
+		},
+		{
+			name: "no verifier",
+			archiver: func(controller *gomock.Controller) *mocks.MockArchiver {
+				return mocks.NewMockArchiver(controller)
+			},
+			bundleFactory: func(controller *gomock.Controller) sheaf.BundleFactoryFunc {
+				return nil
+			},
+			archiveVerifier: func(controller *gomock.Controller) sheaf.ArchiveVerifier {
+				return nil
+			},
+			wantErr: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			controller := gomock.NewController(t)
+			defer controller.Finish()
+
+			var buf bytes.Buffer
+
+			options := []sheaf.Option{
+				sheaf.WithArchive("archive.tgz"),
+				sheaf.WithArchiver(test.archiver(controller)),
+				sheaf.WithBundleFactory(test.bundleFactory(controller)),
+				sheaf.WithArchiveVerifier(test.archiveVerifier(controller)),
+				sheaf.WithReporter(reporter.Nop{}),
+				sheaf.WithWriter(&buf),
+			}
+			if test.signature != nil {
+				options = append(options, sheaf.WithSignatureVerifier(test.signature(controller)))
+			}
+
+			err := sheaf.ArchiveVerify(options...)
+			require.Equal(t, test.wantOutput, buf.String())
+			if test.wantErr {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+		})
+	}
+}
@@ -18,4 +18,9 @@ type Archiver interface {
 	Unarchive(r io.Reader, dest string) error
 	// UnarchivePath unarchives a source to a destination.
 	UnarchivePath(src string, dest string) error
+	// UnarchiveMetadataPath unarchives a source to a destination without its
+	// image layers.
+	UnarchiveMetadataPath(src string, dest string) error
+	// Extension returns the filename extension of archives.
+	Extension() string
 }
@@ -31,6 +31,8 @@ type Bundle interface {
 	Artifacts() ArtifactsService
 	Manifests() (ManifestService, error)
 	Images() (images.Set, error)
+	Lock() (BundleLock, error)
+	WriteLock(lock BundleLock) error
 	Copy(dest string) (Bundle, error)
 }
 
@@ -38,6 +40,7 @@ type Bundle interface {
 type ManifestService interface {
 	List() ([]BundleManifest, error)
 	Add(overwrite bool, manifestURIs ...string) error
+	AddSource(overwrite bool, source ManifestSource) error
 }
 
 // BundleManifest describes a manifest in a fs.
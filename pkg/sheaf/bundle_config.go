@@ -30,8 +30,18 @@ type BundleConfig interface {
 	SetName(string)
 	GetVersion() string
 	SetVersion(string)
+	GetImages() []string
+	SetImages([]string)
+	GetImageExclusions() []ImageExclusion
+	SetImageExclusions([]ImageExclusion)
 	GetUserDefinedImages() []UserDefinedImage
 	SetUserDefinedImages([]UserDefinedImage)
+	GetCatalog() CatalogSelection
+	SetCatalog(CatalogSelection)
+	GetManifestSources() []ManifestSource
+	SetManifestSources([]ManifestSource)
+	GetMappingStrategy() MappingStrategy
+	SetMappingStrategy(MappingStrategy)
 }
 
 // BundleConfigWriter writes a bundle config.
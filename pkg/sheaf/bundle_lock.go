@@ -0,0 +1,180 @@
+/*
+ * Copyright 2020 Sheaf Authors
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package sheaf
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pivotal/image-relocation/pkg/image"
+	"github.com/pivotal/image-relocation/pkg/images"
+)
+
+// BundleLockFilename is the filename for a bundle lock.
+const BundleLockFilename = "bundle.lock.json"
+
+// BundleLock pins the images in a bundle to digests.
+type BundleLock struct {
+	// Images are the locked images sorted by name.
+	Images []LockedImage `json:"images"`
+}
+
+// LockedImage is an image name and the digest it resolved to.
+type LockedImage struct {
+	// Name is the image name as found in the bundle.
+	Name string `json:"name"`
+	// Digest is the digest the image name resolved to.
+	Digest string `json:"digest"`
+}
+
+// NewBundleLock creates a bundle lock from image names and their digests.
+func NewBundleLock(digests map[image.Name]image.Digest) BundleLock {
+	var lock BundleLock
+	for name, digest := range digests {
+		lock.Images = append(lock.Images, LockedImage{
+			Name:   name.String(),
+			Digest: digest.String(),
+		})
+	}
+
+	sort.Slice(lock.Images, func(i, j int) bool {
+		return lock.Images[i].Name < lock.Images[j].Name
+	})
+
+	return lock
+}
+
+// IsEmpty returns true if the lock contains no images.
+func (bl BundleLock) IsEmpty() bool {
+	return len(bl.Images) == 0
+}
+
+// Pin returns the image name with its locked digest. It returns false if the
+// image is not locked. Images that already have a digest are returned unchanged.
+func (bl BundleLock) Pin(n image.Name) (image.Name, bool, error) {
+	if n.Digest() != image.EmptyDigest {
+		return n, true, nil
+	}
+
+	for _, locked := range bl.Images {
+		lockedName, err := image.NewName(locked.Name)
+		if err != nil {
+			return image.EmptyName, false, fmt.Errorf("parse locked image name %q: %w", locked.Name, err)
+		}
+
+		if lockedName != n.Normalize() {
+			continue
+		}
+
+		digest, err := image.NewDigest(locked.Digest)
+		if err != nil {
+			return image.EmptyName, false, fmt.Errorf("parse locked digest for %s: %w", locked.Name, err)
+		}
+
+		pinned, err := n.WithDigest(digest)
+		if err != nil {
+			return image.EmptyName, false, err
+		}
+
+		return pinned, true, nil
+	}
+
+	return n, false, nil
+}
+
+// PinSet pins a set of images. Images that are not locked are returned
+// unchanged.
+func (bl BundleLock) PinSet(set images.Set) (images.Set, error) {
+	var names []string
+	for _, n := range set.Slice() {
+		pinned, _, err := bl.Pin(n)
+		if err != nil {
+			return images.Empty, err
+		}
+
+		names = append(names, pinned.String())
+	}
+
+	return images.New(names...)
+}
+
+// Relock returns a copy of the lock with the digests of locked images
+// replaced. Images that are not locked are ignored.
+func (bl BundleLock) Relock(digests map[image.Name]image.Digest) (BundleLock, error) {
+	relocked := BundleLock{Images: make([]LockedImage, len(bl.Images))}
+	copy(relocked.Images, bl.Images)
+
+	for i, locked := range relocked.Images {
+		lockedName, err := image.NewName(locked.Name)
+		if err != nil {
+			return BundleLock{}, fmt.Errorf("parse locked image name %q: %w", locked.Name, err)
+		}
+
+		for n, digest := range digests {
+			if lockedName == n.Normalize() {
+				relocked.Images[i].Digest = digest.String()
+			}
+		}
+	}
+
+	return relocked, nil
+}
+
+// Check returns an error if the lock does not match a set of images. A lock
+// is stale if an image is not locked or a locked image is no longer used.
+func (bl BundleLock) Check(set images.Set) error {
+	var problems []string
+
+	current := map[string]bool{}
+	for _, n := range set.Slice() {
+		if n.Digest() != image.EmptyDigest {
+			continue
+		}
+
+		current[n.String()] = true
+
+		if _, ok, err := bl.Pin(n); err != nil {
+			return err
+		} else if !ok {
+			problems = append(problems, fmt.Sprintf("%s is not locked", n))
+		}
+	}
+
+	for _, locked := range bl.Images {
+		lockedName, err := image.NewName(locked.Name)
+		if err != nil {
+			return fmt.Errorf("parse locked image name %q: %w", locked.Name, err)
+		}
+
+		if !current[lockedName.String()] {
+			problems = append(problems, fmt.Sprintf("%s is locked but not used", locked.Name))
+		}
+	}
+
+	if len(problems) > 0 {
+		sort.Strings(problems)
+		return fmt.Errorf("bundle lock is stale: %s", strings.Join(problems, ", "))
+	}
+
+	return nil
+}
+
+// checkBundleLock returns an error if the bundle lock is stale.
+func checkBundleLock(b Bundle) error {
+	lock, err := b.Lock()
+	if err != nil {
+		return fmt.Errorf("load bundle lock: %w", err)
+	}
+
+	list, err := b.Images()
+	if err != nil {
+		return fmt.Errorf("load images from bundle: %w", err)
+	}
+
+	return lock.Check(list)
+}
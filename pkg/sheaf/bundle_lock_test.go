@@ -0,0 +1,174 @@
+/*
+ * Copyright 2020 Sheaf Authors
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package sheaf_test
+
+import (
+	"testing"
+
+	"github.com/pivotal/image-relocation/pkg/image"
+	"github.com/pivotal/image-relocation/pkg/images"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bryanl/sheaf/pkg/sheaf"
+)
+
+const (
+	testDigest1 = "sha256:2539d4344dd18e1df02be842ffc435f8e1f699cfc55516e2cf2cb16b7a9aea0b"
+	testDigest2 = "sha256:8d4bd7e2bb0adc6df8e1fd8e5b2a3d2d6e6a0f4fbd13ba07fe56e7d4c2ae4c8b"
+)
+
+func genBundleLock(t *testing.T, pairs ...string) sheaf.BundleLock {
+	digests := map[image.Name]image.Digest{}
+	for i := 0; i < len(pairs); i += 2 {
+		n, err := image.NewName(pairs[i])
+		require.NoError(t, err)
+		d, err := image.NewDigest(pairs[i+1])
+		require.NoError(t, err)
+		digests[n] = d
+	}
+
+	return sheaf.NewBundleLock(digests)
+}
+
+func TestNewBundleLock(t *testing.T) {
+	lock := genBundleLock(t, "redis:5", testDigest2, "nginx:1.17", testDigest1)
+
+	wanted := sheaf.BundleLock{
+		Images: []sheaf.LockedImage{
+			{Name: "docker.io/library/nginx:1.17", Digest: testDigest1},
+			{Name: "docker.io/library/redis:5", Digest: testDigest2},
+		},
+	}
+
+	require.Equal(t, wanted, lock)
+}
+
+func TestBundleLock_Pin(t *testing.T) {
+	lock := genBundleLock(t, "nginx:1.17", testDigest1)
+
+	cases := []struct {
+		name   string
+		image  string
+		want   string
+		wantOK bool
+	}{
+		{
+			name:   "locked image",
+			image:  "nginx:1.17",
+			want:   "docker.io/library/nginx:1.17@" + testDigest1,
+			wantOK: true,
+		},
+		{
+			name:   "locked image with full name",
+			image:  "docker.io/library/nginx:1.17",
+			want:   "docker.io/library/nginx:1.17@" + testDigest1,
+			wantOK: true,
+		},
+		{
+			name:  "unlocked image",
+			image: "nginx:1.18",
+			want:  "docker.io/library/nginx:1.18",
+		},
+		{
+			name:   "image with digest",
+			image:  "redis@" + testDigest2,
+			want:   "docker.io/library/redis@" + testDigest2,
+			wantOK: true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			n, err := image.NewName(tc.image)
+			require.NoError(t, err)
+
+			got, ok, err := lock.Pin(n)
+			require.NoError(t, err)
+			require.Equal(t, tc.wantOK, ok)
+			require.Equal(t, tc.want, got.String())
+		})
+	}
+}
+
+func TestBundleLock_PinSet(t *testing.T) {
+	lock := genBundleLock(t, "nginx:1.17", testDigest1)
+
+	set, err := images.New("nginx:1.17", "redis:5")
+	require.NoError(t, err)
+
+	got, err := lock.PinSet(set)
+	require.NoError(t, err)
+
+	require.Equal(t, []string{
+		"docker.io/library/nginx:1.17@" + testDigest1,
+		"docker.io/library/redis:5",
+	}, got.Strings())
+}
+
+func TestBundleLock_Relock(t *testing.T) {
+	lock := genBundleLock(t, "nginx:1.17", testDigest1, "redis:5", testDigest1)
+
+	n, err := image.NewName("nginx:1.17")
+	require.NoError(t, err)
+	d, err := image.NewDigest(testDigest2)
+	require.NoError(t, err)
+	other, err := image.NewName("memcached:1.6")
+	require.NoError(t, err)
+
+	got, err := lock.Relock(map[image.Name]image.Digest{n: d, other: d})
+	require.NoError(t, err)
+
+	require.Equal(t, genBundleLock(t, "nginx:1.17", testDigest2, "redis:5", testDigest1), got)
+	require.Equal(t, genBundleLock(t, "nginx:1.17", testDigest1, "redis:5", testDigest1), lock)
+}
+
+func TestBundleLock_Check(t *testing.T) {
+	cases := []struct {
+		name    string
+		lock    sheaf.BundleLock
+		images  []string
+		wantErr bool
+	}{
+		{
+			name:   "current",
+			lock:   genBundleLock(t, "nginx:1.17", testDigest1),
+			images: []string{"nginx:1.17", "redis@" + testDigest2},
+		},
+		{
+			name:    "image is not locked",
+			lock:    genBundleLock(t, "nginx:1.17", testDigest1),
+			images:  []string{"nginx:1.17", "redis:5"},
+			wantErr: true,
+		},
+		{
+			name:    "locked image is not used",
+			lock:    genBundleLock(t, "nginx:1.17", testDigest1, "redis:5", testDigest2),
+			images:  []string{"nginx:1.17"},
+			wantErr: true,
+		},
+		{
+			name:    "no lock",
+			images:  []string{"nginx:1.17"},
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			set, err := images.New(tc.images...)
+			require.NoError(t, err)
+
+			err = tc.lock.Check(set)
+			if tc.wantErr {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+		})
+	}
+}
@@ -0,0 +1,250 @@
+/*
+ * Copyright 2020 Sheaf Authors
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package sheaf
+
+import (
+	"fmt"
+	"sort"
+)
+
+// CatalogVersion is the version of the built-in user defined image catalog.
+const CatalogVersion = "v1"
+
+// CatalogEntry is a named group of user defined images for a popular project.
+type CatalogEntry struct {
+	Name              string             `json:"name"`
+	Description       string             `json:"description"`
+	UserDefinedImages []UserDefinedImage `json:"userDefinedImages"`
+}
+
+// CatalogSelection is the catalog selection stored in a bundle config. Catalog
+// entries are enabled unless they are disabled.
+type CatalogSelection struct {
+	// Version is the catalog version the selection was written with.
+	Version string `json:"version,omitempty"`
+	// Disabled is a list of disabled catalog entry names.
+	Disabled []string `json:"disabled,omitempty"`
+}
+
+// IsEnabled returns true if the named catalog entry is enabled.
+func (cs CatalogSelection) IsEnabled(name string) bool {
+	for _, disabled := range cs.Disabled {
+		if disabled == name {
+			return false
+		}
+	}
+
+	return true
+}
+
+// CatalogEntryStatus is a catalog entry and its state in a bundle.
+type CatalogEntryStatus struct {
+	CatalogEntry
+	Enabled bool `json:"enabled"`
+}
+
+var catalog = []CatalogEntry{
+	{
+		Name:              "argo",
+		Description:       "Argo Workflows workflow templates",
+		UserDefinedImages: argoUserDefinedImages(),
+	},
+	{
+		Name:              "cert-manager",
+		Description:       "cert-manager controller, webhook, cainjector, and ACME solver images",
+		UserDefinedImages: certManagerUserDefinedImages(),
+	},
+	{
+		Name:              "istio",
+		Description:       "Istio operator component and proxy images",
+		UserDefinedImages: istioUserDefinedImages(),
+	},
+	{
+		Name:        "knative",
+		Description: "Knative caching images",
+		UserDefinedImages: []UserDefinedImage{
+			{
+				APIVersion: "caching.internal.knative.dev/v1alpha1",
+				Kind:       "Image",
+				JSONPath:   ".spec.image",
+			},
+		},
+	},
+	{
+		Name:        "tekton",
+		Description: "Tekton task steps and sidecars",
+		UserDefinedImages: tektonUserDefinedImages(
+			"tekton.dev/v1alpha1", "tekton.dev/v1beta1"),
+	},
+}
+
+func argoUserDefinedImages() []UserDefinedImage {
+	templatePaths := map[string]string{
+		"ClusterWorkflowTemplate": ".spec.templates[*]",
+		"CronWorkflow":            ".spec.workflowSpec.templates[*]",
+		"Workflow":                ".spec.templates[*]",
+		"WorkflowTemplate":        ".spec.templates[*]",
+	}
+
+	var kinds []string
+	for kind := range templatePaths {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+
+	var list []UserDefinedImage
+	for _, kind := range kinds {
+		templatePath := templatePaths[kind]
+		list = append(list,
+			UserDefinedImage{
+				APIVersion: "argoproj.io/v1alpha1",
+				Kind:       kind,
+				JSONPath:   templatePath + "['container','script'].image",
+			},
+			UserDefinedImage{
+				APIVersion: "argoproj.io/v1alpha1",
+				Kind:       kind,
+				JSONPath:   templatePath + "['initContainers','sidecars'][*].image",
+			})
+	}
+
+	return list
+}
+
+func certManagerUserDefinedImages() []UserDefinedImage {
+	// The operator's spec holds the cert-manager chart values, which keep the
+	// repository and tag of each image in separate fields.
+	var list []UserDefinedImage
+	for _, jsonPath := range []string{
+		".spec.image.repository",
+		".spec['webhook','cainjector','acmesolver','startupapicheck'].image.repository",
+	} {
+		list = append(list, UserDefinedImage{
+			APIVersion:   "operator.cert-manager.io/v1alpha1",
+			Kind:         "CertManager",
+			JSONPath:     jsonPath,
+			TagJSONPaths: []string{"@.tag"},
+		})
+	}
+
+	return list
+}
+
+func istioUserDefinedImages() []UserDefinedImage {
+	// A component's hub and tag take precedence over the global ones, and
+	// the operator replaces the global hub and tag with spec.hub and spec.tag.
+	hubPaths := []string{"@.hub", ".spec.hub", ".spec.values.global.hub"}
+	tagPaths := []string{"@.tag", ".spec.tag", ".spec.values.global.tag"}
+
+	var list []UserDefinedImage
+	for _, jsonPath := range []string{
+		".spec.values.*.image",
+		".spec.values.global['proxy','proxy_init'].image",
+	} {
+		list = append(list, UserDefinedImage{
+			APIVersion:   "install.istio.io/v1alpha1",
+			Kind:         "IstioOperator",
+			JSONPath:     jsonPath,
+			HubJSONPaths: hubPaths,
+			TagJSONPaths: tagPaths,
+		})
+	}
+
+	return list
+}
+
+func tektonUserDefinedImages(apiVersions ...string) []UserDefinedImage {
+	var list []UserDefinedImage
+	for _, apiVersion := range apiVersions {
+		for _, kind := range []string{"Task", "ClusterTask"} {
+			list = append(list, UserDefinedImage{
+				APIVersion: apiVersion,
+				Kind:       kind,
+				JSONPath:   ".spec['steps','sidecars'][*].image",
+			})
+		}
+	}
+
+	return list
+}
+
+// Catalog returns the built-in catalog entries sorted by name.
+func Catalog() []CatalogEntry {
+	list := make([]CatalogEntry, len(catalog))
+	copy(list, catalog)
+
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Name < list[j].Name
+	})
+
+	return list
+}
+
+// LookupCatalogEntry returns the named catalog entry.
+func LookupCatalogEntry(name string) (CatalogEntry, error) {
+	for _, entry := range catalog {
+		if entry.Name == name {
+			return entry, nil
+		}
+	}
+
+	return CatalogEntry{}, fmt.Errorf("catalog entry %q does not exist", name)
+}
+
+// CatalogUserDefinedImages returns the user defined images for the catalog
+// entries enabled in a selection.
+func CatalogUserDefinedImages(selection CatalogSelection) []UserDefinedImage {
+	var list []UserDefinedImage
+	for _, entry := range Catalog() {
+		if !selection.IsEnabled(entry.Name) {
+			continue
+		}
+
+		list = append(list, entry.UserDefinedImages...)
+	}
+
+	return list
+}
+
+func updateCatalog(config BundleConfig, names []string, enabled bool) (BundleConfig, error) {
+	if len(names) == 0 {
+		return nil, fmt.Errorf("catalog entry name is required")
+	}
+
+	selection := config.GetCatalog()
+
+	disabled := map[string]bool{}
+	for _, name := range selection.Disabled {
+		disabled[name] = true
+	}
+
+	for _, name := range names {
+		if _, err := LookupCatalogEntry(name); err != nil {
+			return nil, err
+		}
+
+		if enabled {
+			delete(disabled, name)
+			continue
+		}
+
+		disabled[name] = true
+	}
+
+	var list []string
+	for name := range disabled {
+		list = append(list, name)
+	}
+	sort.Strings(list)
+
+	config.SetCatalog(CatalogSelection{
+		Version:  CatalogVersion,
+		Disabled: list,
+	})
+
+	return config, nil
+}
@@ -0,0 +1,46 @@
+/*
+ * Copyright 2020 Sheaf Authors
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package sheaf
+
+import "fmt"
+
+// ConfigAddExclusion adds image exclusions to a bundle configuration.
+func ConfigAddExclusion(optionList ...Option) error {
+	opts := makeDefaultOptions(optionList...)
+
+	if len(opts.exclusions) == 0 {
+		return fmt.Errorf("exclusion is required")
+	}
+
+	for _, exclusion := range opts.exclusions {
+		if err := exclusion.Validate(); err != nil {
+			return fmt.Errorf("invalid exclusion %s: %w", exclusion, err)
+		}
+	}
+
+	bcw, err := opts.bundleConfigWriter()
+	if err != nil {
+		return err
+	}
+
+	b, err := opts.bundleFactory(opts.bundlePath)
+	if err != nil {
+		return fmt.Errorf("load bundle: %w", err)
+	}
+
+	config := updateImageExclusions(b.Config(), func(m imageExclusionMap) {
+		for _, exclusion := range opts.exclusions {
+			m[exclusion] = true
+		}
+	})
+
+	if err := bcw.Write(b, config); err != nil {
+		return fmt.Errorf("write bundle config: %w", err)
+	}
+
+	return nil
+}
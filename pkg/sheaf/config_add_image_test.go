@@ -0,0 +1,116 @@
+/*
+ * Copyright 2020 Sheaf Authors
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package sheaf_test
+
+import (
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bryanl/sheaf/internal/testutil"
+	"github.com/bryanl/sheaf/pkg/mocks"
+	"github.com/bryanl/sheaf/pkg/sheaf"
+)
+
+func TestConfigAddImage(t *testing.T) {
+	genBundleFactory := func(t *testing.T, controller *gomock.Controller, config *mocks.MockBundleConfig) sheaf.BundleFactoryFunc {
+		bundle := testutil.GenerateBundle(t, controller,
+			testutil.BundleGeneratorConfig(config))
+		return func(string) (sheaf.Bundle, error) {
+			return bundle, nil
+		}
+	}
+
+	cases := []struct {
+		name          string
+		images        []string
+		bundleFactory bundleFactoryFunc
+		configWriter  func(controller *gomock.Controller) *mocks.MockBundleConfigWriter
+		wantErr       bool
+	}{
+		{
+			name:   "add to empty list",
+			images: []string{"redis:5", "busybox"},
+			bundleFactory: func(controller *gomock.Controller) sheaf.BundleFactoryFunc {
+				config := testutil.GenerateBundleConfig(controller)
+				config.EXPECT().GetImages().Return(nil)
+				config.EXPECT().SetImages([]string{"busybox", "redis:5"})
+
+				return genBundleFactory(t, controller, config)
+			},
+			configWriter: successfulConfigWriter,
+		},
+		{
+			name:   "add existing image",
+			images: []string{"docker.io/library/nginx:1.17"},
+			bundleFactory: func(controller *gomock.Controller) sheaf.BundleFactoryFunc {
+				config := testutil.GenerateBundleConfig(controller)
+				config.EXPECT().GetImages().Return([]string{"nginx:1.17"})
+				config.EXPECT().SetImages([]string{"docker.io/library/nginx:1.17"})
+
+				return genBundleFactory(t, controller, config)
+			},
+			configWriter: successfulConfigWriter,
+		},
+		{
+			name:   "invalid image",
+			images: []string{"INVALID"},
+			bundleFactory: func(controller *gomock.Controller) sheaf.BundleFactoryFunc {
+				config := testutil.GenerateBundleConfig(controller)
+				config.EXPECT().GetImages().Return(nil)
+
+				return genBundleFactory(t, controller, config)
+			},
+			configWriter: noopConfigWriter,
+			wantErr:      true,
+		},
+		{
+			name: "no images",
+			bundleFactory: func(controller *gomock.Controller) sheaf.BundleFactoryFunc {
+				config := testutil.GenerateBundleConfig(controller)
+				return genBundleFactory(t, controller, config)
+			},
+			configWriter: noopConfigWriter,
+			wantErr:      true,
+		},
+		{
+			name:   "unable to write config",
+			images: []string{"redis:5"},
+			bundleFactory: func(controller *gomock.Controller) sheaf.BundleFactoryFunc {
+				config := testutil.GenerateBundleConfig(controller)
+				config.EXPECT().GetImages().Return(nil)
+				config.EXPECT().SetImages([]string{"redis:5"})
+
+				return genBundleFactory(t, controller, config)
+			},
+			configWriter: errorConfigWriter,
+			wantErr:      true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			controller := gomock.NewController(t)
+			defer controller.Finish()
+
+			options := []sheaf.Option{
+				sheaf.WithImages(tc.images),
+				sheaf.WithBundleFactory(tc.bundleFactory(controller)),
+				sheaf.WithBundleConfigWriter(tc.configWriter(controller)),
+			}
+
+			err := sheaf.ConfigAddImage(options...)
+			if tc.wantErr {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+		})
+	}
+}
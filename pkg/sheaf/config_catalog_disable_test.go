@@ -0,0 +1,143 @@
+/*
+ * Copyright 2020 Sheaf Authors
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package sheaf_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bryanl/sheaf/internal/testutil"
+	"github.com/bryanl/sheaf/pkg/mocks"
+	"github.com/bryanl/sheaf/pkg/sheaf"
+)
+
+func TestConfigCatalogDisable(t *testing.T) {
+	genBundleFactory := func(t *testing.T, controller *gomock.Controller, config *mocks.MockBundleConfig) sheaf.BundleFactoryFunc {
+		bundle := testutil.GenerateBundle(t, controller,
+			testutil.BundleGeneratorConfig(config))
+		return func(string) (sheaf.Bundle, error) {
+			return bundle, nil
+		}
+	}
+
+	cases := []struct {
+		name          string
+		names         []string
+		bundleFactory bundleFactoryFunc
+		configWriter  func(controller *gomock.Controller) *mocks.MockBundleConfigWriter
+		wantErr       bool
+	}{
+		{
+			name:  "with no existing selection",
+			names: []string{"tekton"},
+			bundleFactory: func(controller *gomock.Controller) sheaf.BundleFactoryFunc {
+				config := testutil.GenerateBundleConfig(controller)
+				config.EXPECT().GetCatalog().Return(sheaf.CatalogSelection{})
+				config.EXPECT().SetCatalog(sheaf.CatalogSelection{
+					Version:  sheaf.CatalogVersion,
+					Disabled: []string{"tekton"},
+				})
+
+				return genBundleFactory(t, controller, config)
+			},
+			configWriter: successfulConfigWriter,
+		},
+		{
+			name:  "with existing selection",
+			names: []string{"argo", "tekton"},
+			bundleFactory: func(controller *gomock.Controller) sheaf.BundleFactoryFunc {
+				config := testutil.GenerateBundleConfig(controller)
+				config.EXPECT().GetCatalog().Return(sheaf.CatalogSelection{
+					Disabled: []string{"tekton"},
+				})
+				config.EXPECT().SetCatalog(sheaf.CatalogSelection{
+					Version:  sheaf.CatalogVersion,
+					Disabled: []string{"argo", "tekton"},
+				})
+
+				return genBundleFactory(t, controller, config)
+			},
+			configWriter: successfulConfigWriter,
+		},
+		{
+			name:  "unknown catalog entry",
+			names: []string{"unknown"},
+			bundleFactory: func(controller *gomock.Controller) sheaf.BundleFactoryFunc {
+				config := testutil.GenerateBundleConfig(controller)
+				config.EXPECT().GetCatalog().Return(sheaf.CatalogSelection{})
+
+				return genBundleFactory(t, controller, config)
+			},
+			configWriter: noopConfigWriter,
+			wantErr:      true,
+		},
+		{
+			name: "no catalog entry names",
+			bundleFactory: func(controller *gomock.Controller) sheaf.BundleFactoryFunc {
+				config := testutil.GenerateBundleConfig(controller)
+				return genBundleFactory(t, controller, config)
+			},
+			configWriter: noopConfigWriter,
+			wantErr:      true,
+		},
+		{
+			name: "load bundle error",
+			bundleFactory: func(controller *gomock.Controller) sheaf.BundleFactoryFunc {
+				return func(string) (bundle sheaf.Bundle, err error) {
+					return nil, fmt.Errorf("error")
+				}
+			},
+			configWriter: noopConfigWriter,
+			wantErr:      true,
+		},
+		{
+			name:  "write config error",
+			names: []string{"tekton"},
+			bundleFactory: func(controller *gomock.Controller) sheaf.BundleFactoryFunc {
+				config := testutil.GenerateBundleConfig(controller)
+				config.EXPECT().GetCatalog().Return(sheaf.CatalogSelection{})
+				config.EXPECT().SetCatalog(gomock.Any())
+
+				return genBundleFactory(t, controller, config)
+			},
+			configWriter: errorConfigWriter,
+			wantErr:      true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			controller := gomock.NewController(t)
+			defer controller.Finish()
+
+			options := []sheaf.Option{
+				sheaf.WithCatalogEntryNames(tc.names),
+			}
+
+			if tc.bundleFactory != nil {
+				options = append(options, sheaf.WithBundleFactory(
+					tc.bundleFactory(controller)))
+			}
+
+			if tc.configWriter != nil {
+				options = append(options, sheaf.WithBundleConfigWriter(
+					tc.configWriter(controller)))
+			}
+
+			err := sheaf.ConfigCatalogDisable(options...)
+			if tc.wantErr {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+		})
+	}
+}
@@ -0,0 +1,37 @@
+/*
+ * Copyright 2020 Sheaf Authors
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package sheaf
+
+import (
+	"fmt"
+)
+
+// ConfigCatalogEnable enables user defined image catalog entries in the bundle configuration.
+func ConfigCatalogEnable(optionList ...Option) error {
+	opts := makeDefaultOptions(optionList...)
+
+	bcw, err := opts.bundleConfigWriter()
+	if err != nil {
+		return err
+	}
+
+	b, err := opts.bundleFactory(opts.bundlePath)
+	if err != nil {
+		return fmt.Errorf("load bundle: %w", err)
+	}
+
+	config, err := updateCatalog(b.Config(), opts.catalogEntryNames, true)
+	if err != nil {
+		return fmt.Errorf("enable catalog entries: %w", err)
+	}
+
+	if err := bcw.Write(b, config); err != nil {
+		return fmt.Errorf("write bundle config: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,102 @@
+/*
+ * Copyright 2020 Sheaf Authors
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package sheaf_test
+
+import (
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bryanl/sheaf/internal/testutil"
+	"github.com/bryanl/sheaf/pkg/mocks"
+	"github.com/bryanl/sheaf/pkg/sheaf"
+)
+
+func TestConfigCatalogEnable(t *testing.T) {
+	genBundleFactory := func(t *testing.T, controller *gomock.Controller, config *mocks.MockBundleConfig) sheaf.BundleFactoryFunc {
+		bundle := testutil.GenerateBundle(t, controller,
+			testutil.BundleGeneratorConfig(config))
+		return func(string) (sheaf.Bundle, error) {
+			return bundle, nil
+		}
+	}
+
+	cases := []struct {
+		name          string
+		names         []string
+		bundleFactory bundleFactoryFunc
+		configWriter  func(controller *gomock.Controller) *mocks.MockBundleConfigWriter
+		wantErr       bool
+	}{
+		{
+			name:  "enable disabled entry",
+			names: []string{"tekton"},
+			bundleFactory: func(controller *gomock.Controller) sheaf.BundleFactoryFunc {
+				config := testutil.GenerateBundleConfig(controller)
+				config.EXPECT().GetCatalog().Return(sheaf.CatalogSelection{
+					Version:  sheaf.CatalogVersion,
+					Disabled: []string{"argo", "tekton"},
+				})
+				config.EXPECT().SetCatalog(sheaf.CatalogSelection{
+					Version:  sheaf.CatalogVersion,
+					Disabled: []string{"argo"},
+				})
+
+				return genBundleFactory(t, controller, config)
+			},
+			configWriter: successfulConfigWriter,
+		},
+		{
+			name:  "enable enabled entry",
+			names: []string{"knative"},
+			bundleFactory: func(controller *gomock.Controller) sheaf.BundleFactoryFunc {
+				config := testutil.GenerateBundleConfig(controller)
+				config.EXPECT().GetCatalog().Return(sheaf.CatalogSelection{})
+				config.EXPECT().SetCatalog(sheaf.CatalogSelection{
+					Version: sheaf.CatalogVersion,
+				})
+
+				return genBundleFactory(t, controller, config)
+			},
+			configWriter: successfulConfigWriter,
+		},
+		{
+			name:  "unknown catalog entry",
+			names: []string{"unknown"},
+			bundleFactory: func(controller *gomock.Controller) sheaf.BundleFactoryFunc {
+				config := testutil.GenerateBundleConfig(controller)
+				config.EXPECT().GetCatalog().Return(sheaf.CatalogSelection{})
+
+				return genBundleFactory(t, controller, config)
+			},
+			configWriter: noopConfigWriter,
+			wantErr:      true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			controller := gomock.NewController(t)
+			defer controller.Finish()
+
+			options := []sheaf.Option{
+				sheaf.WithCatalogEntryNames(tc.names),
+				sheaf.WithBundleFactory(tc.bundleFactory(controller)),
+				sheaf.WithBundleConfigWriter(tc.configWriter(controller)),
+			}
+
+			err := sheaf.ConfigCatalogEnable(options...)
+			if tc.wantErr {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+		})
+	}
+}
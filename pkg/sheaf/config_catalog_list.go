@@ -0,0 +1,41 @@
+/*
+ * Copyright 2020 Sheaf Authors
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package sheaf
+
+import (
+	"fmt"
+)
+
+// ConfigCatalogList lists the built-in user defined image catalog and
+// whether each entry is enabled for the bundle.
+func ConfigCatalogList(optionList ...Option) error {
+	opts := makeDefaultOptions(optionList...)
+
+	b, err := opts.bundleFactory(opts.bundlePath)
+	if err != nil {
+		return fmt.Errorf("load bundle: %w", err)
+	}
+
+	selection := b.Config().GetCatalog()
+
+	var list []CatalogEntryStatus
+	for _, entry := range Catalog() {
+		list = append(list, CatalogEntryStatus{
+			CatalogEntry: entry,
+			Enabled:      selection.IsEnabled(entry.Name),
+		})
+	}
+
+	data, err := opts.codec.Encode(list)
+	if err != nil {
+		return fmt.Errorf("encode catalog: %w", err)
+	}
+
+	fmt.Fprintln(opts.writer, string(data))
+
+	return nil
+}
@@ -0,0 +1,40 @@
+/*
+ * Copyright 2020 Sheaf Authors
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package sheaf
+
+import "fmt"
+
+// ConfigRemoveExclusion removes image exclusions from a bundle configuration.
+func ConfigRemoveExclusion(optionList ...Option) error {
+	opts := makeDefaultOptions(optionList...)
+
+	if len(opts.exclusions) == 0 {
+		return fmt.Errorf("exclusion is required")
+	}
+
+	bcw, err := opts.bundleConfigWriter()
+	if err != nil {
+		return err
+	}
+
+	b, err := opts.bundleFactory(opts.bundlePath)
+	if err != nil {
+		return fmt.Errorf("load bundle: %w", err)
+	}
+
+	config := updateImageExclusions(b.Config(), func(m imageExclusionMap) {
+		for _, exclusion := range opts.exclusions {
+			delete(m, exclusion)
+		}
+	})
+
+	if err := bcw.Write(b, config); err != nil {
+		return fmt.Errorf("write bundle config: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,104 @@
+/*
+ * Copyright 2020 Sheaf Authors
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package sheaf_test
+
+import (
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bryanl/sheaf/internal/testutil"
+	"github.com/bryanl/sheaf/pkg/mocks"
+	"github.com/bryanl/sheaf/pkg/sheaf"
+)
+
+func TestConfigRemoveExclusion(t *testing.T) {
+	genBundleFactory := func(t *testing.T, controller *gomock.Controller, config *mocks.MockBundleConfig) sheaf.BundleFactoryFunc {
+		bundle := testutil.GenerateBundle(t, controller,
+			testutil.BundleGeneratorConfig(config))
+		return func(string) (sheaf.Bundle, error) {
+			return bundle, nil
+		}
+	}
+
+	cases := []struct {
+		name          string
+		exclusions    []sheaf.ImageExclusion
+		bundleFactory bundleFactoryFunc
+		configWriter  func(controller *gomock.Controller) *mocks.MockBundleConfigWriter
+		wantErr       bool
+	}{
+		{
+			name:       "remove exclusion",
+			exclusions: []sheaf.ImageExclusion{{Registry: "quay.io"}},
+			bundleFactory: func(controller *gomock.Controller) sheaf.BundleFactoryFunc {
+				config := testutil.GenerateBundleConfig(controller)
+				config.EXPECT().GetImageExclusions().Return([]sheaf.ImageExclusion{{Image: "busybox"}, {Registry: "quay.io"}})
+				config.EXPECT().SetImageExclusions([]sheaf.ImageExclusion{{Image: "busybox"}})
+
+				return genBundleFactory(t, controller, config)
+			},
+			configWriter: successfulConfigWriter,
+		},
+		{
+			name:       "remove missing exclusion",
+			exclusions: []sheaf.ImageExclusion{{Registry: "quay.io"}},
+			bundleFactory: func(controller *gomock.Controller) sheaf.BundleFactoryFunc {
+				config := testutil.GenerateBundleConfig(controller)
+				config.EXPECT().GetImageExclusions().Return([]sheaf.ImageExclusion{{Image: "busybox"}})
+				config.EXPECT().SetImageExclusions([]sheaf.ImageExclusion{{Image: "busybox"}})
+
+				return genBundleFactory(t, controller, config)
+			},
+			configWriter: successfulConfigWriter,
+		},
+		{
+			name: "no exclusions",
+			bundleFactory: func(controller *gomock.Controller) sheaf.BundleFactoryFunc {
+				config := testutil.GenerateBundleConfig(controller)
+				return genBundleFactory(t, controller, config)
+			},
+			configWriter: noopConfigWriter,
+			wantErr:      true,
+		},
+		{
+			name:       "unable to write config",
+			exclusions: []sheaf.ImageExclusion{{Registry: "quay.io"}},
+			bundleFactory: func(controller *gomock.Controller) sheaf.BundleFactoryFunc {
+				config := testutil.GenerateBundleConfig(controller)
+				config.EXPECT().GetImageExclusions().Return(nil)
+				config.EXPECT().SetImageExclusions(gomock.Any())
+
+				return genBundleFactory(t, controller, config)
+			},
+			configWriter: errorConfigWriter,
+			wantErr:      true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			controller := gomock.NewController(t)
+			defer controller.Finish()
+
+			options := []sheaf.Option{
+				sheaf.WithImageExclusions(tc.exclusions),
+				sheaf.WithBundleFactory(tc.bundleFactory(controller)),
+				sheaf.WithBundleConfigWriter(tc.configWriter(controller)),
+			}
+
+			err := sheaf.ConfigRemoveExclusion(options...)
+			if tc.wantErr {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+		})
+	}
+}
@@ -0,0 +1,37 @@
+/*
+ * Copyright 2020 Sheaf Authors
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package sheaf
+
+import "fmt"
+
+// ConfigRemoveImage removes images from a bundle configuration.
+func ConfigRemoveImage(optionList ...Option) error {
+	opts := makeDefaultOptions(optionList...)
+
+	bcw, err := opts.bundleConfigWriter()
+	if err != nil {
+		return err
+	}
+
+	b, err := opts.bundleFactory(opts.bundlePath)
+	if err != nil {
+		return fmt.Errorf("load bundle: %w", err)
+	}
+
+	config, err := updateImages(b.Config(), opts.images, func(m imageMap, key, name string) {
+		delete(m, key)
+	})
+	if err != nil {
+		return fmt.Errorf("remove images: %w", err)
+	}
+
+	if err := bcw.Write(b, config); err != nil {
+		return fmt.Errorf("write bundle config: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,41 @@
+/*
+ * Copyright 2020 Sheaf Authors
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package sheaf
+
+import "fmt"
+
+// ConfigSetMappingStrategy sets the repository mapping strategy in a bundle configuration.
+func ConfigSetMappingStrategy(optionList ...Option) error {
+	opts := makeDefaultOptions(optionList...)
+
+	if opts.mappingStrategy.IsZero() {
+		return fmt.Errorf("mapping strategy is required")
+	}
+
+	if err := opts.mappingStrategy.Validate(); err != nil {
+		return fmt.Errorf("invalid mapping strategy: %w", err)
+	}
+
+	bcw, err := opts.bundleConfigWriter()
+	if err != nil {
+		return err
+	}
+
+	b, err := opts.bundleFactory(opts.bundlePath)
+	if err != nil {
+		return fmt.Errorf("load bundle: %w", err)
+	}
+
+	config := b.Config()
+	config.SetMappingStrategy(opts.mappingStrategy)
+
+	if err := bcw.Write(b, config); err != nil {
+		return fmt.Errorf("write bundle config: %w", err)
+	}
+
+	return nil
+}
@@ -7,18 +7,22 @@
 package sheaf
 
 import (
+	"context"
+
 	"github.com/pivotal/image-relocation/pkg/image"
 )
 
 //go:generate mockgen -destination=../mocks/mock_artifacts_service.go -package mocks github.com/bryanl/sheaf/pkg/sheaf ArtifactsService
 //go:generate mockgen -destination=../mocks/mock_image_service.go -package mocks github.com/bryanl/sheaf/pkg/sheaf ImageService
 //go:generate mockgen -destination=../mocks/mock_image_relocator.go -package mocks github.com/bryanl/sheaf/pkg/sheaf ImageRelocator
+//go:generate mockgen -destination=../mocks/mock_registry_server.go -package mocks github.com/bryanl/sheaf/pkg/sheaf RegistryServer
 
 // BundleImage is an image in a fs.
 type BundleImage struct {
-	Name      string `json:"name"`
-	Digest    string `json:"digest"`
-	MediaType string `json:"mediaType"`
+	Name      string   `json:"name"`
+	Digest    string   `json:"digest"`
+	MediaType string   `json:"mediaType"`
+	Platforms []string `json:"platforms,omitempty"`
 }
 
 // ImageService returns a list of fs artifact images.
@@ -29,10 +33,21 @@ type ImageService interface {
 // ArtifactsService interacts with fs artifacts.
 type ArtifactsService interface {
 	Index() ([]byte, error)
+	Blob(digest string) ([]byte, error)
 	Image() ImageService
 }
 
 // ImageRelocator relocates an images to another registry.
 type ImageRelocator interface {
-	Relocate(rootPath, prefix string, images []image.Name, iw ImageWriter) error
+	// Relocate relocates images to the names created by a mapping. Images
+	// excluded by the bundle config are skipped. It returns a mapping of
+	// original image references to relocated image references.
+	Relocate(rootPath string, mapping ImageMapping, config BundleConfig, images []image.Name, iw ImageWriter) (RelocationMapping, error)
+}
+
+// RegistryServer serves the images in a bundle as a read-only registry.
+type RegistryServer interface {
+	// Serve serves the images in the bundle at rootPath on addr until the
+	// context is done.
+	Serve(ctx context.Context, rootPath, addr string) error
 }
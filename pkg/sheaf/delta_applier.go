@@ -0,0 +1,22 @@
+/*
+ * Copyright 2020 Sheaf Authors
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package sheaf
+
+//go:generate mockgen -destination=../mocks/mock_delta_applier.go -package mocks github.com/bryanl/sheaf/pkg/sheaf DeltaApplier
+
+// DeltaApplier applies delta archives. A delta archive holds only the blobs
+// missing from the base archive it was packed against.
+type DeltaApplier interface {
+	// RequiredBase returns the file name of the base archive of an exploded
+	// delta archive that still needs its base. It returns an empty string if
+	// the archive is not a delta archive or the base was already applied.
+	RequiredBase(b Bundle) (string, error)
+	// ApplyDelta adds the blobs an exploded delta archive takes from its base
+	// archive. It fails if base is not the archive the delta was packed
+	// against.
+	ApplyDelta(b Bundle, base string) error
+}
@@ -0,0 +1,161 @@
+/*
+ * Copyright 2020 Sheaf Authors
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package sheaf
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/pivotal/image-relocation/pkg/image"
+	"github.com/pivotal/image-relocation/pkg/images"
+)
+
+// ImageExclusion excludes images from a bundle. Exactly one of its fields
+// must be set.
+type ImageExclusion struct {
+	// Image excludes an exact image reference.
+	Image string `json:"image,omitempty"`
+	// Repository excludes repositories matching a glob. The glob is matched
+	// against the fully qualified repository name, e.g. docker.io/library/nginx.
+	Repository string `json:"repository,omitempty"`
+	// Registry excludes all images hosted by a registry.
+	Registry string `json:"registry,omitempty"`
+}
+
+// Validate validates an image exclusion.
+func (ie ImageExclusion) Validate() error {
+	set := 0
+	for _, s := range []string{ie.Image, ie.Repository, ie.Registry} {
+		if s != "" {
+			set++
+		}
+	}
+
+	if set != 1 {
+		return fmt.Errorf("exactly one of image, repository, or registry is required")
+	}
+
+	if ie.Image != "" {
+		if _, err := image.NewName(ie.Image); err != nil {
+			return err
+		}
+	}
+
+	if ie.Repository != "" {
+		if _, err := path.Match(ie.Repository, ""); err != nil {
+			return fmt.Errorf("invalid repository glob %q: %w", ie.Repository, err)
+		}
+	}
+
+	return nil
+}
+
+// String returns a string representation of the exclusion.
+func (ie ImageExclusion) String() string {
+	switch {
+	case ie.Image != "":
+		return "image " + ie.Image
+	case ie.Repository != "":
+		return "repository " + ie.Repository
+	default:
+		return "registry " + ie.Registry
+	}
+}
+
+// Matches returns true if the exclusion matches an image name.
+func (ie ImageExclusion) Matches(n image.Name) bool {
+	switch {
+	case ie.Image != "":
+		excluded, err := image.NewName(ie.Image)
+		if err != nil {
+			return false
+		}
+
+		if n.String() == excluded.String() {
+			return true
+		}
+
+		// A pinned image is still excluded by the reference it was pinned from.
+		return excluded.Digest() == image.EmptyDigest && n.WithoutDigest().String() == excluded.String()
+	case ie.Repository != "":
+		if matched, _ := path.Match(ie.Repository, n.Name()); matched {
+			return true
+		}
+
+		excluded, err := image.NewName(ie.Repository)
+		if err != nil {
+			return false
+		}
+
+		return excluded.Name() == n.Name()
+	case ie.Registry != "":
+		return strings.EqualFold(ie.Registry, n.Host())
+	default:
+		return false
+	}
+}
+
+// ImageExclusions is a list of image exclusions.
+type ImageExclusions []ImageExclusion
+
+// Excludes returns true if any exclusion matches an image name.
+func (list ImageExclusions) Excludes(n image.Name) bool {
+	for _, ie := range list {
+		if ie.Matches(n) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Filter splits a set of images into included and excluded images.
+func (list ImageExclusions) Filter(set images.Set) (included images.Set, excluded images.Set, err error) {
+	var includedNames, excludedNames []string
+	for _, n := range set.Slice() {
+		if list.Excludes(n) {
+			excludedNames = append(excludedNames, n.String())
+			continue
+		}
+
+		includedNames = append(includedNames, n.String())
+	}
+
+	if included, err = images.New(includedNames...); err != nil {
+		return images.Empty, images.Empty, err
+	}
+
+	if excluded, err = images.New(excludedNames...); err != nil {
+		return images.Empty, images.Empty, err
+	}
+
+	return included, excluded, nil
+}
+
+type imageExclusionMap map[ImageExclusion]bool
+
+func updateImageExclusions(config BundleConfig, fn func(m imageExclusionMap)) BundleConfig {
+	m := imageExclusionMap{}
+	for _, cur := range config.GetImageExclusions() {
+		m[cur] = true
+	}
+
+	fn(m)
+
+	var list []ImageExclusion
+	for ie := range m {
+		list = append(list, ie)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].String() < list[j].String()
+	})
+
+	config.SetImageExclusions(list)
+	return config
+}
@@ -0,0 +1,152 @@
+/*
+ * Copyright 2020 Sheaf Authors
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package sheaf_test
+
+import (
+	"testing"
+
+	"github.com/pivotal/image-relocation/pkg/image"
+	"github.com/pivotal/image-relocation/pkg/images"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bryanl/sheaf/pkg/sheaf"
+)
+
+func TestImageExclusion_Validate(t *testing.T) {
+	cases := []struct {
+		name      string
+		exclusion sheaf.ImageExclusion
+		wantErr   bool
+	}{
+		{
+			name:      "image",
+			exclusion: sheaf.ImageExclusion{Image: "nginx:1.17"},
+		},
+		{
+			name:      "repository",
+			exclusion: sheaf.ImageExclusion{Repository: "gcr.io/project/*"},
+		},
+		{
+			name:      "registry",
+			exclusion: sheaf.ImageExclusion{Registry: "quay.io"},
+		},
+		{
+			name:    "empty",
+			wantErr: true,
+		},
+		{
+			name:      "multiple fields",
+			exclusion: sheaf.ImageExclusion{Image: "nginx:1.17", Registry: "quay.io"},
+			wantErr:   true,
+		},
+		{
+			name:      "invalid image",
+			exclusion: sheaf.ImageExclusion{Image: "INVALID"},
+			wantErr:   true,
+		},
+		{
+			name:      "invalid glob",
+			exclusion: sheaf.ImageExclusion{Repository: "gcr.io/[project"},
+			wantErr:   true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.exclusion.Validate()
+			if tc.wantErr {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestImageExclusion_Matches(t *testing.T) {
+	cases := []struct {
+		name      string
+		exclusion sheaf.ImageExclusion
+		image     string
+		expected  bool
+	}{
+		{
+			name:      "exact image",
+			exclusion: sheaf.ImageExclusion{Image: "nginx:1.17"},
+			image:     "docker.io/library/nginx:1.17",
+			expected:  true,
+		},
+		{
+			name:      "exact image with different tag",
+			exclusion: sheaf.ImageExclusion{Image: "nginx:1.17"},
+			image:     "nginx:1.18",
+		},
+		{
+			name:      "exact image pinned to a digest",
+			exclusion: sheaf.ImageExclusion{Image: "nginx:1.17"},
+			image:     "nginx:1.17@" + testDigest1,
+			expected:  true,
+		},
+		{
+			name:      "repository glob",
+			exclusion: sheaf.ImageExclusion{Repository: "gcr.io/project/test-*"},
+			image:     "gcr.io/project/test-hook:1.0",
+			expected:  true,
+		},
+		{
+			name:      "repository glob does not cross path separators",
+			exclusion: sheaf.ImageExclusion{Repository: "gcr.io/project/*"},
+			image:     "gcr.io/project/nested/hook:1.0",
+		},
+		{
+			name:      "repository without glob is normalized",
+			exclusion: sheaf.ImageExclusion{Repository: "nginx"},
+			image:     "docker.io/library/nginx:1.17",
+			expected:  true,
+		},
+		{
+			name:      "registry",
+			exclusion: sheaf.ImageExclusion{Registry: "registry.example.com"},
+			image:     "registry.example.com/app/web:1.0",
+			expected:  true,
+		},
+		{
+			name:      "different registry",
+			exclusion: sheaf.ImageExclusion{Registry: "registry.example.com"},
+			image:     "nginx:1.17",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			n, err := image.NewName(tc.image)
+			require.NoError(t, err)
+
+			require.Equal(t, tc.expected, tc.exclusion.Matches(n))
+		})
+	}
+}
+
+func TestImageExclusions_Filter(t *testing.T) {
+	exclusions := sheaf.ImageExclusions{
+		{Image: "busybox:1.31"},
+		{Registry: "example.com"},
+	}
+
+	set, err := images.New("nginx:1.17", "busybox:1.31", "example.com/test/hook:1.0")
+	require.NoError(t, err)
+
+	included, excluded, err := exclusions.Filter(set)
+	require.NoError(t, err)
+
+	require.Equal(t, []string{"docker.io/library/nginx:1.17"}, included.Strings())
+	require.Equal(t, []string{
+		"docker.io/library/busybox:1.31",
+		"example.com/test/hook:1.0",
+	}, excluded.Strings())
+}
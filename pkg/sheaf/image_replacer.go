@@ -6,10 +6,52 @@
 
 package sheaf
 
+import (
+	"fmt"
+
+	"github.com/pivotal/image-relocation/pkg/image"
+)
+
 //go:generate mockgen -destination=../mocks/mock_image_replacer.go -package mocks github.com/bryanl/sheaf/pkg/sheaf ImageReplacer
 
+// ImageMapping maps an image name to a new image name.
+type ImageMapping func(originalImage image.Name) (image.Name, error)
+
 // ImageReplacer is an interface that wraps images replacing.
 type ImageReplacer interface {
-	// Replace replaces images in a bundle manifest with prefixed options.
-	Replace(manifest BundleManifest, config BundleConfig, prefix string) ([]byte, error)
+	// Replace replaces images in a bundle manifest using a mapping. A nil
+	// mapping leaves the manifest unchanged.
+	Replace(manifest BundleManifest, config BundleConfig, mapping ImageMapping) ([]byte, error)
+}
+
+// manifestImageMapping creates a mapping that pins images to their locked
+// digests and then relocates them. If locked is true, images missing from the
+// lock are an error. Excluded images are left unchanged. A nil relocate
+// leaves pinned images where they are. It returns nil if there is nothing to
+// map.
+func manifestImageMapping(lock BundleLock, locked bool, exclusions ImageExclusions, relocate ImageMapping) ImageMapping {
+	if lock.IsEmpty() && !locked && relocate == nil {
+		return nil
+	}
+
+	return func(originalImage image.Name) (image.Name, error) {
+		if exclusions.Excludes(originalImage) {
+			return originalImage, nil
+		}
+
+		newImage, ok, err := lock.Pin(originalImage)
+		if err != nil {
+			return image.EmptyName, err
+		}
+
+		if !ok && locked {
+			return image.EmptyName, fmt.Errorf("bundle lock is stale: %s is not locked", originalImage)
+		}
+
+		if relocate == nil {
+			return newImage, nil
+		}
+
+		return relocate(newImage)
+	}
 }
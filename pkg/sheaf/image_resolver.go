@@ -0,0 +1,17 @@
+/*
+ * Copyright 2020 Sheaf Authors
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package sheaf
+
+import "github.com/pivotal/image-relocation/pkg/image"
+
+//go:generate mockgen -destination=../mocks/mock_image_resolver.go -package mocks github.com/bryanl/sheaf/pkg/sheaf ImageResolver
+
+// ImageResolver is an interface that wraps resolving image names to digests.
+type ImageResolver interface {
+	// Resolve resolves an image name to the digest it currently points at.
+	Resolve(n image.Name) (image.Digest, error)
+}
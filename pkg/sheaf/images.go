@@ -0,0 +1,60 @@
+/*
+ * Copyright 2020 Sheaf Authors
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package sheaf
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/pivotal/image-relocation/pkg/image"
+)
+
+// imageMap maps a normalized image name to the name as it was written.
+type imageMap map[string]string
+
+func updateImages(config BundleConfig, names []string, fn func(m imageMap, key, name string)) (BundleConfig, error) {
+	if len(names) == 0 {
+		return nil, fmt.Errorf("image name is required")
+	}
+
+	m := imageMap{}
+	for _, cur := range config.GetImages() {
+		key, err := imageKey(cur)
+		if err != nil {
+			return nil, err
+		}
+
+		m[key] = cur
+	}
+
+	for _, name := range names {
+		key, err := imageKey(name)
+		if err != nil {
+			return nil, err
+		}
+
+		fn(m, key, name)
+	}
+
+	var list []string
+	for _, name := range m {
+		list = append(list, name)
+	}
+	sort.Strings(list)
+
+	config.SetImages(list)
+	return config, nil
+}
+
+func imageKey(name string) (string, error) {
+	n, err := image.NewName(name)
+	if err != nil {
+		return "", err
+	}
+
+	return n.String(), nil
+}
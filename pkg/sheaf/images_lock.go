@@ -0,0 +1,56 @@
+/*
+ * Copyright 2020 Sheaf Authors
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package sheaf
+
+import (
+	"fmt"
+
+	"github.com/pivotal/image-relocation/pkg/image"
+)
+
+// ImagesLock resolves the images in a bundle to digests and writes them to
+// the bundle lock.
+func ImagesLock(optionList ...Option) error {
+	opts := makeDefaultOptions(optionList...)
+
+	if opts.imageResolver == nil {
+		return fmt.Errorf("image resolver is not configured")
+	}
+
+	b, err := opts.bundleFactory(opts.bundlePath)
+	if err != nil {
+		return fmt.Errorf("load bundle: %w", err)
+	}
+
+	list, err := b.Images()
+	if err != nil {
+		return fmt.Errorf("load images from bundle: %w", err)
+	}
+
+	opts.reporter.Header("Resolving image digests")
+
+	digests := map[image.Name]image.Digest{}
+	for _, n := range list.Slice() {
+		if n.Digest() != image.EmptyDigest {
+			continue
+		}
+
+		digest, err := opts.imageResolver.Resolve(n)
+		if err != nil {
+			return fmt.Errorf("resolve digest for %s: %w", n, err)
+		}
+
+		opts.reporter.Reportf("%s: %s", n, digest)
+		digests[n] = digest
+	}
+
+	if err := b.WriteLock(NewBundleLock(digests)); err != nil {
+		return fmt.Errorf("write bundle lock: %w", err)
+	}
+
+	return nil
+}
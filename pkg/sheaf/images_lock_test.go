@@ -0,0 +1,108 @@
+/*
+ * Copyright 2020 Sheaf Authors
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package sheaf_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/pivotal/image-relocation/pkg/image"
+	"github.com/pivotal/image-relocation/pkg/images"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bryanl/sheaf/pkg/mocks"
+	"github.com/bryanl/sheaf/pkg/reporter"
+	"github.com/bryanl/sheaf/pkg/sheaf"
+)
+
+func TestImagesLock(t *testing.T) {
+	genBundleFactory := func(t *testing.T, controller *gomock.Controller, wantLock *sheaf.BundleLock) sheaf.BundleFactoryFunc {
+		set, err := images.New("nginx:1.17", "redis@"+testDigest2)
+		require.NoError(t, err)
+
+		bundle := mocks.NewMockBundle(controller)
+		bundle.EXPECT().Images().Return(set, nil)
+		if wantLock != nil {
+			bundle.EXPECT().WriteLock(*wantLock).Return(nil)
+		}
+
+		return func(string) (sheaf.Bundle, error) {
+			return bundle, nil
+		}
+	}
+
+	cases := []struct {
+		name          string
+		bundleFactory bundleFactoryFunc
+		imageResolver func(controller *gomock.Controller) sheaf.ImageResolver
+		wantErr       bool
+	}{
+		{
+			name: "in general",
+			bundleFactory: func(controller *gomock.Controller) sheaf.BundleFactoryFunc {
+				lock := genBundleLock(t, "nginx:1.17", testDigest1)
+				return genBundleFactory(t, controller, &lock)
+			},
+			imageResolver: func(controller *gomock.Controller) sheaf.ImageResolver {
+				n, err := image.NewName("nginx:1.17")
+				require.NoError(t, err)
+				d, err := image.NewDigest(testDigest1)
+				require.NoError(t, err)
+
+				ir := mocks.NewMockImageResolver(controller)
+				ir.EXPECT().Resolve(n).Return(d, nil)
+				return ir
+			},
+		},
+		{
+			name: "resolve fails",
+			bundleFactory: func(controller *gomock.Controller) sheaf.BundleFactoryFunc {
+				return genBundleFactory(t, controller, nil)
+			},
+			imageResolver: func(controller *gomock.Controller) sheaf.ImageResolver {
+				ir := mocks.NewMockImageResolver(controller)
+				ir.EXPECT().Resolve(gomock.Any()).Return(image.EmptyDigest, fmt.Errorf("error"))
+				return ir
+			},
+			wantErr: true,
+		},
+		{
+			name: "requires image resolver",
+			bundleFactory: func(controller *gomock.Controller) sheaf.BundleFactoryFunc {
+				return func(string) (sheaf.Bundle, error) {
+					return nil, fmt.Errorf("unused")
+				}
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			controller := gomock.NewController(t)
+			defer controller.Finish()
+
+			options := []sheaf.Option{
+				sheaf.WithBundleFactory(tc.bundleFactory(controller)),
+				sheaf.WithReporter(reporter.Nop{}),
+			}
+
+			if tc.imageResolver != nil {
+				options = append(options, sheaf.WithImageResolver(tc.imageResolver(controller)))
+			}
+
+			err := sheaf.ImagesLock(options...)
+			if tc.wantErr {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+		})
+	}
+}
@@ -26,5 +26,57 @@ func ManifestAdd(optionList ...Option) error {
 		return fmt.Errorf("unable to add files: %w", err)
 	}
 
+	var sources []ManifestSource
+
+	if opts.helmChart.Chart != "" {
+		source, err := newHelmManifestSource(b.Path(), opts.helmChart)
+		if err != nil {
+			return fmt.Errorf("create helm manifest source: %w", err)
+		}
+
+		sources = append(sources, source)
+	}
+
+	if opts.kustomization != "" {
+		source, err := newKustomizeManifestSource(b.Path(), opts.kustomization)
+		if err != nil {
+			return fmt.Errorf("create kustomize manifest source: %w", err)
+		}
+
+		sources = append(sources, source)
+	}
+
+	if len(sources) == 0 {
+		return nil
+	}
+
+	return addManifestSources(opts, b, ms, sources...)
+}
+
+func addManifestSources(opts options, b Bundle, ms ManifestService, sources ...ManifestSource) error {
+	bcw, err := opts.bundleConfigWriter()
+	if err != nil {
+		return err
+	}
+
+	config := b.Config()
+
+	for _, source := range sources {
+		// Sources are checked before they are rendered, so a manifest
+		// rendered from another source is not overwritten.
+		config, err = updateManifestSources(config, source)
+		if err != nil {
+			return fmt.Errorf("unable to add %s source %s: %w", source.Type, source.Path, err)
+		}
+
+		if err := ms.AddSource(opts.force, source.ResolvePath(b.Path())); err != nil {
+			return fmt.Errorf("unable to add %s source %s: %w", source.Type, source.Path, err)
+		}
+	}
+
+	if err := bcw.Write(b, config); err != nil {
+		return fmt.Errorf("write bundle config: %w", err)
+	}
+
 	return nil
 }
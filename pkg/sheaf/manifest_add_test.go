@@ -8,6 +8,9 @@ package sheaf_test
 
 import (
 	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/golang/mock/gomock"
@@ -18,6 +21,168 @@ import (
 	"github.com/bryanl/sheaf/pkg/sheaf"
 )
 
+func TestManifestAdd_helm_chart(t *testing.T) {
+	testutil.WithBundleDir(t, func(dir string) {
+		chartDir := filepath.Join(dir, "charts", "app")
+		require.NoError(t, os.MkdirAll(chartDir, 0700))
+		require.NoError(t, ioutil.WriteFile(filepath.Join(chartDir, "Chart.yaml"), []byte("name: app"), 0600))
+
+		valuesPath := filepath.Join(dir, "values.yaml")
+		require.NoError(t, ioutil.WriteFile(valuesPath, []byte("replicas: 2"), 0600))
+
+		controller := gomock.NewController(t)
+		defer controller.Finish()
+
+		wanted := sheaf.ManifestSource{
+			Type:     sheaf.HelmManifestSource,
+			Path:     "charts/app",
+			Manifest: "release.yaml",
+			Helm: &sheaf.HelmOptions{
+				ReleaseName: "release",
+				Namespace:   "apps",
+				Values:      []map[string]interface{}{{"replicas": float64(2)}},
+			},
+		}
+
+		resolved := wanted
+		resolved.Path = chartDir
+
+		config := testutil.GenerateBundleConfig(controller)
+		config.EXPECT().GetManifestSources().Return(nil)
+		config.EXPECT().SetManifestSources([]sheaf.ManifestSource{wanted})
+
+		ms := mocks.NewMockManifestService(controller)
+		ms.EXPECT().Add(false).Return(nil)
+		ms.EXPECT().AddSource(false, resolved).Return(nil)
+
+		bundle := mocks.NewMockBundle(controller)
+		bundle.EXPECT().Path().Return(dir).AnyTimes()
+		bundle.EXPECT().Config().Return(config).AnyTimes()
+		bundle.EXPECT().Manifests().Return(ms, nil)
+
+		err := sheaf.ManifestAdd(
+			sheaf.WithBundleFactory(func(string) (sheaf.Bundle, error) {
+				return bundle, nil
+			}),
+			sheaf.WithHelmChart(sheaf.HelmChart{
+				Chart:       chartDir,
+				ValuesFiles: []string{valuesPath},
+				ReleaseName: "release",
+				Namespace:   "apps",
+			}),
+			sheaf.WithBundleConfigWriter(successfulConfigWriter(controller)))
+		require.NoError(t, err)
+	})
+}
+
+func TestManifestAdd_kustomization(t *testing.T) {
+	testutil.WithBundleDir(t, func(dir string) {
+		kustomizationDir := filepath.Join(dir, "envB", "overlay")
+		require.NoError(t, os.MkdirAll(kustomizationDir, 0700))
+		require.NoError(t, ioutil.WriteFile(filepath.Join(kustomizationDir, "kustomization.yaml"), []byte("resources: []"), 0600))
+
+		controller := gomock.NewController(t)
+		defer controller.Finish()
+
+		// Overlays with the same directory name render different manifests.
+		existing := sheaf.ManifestSource{
+			Type:     sheaf.KustomizeManifestSource,
+			Path:     "envA/overlay",
+			Manifest: "envA-overlay.yaml",
+		}
+
+		wanted := sheaf.ManifestSource{
+			Type:     sheaf.KustomizeManifestSource,
+			Path:     "envB/overlay",
+			Manifest: "envB-overlay.yaml",
+		}
+
+		resolved := wanted
+		resolved.Path = kustomizationDir
+
+		config := testutil.GenerateBundleConfig(controller)
+		config.EXPECT().GetManifestSources().Return([]sheaf.ManifestSource{existing})
+		config.EXPECT().SetManifestSources([]sheaf.ManifestSource{existing, wanted})
+
+		ms := mocks.NewMockManifestService(controller)
+		ms.EXPECT().Add(false).Return(nil)
+		ms.EXPECT().AddSource(false, resolved).Return(nil)
+
+		bundle := mocks.NewMockBundle(controller)
+		bundle.EXPECT().Path().Return(dir).AnyTimes()
+		bundle.EXPECT().Config().Return(config).AnyTimes()
+		bundle.EXPECT().Manifests().Return(ms, nil)
+
+		err := sheaf.ManifestAdd(
+			sheaf.WithBundleFactory(func(string) (sheaf.Bundle, error) {
+				return bundle, nil
+			}),
+			sheaf.WithKustomization(kustomizationDir),
+			sheaf.WithBundleConfigWriter(successfulConfigWriter(controller)))
+		require.NoError(t, err)
+	})
+}
+
+func TestManifestAdd_kustomization_collision(t *testing.T) {
+	testutil.WithBundleDir(t, func(dir string) {
+		kustomizationDir := filepath.Join(dir, "app")
+		require.NoError(t, os.MkdirAll(kustomizationDir, 0700))
+		require.NoError(t, ioutil.WriteFile(filepath.Join(kustomizationDir, "kustomization.yaml"), []byte("resources: []"), 0600))
+
+		controller := gomock.NewController(t)
+		defer controller.Finish()
+
+		config := testutil.GenerateBundleConfig(controller)
+		config.EXPECT().GetManifestSources().Return([]sheaf.ManifestSource{
+			{
+				Type:     sheaf.HelmManifestSource,
+				Path:     "charts/app",
+				Manifest: "app.yaml",
+				Helm:     &sheaf.HelmOptions{ReleaseName: "app"},
+			},
+		})
+
+		// The manifest rendered from the chart is not overwritten.
+		ms := mocks.NewMockManifestService(controller)
+		ms.EXPECT().Add(false).Return(nil)
+
+		bundle := mocks.NewMockBundle(controller)
+		bundle.EXPECT().Path().Return(dir).AnyTimes()
+		bundle.EXPECT().Config().Return(config).AnyTimes()
+		bundle.EXPECT().Manifests().Return(ms, nil)
+
+		err := sheaf.ManifestAdd(
+			sheaf.WithBundleFactory(func(string) (sheaf.Bundle, error) {
+				return bundle, nil
+			}),
+			sheaf.WithKustomization(kustomizationDir),
+			sheaf.WithBundleConfigWriter(mocks.NewMockBundleConfigWriter(controller)))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "manifest app.yaml is already rendered from helm source charts/app")
+	})
+}
+
+func TestManifestAdd_kustomization_missing_file(t *testing.T) {
+	testutil.WithBundleDir(t, func(dir string) {
+		controller := gomock.NewController(t)
+		defer controller.Finish()
+
+		ms := mocks.NewMockManifestService(controller)
+		ms.EXPECT().Add(false).Return(nil)
+
+		bundle := mocks.NewMockBundle(controller)
+		bundle.EXPECT().Path().Return(dir).AnyTimes()
+		bundle.EXPECT().Manifests().Return(ms, nil)
+
+		err := sheaf.ManifestAdd(
+			sheaf.WithBundleFactory(func(string) (sheaf.Bundle, error) {
+				return bundle, nil
+			}),
+			sheaf.WithKustomization(dir))
+		require.Error(t, err)
+	})
+}
+
 func TestManifestAdd(t *testing.T) {
 	genBundleFactory := func(t *testing.T, controller *gomock.Controller, ms *mocks.MockManifestService) sheaf.BundleFactoryFunc {
 		bundle := testutil.GenerateBundle(t, controller,
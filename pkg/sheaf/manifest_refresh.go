@@ -0,0 +1,32 @@
+/*
+ * Copyright 2020 Sheaf Authors
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package sheaf
+
+import "fmt"
+
+// ManifestRefresh renders the manifest sources recorded in a bundle config again.
+func ManifestRefresh(optionList ...Option) error {
+	opts := makeDefaultOptions(optionList...)
+
+	b, err := opts.bundleFactory(opts.bundlePath)
+	if err != nil {
+		return fmt.Errorf("load bundle: %w", err)
+	}
+
+	ms, err := b.Manifests()
+	if err != nil {
+		return fmt.Errorf("get manifests service: %w", err)
+	}
+
+	for _, source := range b.Config().GetManifestSources() {
+		if err := ms.AddSource(true, source.ResolvePath(b.Path())); err != nil {
+			return fmt.Errorf("unable to refresh %s source %s: %w", source.Type, source.Path, err)
+		}
+	}
+
+	return nil
+}
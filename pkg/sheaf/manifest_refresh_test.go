@@ -0,0 +1,105 @@
+/*
+ * Copyright 2020 Sheaf Authors
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package sheaf_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bryanl/sheaf/internal/testutil"
+	"github.com/bryanl/sheaf/pkg/mocks"
+	"github.com/bryanl/sheaf/pkg/sheaf"
+)
+
+func TestManifestRefresh(t *testing.T) {
+	sources := []sheaf.ManifestSource{
+		{
+			Type:     sheaf.HelmManifestSource,
+			Path:     "charts/app",
+			Manifest: "app.yaml",
+			Helm:     &sheaf.HelmOptions{ReleaseName: "app"},
+		},
+		{
+			Type:     sheaf.KustomizeManifestSource,
+			Path:     "/overlays/prod",
+			Manifest: "prod.yaml",
+		},
+	}
+
+	genBundleFactory := func(controller *gomock.Controller, ms sheaf.ManifestService) sheaf.BundleFactoryFunc {
+		config := testutil.GenerateBundleConfig(controller)
+		config.EXPECT().GetManifestSources().Return(sources).AnyTimes()
+
+		bundle := mocks.NewMockBundle(controller)
+		bundle.EXPECT().Path().Return("/bundle").AnyTimes()
+		bundle.EXPECT().Config().Return(config).AnyTimes()
+		bundle.EXPECT().Manifests().Return(ms, nil)
+
+		return func(string) (sheaf.Bundle, error) {
+			return bundle, nil
+		}
+	}
+
+	cases := []struct {
+		name          string
+		bundleFactory bundleFactoryFunc
+		wantErr       bool
+	}{
+		{
+			name: "in general",
+			bundleFactory: func(controller *gomock.Controller) sheaf.BundleFactoryFunc {
+				helm := sources[0]
+				helm.Path = "/bundle/charts/app"
+
+				ms := mocks.NewMockManifestService(controller)
+				gomock.InOrder(
+					ms.EXPECT().AddSource(true, helm).Return(nil),
+					ms.EXPECT().AddSource(true, sources[1]).Return(nil))
+
+				return genBundleFactory(controller, ms)
+			},
+		},
+		{
+			name: "unable to render source",
+			bundleFactory: func(controller *gomock.Controller) sheaf.BundleFactoryFunc {
+				ms := mocks.NewMockManifestService(controller)
+				ms.EXPECT().AddSource(true, gomock.Any()).Return(fmt.Errorf("error"))
+
+				return genBundleFactory(controller, ms)
+			},
+			wantErr: true,
+		},
+		{
+			name: "unable to load bundle",
+			bundleFactory: func(controller *gomock.Controller) sheaf.BundleFactoryFunc {
+				return func(string) (sheaf.Bundle, error) {
+					return nil, fmt.Errorf("error")
+				}
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			controller := gomock.NewController(t)
+			defer controller.Finish()
+
+			err := sheaf.ManifestRefresh(
+				sheaf.WithBundleFactory(tc.bundleFactory(controller)))
+			if tc.wantErr {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+		})
+	}
+}
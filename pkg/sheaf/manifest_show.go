@@ -19,13 +19,48 @@ func ManifestShow(optionList ...Option) error {
 		return fmt.Errorf("image replacer is not configured")
 	}
 
+	if opts.mappingPath != "" && opts.repositoryPrefix != "" {
+		return fmt.Errorf("a relocation mapping and a repository prefix can't be used together")
+	}
+
 	b, err := opts.bundleFactory(opts.bundlePath)
 	if err != nil {
 		return fmt.Errorf("load bundle: %w", err)
 	}
 
+	if opts.locked {
+		if err := checkBundleLock(b); err != nil {
+			return err
+		}
+	}
+
 	config := b.Config()
 
+	lock, err := b.Lock()
+	if err != nil {
+		return fmt.Errorf("load bundle lock: %w", err)
+	}
+
+	var relocate ImageMapping
+	switch {
+	case opts.mappingPath != "":
+		rm, err := ReadRelocationMapping(opts.mappingPath)
+		if err != nil {
+			return fmt.Errorf("read relocation mapping: %w", err)
+		}
+
+		relocate = rm.ImageMapping()
+	case opts.repositoryPrefix != "":
+		strategy := resolveMappingStrategy(opts, config)
+		relocate, err = strategy.ImageMapping(opts.repositoryPrefix)
+		if err != nil {
+			return fmt.Errorf("mapping strategy: %w", err)
+		}
+	}
+
+	mapping := manifestImageMapping(lock, opts.locked,
+		ImageExclusions(config.GetImageExclusions()), relocate)
+
 	ms, err := b.Manifests()
 	if err != nil {
 		return fmt.Errorf("get manifests service: %w", err)
@@ -37,7 +72,7 @@ func ManifestShow(optionList ...Option) error {
 	}
 
 	for i, manifest := range manifests {
-		data, err := opts.imageReplacer.Replace(manifest, config, opts.repositoryPrefix)
+		data, err := opts.imageReplacer.Replace(manifest, config, mapping)
 		if err != nil {
 			return fmt.Errorf("update manifest %s: %w", manifest.ID, err)
 		}
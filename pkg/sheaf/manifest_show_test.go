@@ -9,9 +9,15 @@ package sheaf_test
 import (
 	"bytes"
 	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/golang/mock/gomock"
+	"github.com/pivotal/image-relocation/pkg/image"
+	"github.com/pivotal/image-relocation/pkg/images"
 	"github.com/stretchr/testify/require"
 
 	"github.com/bryanl/sheaf/internal/testutil"
@@ -20,12 +26,23 @@ import (
 )
 
 func TestManifestShow(t *testing.T) {
-	genBundleFactory := func(t *testing.T, controller *gomock.Controller, names []string) sheaf.BundleFactoryFunc {
+	nginx, err := image.NewName("nginx:1.17")
+	require.NoError(t, err)
+
+	genConfig := func(controller *gomock.Controller, exclusions ...sheaf.ImageExclusion) sheaf.BundleConfig {
+		config := testutil.GenerateBundleConfig(controller)
+		config.EXPECT().GetImageExclusions().Return(exclusions).AnyTimes()
+		config.EXPECT().GetMappingStrategy().Return(sheaf.MappingStrategy{}).AnyTimes()
+		return config
+	}
+
+	genBundleFactory := func(t *testing.T, controller *gomock.Controller, names []string, exclusions ...sheaf.ImageExclusion) sheaf.BundleFactoryFunc {
 		var manifests []sheaf.BundleManifest
 		for _, name := range names {
 			manifests = append(manifests, genManifest(name))
 		}
 		bundle := testutil.GenerateBundle(t, controller,
+			testutil.BundleGeneratorConfig(genConfig(controller, exclusions...)),
 			testutil.BundleGeneratorManifests(manifests))
 		return func(string) (sheaf.Bundle, error) {
 			return bundle, nil
@@ -35,9 +52,16 @@ func TestManifestShow(t *testing.T) {
 	expectBundleManifest := func(name, wantedPrefix string, ir *mocks.MockImageReplacer) {
 		ir.EXPECT().
 			Replace(gomock.Any(), gomock.Any(), gomock.Any()).
-			DoAndReturn(func(manifest sheaf.BundleManifest, config sheaf.BundleConfig, actualPrefix string) ([]byte, error) {
+			DoAndReturn(func(manifest sheaf.BundleManifest, config sheaf.BundleConfig, mapping sheaf.ImageMapping) ([]byte, error) {
 				require.Equal(t, name, manifest.ID)
-				require.Equal(t, wantedPrefix, actualPrefix)
+				if wantedPrefix == "" {
+					require.Nil(t, mapping)
+					return manifest.Data, nil
+				}
+
+				got, err := mapping(nginx)
+				require.NoError(t, err)
+				require.True(t, strings.HasPrefix(got.String(), "docker.io/"+wantedPrefix+"/library-nginx-"), got.String())
 				return manifest.Data, nil
 			})
 	}
@@ -47,6 +71,9 @@ func TestManifestShow(t *testing.T) {
 		bundleFactory  bundleFactoryFunc
 		imageRelocator func(controller *gomock.Controller) sheaf.ImageReplacer
 		prefix         string
+		mapping        string
+		strategy       sheaf.MappingStrategy
+		locked         bool
 		wantErr        bool
 		want           string
 	}{
@@ -88,6 +115,167 @@ func TestManifestShow(t *testing.T) {
 			},
 			want: "file: deploy1.yaml\n---\nfile: deploy2.yaml\n",
 		},
+		{
+			name: "with bundle lock",
+			bundleFactory: func(controller *gomock.Controller) sheaf.BundleFactoryFunc {
+				bundle := testutil.GenerateBundle(t, controller,
+					testutil.BundleGeneratorConfig(genConfig(controller)),
+					testutil.BundleGeneratorManifests([]sheaf.BundleManifest{genManifest("deploy1.yaml")}),
+					testutil.BundleGeneratorCreateBundle(
+						func(t *testing.T, controller *gomock.Controller, config sheaf.BundleConfig, manifests []sheaf.BundleManifest) *mocks.MockBundle {
+							bundle := mocks.NewMockBundle(controller)
+							bundle.EXPECT().Config().Return(config)
+							bundle.EXPECT().Lock().Return(genBundleLock(t, "nginx:1.17", testDigest1), nil).Times(2)
+
+							imageList, err := images.New("nginx:1.17")
+							require.NoError(t, err)
+							bundle.EXPECT().Images().Return(imageList, nil)
+
+							ms := mocks.NewMockManifestService(controller)
+							ms.EXPECT().List().Return(manifests, nil)
+							bundle.EXPECT().Manifests().Return(ms, nil)
+							return bundle
+						}))
+				return func(string) (sheaf.Bundle, error) {
+					return bundle, nil
+				}
+			},
+			locked: true,
+			imageRelocator: func(controller *gomock.Controller) sheaf.ImageReplacer {
+				ir := mocks.NewMockImageReplacer(controller)
+				ir.EXPECT().
+					Replace(gomock.Any(), gomock.Any(), gomock.Any()).
+					DoAndReturn(func(manifest sheaf.BundleManifest, config sheaf.BundleConfig, mapping sheaf.ImageMapping) ([]byte, error) {
+						got, err := mapping(nginx)
+						require.NoError(t, err)
+						require.Equal(t, "docker.io/library/nginx:1.17@"+testDigest1, got.String())
+						return manifest.Data, nil
+					})
+				return ir
+			},
+			want: "file: deploy1.yaml\n",
+		},
+		{
+			name: "with prefix and excluded image",
+			bundleFactory: func(controller *gomock.Controller) sheaf.BundleFactoryFunc {
+				exclusion := sheaf.ImageExclusion{Repository: "docker.io/library/*"}
+				bundle := testutil.GenerateBundle(t, controller,
+					testutil.BundleGeneratorConfig(genConfig(controller, exclusion)),
+					testutil.BundleGeneratorManifests([]sheaf.BundleManifest{genManifest("deploy1.yaml")}),
+					testutil.BundleGeneratorCreateBundle(
+						func(t *testing.T, controller *gomock.Controller, config sheaf.BundleConfig, manifests []sheaf.BundleManifest) *mocks.MockBundle {
+							bundle := mocks.NewMockBundle(controller)
+							bundle.EXPECT().Config().Return(config)
+							bundle.EXPECT().Lock().Return(sheaf.BundleLock{}, nil).Times(2)
+
+							// Excluded images are not in the bundle's images, so
+							// they don't need to be locked.
+							bundle.EXPECT().Images().Return(images.Empty, nil)
+
+							ms := mocks.NewMockManifestService(controller)
+							ms.EXPECT().List().Return(manifests, nil)
+							bundle.EXPECT().Manifests().Return(ms, nil)
+							return bundle
+						}))
+				return func(string) (sheaf.Bundle, error) {
+					return bundle, nil
+				}
+			},
+			prefix: "prefix",
+			locked: true,
+			imageRelocator: func(controller *gomock.Controller) sheaf.ImageReplacer {
+				ir := mocks.NewMockImageReplacer(controller)
+				ir.EXPECT().
+					Replace(gomock.Any(), gomock.Any(), gomock.Any()).
+					DoAndReturn(func(manifest sheaf.BundleManifest, config sheaf.BundleConfig, mapping sheaf.ImageMapping) ([]byte, error) {
+						got, err := mapping(nginx)
+						require.NoError(t, err)
+						require.Equal(t, nginx, got)
+						return manifest.Data, nil
+					})
+				return ir
+			},
+			want: "file: deploy1.yaml\n",
+		},
+		{
+			name: "with prefix and mapping strategy",
+			bundleFactory: func(controller *gomock.Controller) sheaf.BundleFactoryFunc {
+				return genBundleFactory(t, controller, []string{"deploy1.yaml"})
+			},
+			prefix:   "registry.example.com/mirror",
+			strategy: sheaf.MappingStrategy{Name: sheaf.PreservePathMappingStrategy},
+			imageRelocator: func(controller *gomock.Controller) sheaf.ImageReplacer {
+				ir := mocks.NewMockImageReplacer(controller)
+				ir.EXPECT().
+					Replace(gomock.Any(), gomock.Any(), gomock.Any()).
+					DoAndReturn(func(manifest sheaf.BundleManifest, config sheaf.BundleConfig, mapping sheaf.ImageMapping) ([]byte, error) {
+						got, err := mapping(nginx)
+						require.NoError(t, err)
+						require.Equal(t, "registry.example.com/mirror/docker.io/library/nginx:1.17", got.String())
+						return manifest.Data, nil
+					})
+				return ir
+			},
+			want: "file: deploy1.yaml\n",
+		},
+		{
+			name: "with relocation mapping",
+			bundleFactory: func(controller *gomock.Controller) sheaf.BundleFactoryFunc {
+				return genBundleFactory(t, controller, []string{"deploy1.yaml"})
+			},
+			mapping: `{"images": [{"original": "nginx:1.17", "relocated": "registry.example.com/mirror/nginx:1.17"}]}`,
+			imageRelocator: func(controller *gomock.Controller) sheaf.ImageReplacer {
+				ir := mocks.NewMockImageReplacer(controller)
+				ir.EXPECT().
+					Replace(gomock.Any(), gomock.Any(), gomock.Any()).
+					DoAndReturn(func(manifest sheaf.BundleManifest, config sheaf.BundleConfig, mapping sheaf.ImageMapping) ([]byte, error) {
+						got, err := mapping(nginx)
+						require.NoError(t, err)
+						require.Equal(t, "registry.example.com/mirror/nginx:1.17", got.String())
+						return manifest.Data, nil
+					})
+				return ir
+			},
+			want: "file: deploy1.yaml\n",
+		},
+		{
+			name: "with image missing from relocation mapping",
+			bundleFactory: func(controller *gomock.Controller) sheaf.BundleFactoryFunc {
+				return genBundleFactory(t, controller, []string{"deploy1.yaml"})
+			},
+			mapping: "images:\n- original: redis:5\n  relocated: registry.example.com/mirror/redis:5\n",
+			imageRelocator: func(controller *gomock.Controller) sheaf.ImageReplacer {
+				ir := mocks.NewMockImageReplacer(controller)
+				ir.EXPECT().
+					Replace(gomock.Any(), gomock.Any(), gomock.Any()).
+					DoAndReturn(func(manifest sheaf.BundleManifest, config sheaf.BundleConfig, mapping sheaf.ImageMapping) ([]byte, error) {
+						return nil, callMapping(mapping, nginx)
+					})
+				return ir
+			},
+			wantErr: true,
+		},
+		{
+			name:    "with relocation mapping and prefix",
+			prefix:  "prefix",
+			mapping: `{"images": []}`,
+			imageRelocator: func(controller *gomock.Controller) sheaf.ImageReplacer {
+				return mocks.NewMockImageReplacer(controller)
+			},
+			wantErr: true,
+		},
+		{
+			name: "locked with unlocked image",
+			bundleFactory: func(controller *gomock.Controller) sheaf.BundleFactoryFunc {
+				return genBundleFactory(t, controller, []string{"deploy1.yaml"})
+			},
+			locked: true,
+			imageRelocator: func(controller *gomock.Controller) sheaf.ImageReplacer {
+				// The stale lock is found before manifests are shown.
+				return mocks.NewMockImageReplacer(controller)
+			},
+			wantErr: true,
+		},
 		{
 			name: "requires bundle factory",
 			imageRelocator: func(controller *gomock.Controller) sheaf.ImageReplacer {
@@ -107,10 +295,12 @@ func TestManifestShow(t *testing.T) {
 			name: "config get manifests fails",
 			bundleFactory: func(controller *gomock.Controller) sheaf.BundleFactoryFunc {
 				bundle := testutil.GenerateBundle(t, controller,
+					testutil.BundleGeneratorConfig(genConfig(controller)),
 					testutil.BundleGeneratorCreateBundle(
 						func(t *testing.T, controller *gomock.Controller, config sheaf.BundleConfig, manifests []sheaf.BundleManifest) *mocks.MockBundle {
 							bundle := mocks.NewMockBundle(controller)
 							bundle.EXPECT().Config().Return(config)
+							bundle.EXPECT().Lock().Return(sheaf.BundleLock{}, nil)
 							bundle.EXPECT().Manifests().Return(nil, fmt.Errorf("error"))
 							return bundle
 						}))
@@ -128,10 +318,12 @@ func TestManifestShow(t *testing.T) {
 			name: "manifest list fails",
 			bundleFactory: func(controller *gomock.Controller) sheaf.BundleFactoryFunc {
 				bundle := testutil.GenerateBundle(t, controller,
+					testutil.BundleGeneratorConfig(genConfig(controller)),
 					testutil.BundleGeneratorCreateBundle(
 						func(t *testing.T, controller *gomock.Controller, config sheaf.BundleConfig, manifests []sheaf.BundleManifest) *mocks.MockBundle {
 							bundle := mocks.NewMockBundle(controller)
 							bundle.EXPECT().Config().Return(config)
+							bundle.EXPECT().Lock().Return(sheaf.BundleLock{}, nil)
 
 							ms := mocks.NewMockManifestService(controller)
 							bundle.EXPECT().Manifests().Return(ms, nil)
@@ -170,6 +362,21 @@ func TestManifestShow(t *testing.T) {
 
 			options := []sheaf.Option{
 				sheaf.WithRepositoryPrefix(tc.prefix),
+				sheaf.WithLocked(tc.locked),
+				sheaf.WithMappingStrategy(tc.strategy),
+			}
+
+			if tc.mapping != "" {
+				dir, err := ioutil.TempDir("", "sheaf-test")
+				require.NoError(t, err)
+
+				defer func() {
+					require.NoError(t, os.RemoveAll(dir))
+				}()
+
+				mappingPath := filepath.Join(dir, "mapping")
+				require.NoError(t, ioutil.WriteFile(mappingPath, []byte(tc.mapping), 0600))
+				options = append(options, sheaf.WithMappingPath(mappingPath))
 			}
 
 			if tc.bundleFactory != nil {
@@ -196,3 +403,12 @@ func TestManifestShow(t *testing.T) {
 		})
 	}
 }
+
+func callMapping(mapping sheaf.ImageMapping, n image.Name) error {
+	if mapping == nil {
+		return nil
+	}
+
+	_, err := mapping(n)
+	return err
+}
@@ -0,0 +1,240 @@
+/*
+ * Copyright 2020 Sheaf Authors
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package sheaf
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+)
+
+//go:generate mockgen -destination=../mocks/mock_manifest_renderer.go -package mocks github.com/bryanl/sheaf/pkg/sheaf ManifestRenderer
+
+// ManifestSourceType is the type of manifest source.
+type ManifestSourceType string
+
+const (
+	// HelmManifestSource is a manifest source rendered from a Helm chart.
+	HelmManifestSource ManifestSourceType = "helm"
+	// KustomizeManifestSource is a manifest source built from a kustomization.
+	KustomizeManifestSource ManifestSourceType = "kustomize"
+)
+
+// ManifestSource is a source that is rendered into a bundle manifest. Manifest
+// sources are recorded in the bundle config so they can be rendered again.
+type ManifestSource struct {
+	// Type is the type of the source.
+	Type ManifestSourceType `json:"type"`
+	// Path is the location of the source. Paths inside the bundle are relative
+	// to the bundle root.
+	Path string `json:"path"`
+	// Manifest is the name of the rendered manifest in the bundle.
+	Manifest string `json:"manifest"`
+	// Helm contains options for Helm chart sources.
+	Helm *HelmOptions `json:"helm,omitempty"`
+}
+
+// Validate validates a manifest source.
+func (ms ManifestSource) Validate() error {
+	if ms.Path == "" {
+		return fmt.Errorf("manifest source path is blank")
+	}
+
+	if ms.Manifest == "" {
+		return fmt.Errorf("manifest source manifest name is blank")
+	}
+
+	switch ms.Type {
+	case HelmManifestSource:
+		if ms.Helm == nil || ms.Helm.ReleaseName == "" {
+			return fmt.Errorf("helm release name is blank")
+		}
+	case KustomizeManifestSource:
+	default:
+		return fmt.Errorf("unsupported manifest source type %q", ms.Type)
+	}
+
+	return nil
+}
+
+// HelmOptions are options for rendering a Helm chart.
+type HelmOptions struct {
+	// ReleaseName is the release name used to render the chart.
+	ReleaseName string `json:"releaseName"`
+	// Namespace is the namespace used to render the chart.
+	Namespace string `json:"namespace,omitempty"`
+	// Values are values documents applied in order.
+	Values []map[string]interface{} `json:"values,omitempty"`
+}
+
+// HelmChart describes a Helm chart to add to a bundle.
+type HelmChart struct {
+	// Chart is a chart directory or packaged chart.
+	Chart string
+	// ValuesFiles are values files applied in order.
+	ValuesFiles []string
+	// ReleaseName is the release name. It defaults to the chart name.
+	ReleaseName string
+	// Namespace is the release namespace.
+	Namespace string
+}
+
+// ManifestRenderer renders manifests from a manifest source.
+type ManifestRenderer interface {
+	// Render renders a manifest source. The source path is absolute.
+	Render(source ManifestSource) ([]byte, error)
+}
+
+// ResolvePath returns a copy of the source with its path resolved against
+// the bundle root.
+func (ms ManifestSource) ResolvePath(rootPath string) ManifestSource {
+	if !filepath.IsAbs(ms.Path) {
+		ms.Path = filepath.Join(rootPath, filepath.FromSlash(ms.Path))
+	}
+
+	return ms
+}
+
+func newHelmManifestSource(rootPath string, chart HelmChart) (ManifestSource, error) {
+	chartPath, err := filepath.Abs(chart.Chart)
+	if err != nil {
+		return ManifestSource{}, err
+	}
+
+	fi, err := os.Stat(chartPath)
+	if err != nil {
+		return ManifestSource{}, fmt.Errorf("unable to open chart %s: %w", chart.Chart, err)
+	}
+
+	chartName := filepath.Base(chartPath)
+	if fi.IsDir() {
+		if _, err := os.Stat(filepath.Join(chartPath, "Chart.yaml")); err != nil {
+			return ManifestSource{}, fmt.Errorf("%s is not a chart directory: %w", chart.Chart, err)
+		}
+	} else {
+		if !strings.HasSuffix(chartName, ".tgz") {
+			return ManifestSource{}, fmt.Errorf("%s is not a packaged chart", chart.Chart)
+		}
+		chartName = strings.TrimSuffix(chartName, ".tgz")
+	}
+
+	releaseName := chart.ReleaseName
+	if releaseName == "" {
+		releaseName = chartName
+	}
+
+	helmOptions := HelmOptions{
+		ReleaseName: releaseName,
+		Namespace:   chart.Namespace,
+	}
+
+	for _, valuesFile := range chart.ValuesFiles {
+		data, err := ioutil.ReadFile(valuesFile)
+		if err != nil {
+			return ManifestSource{}, fmt.Errorf("read values file: %w", err)
+		}
+
+		values := map[string]interface{}{}
+		if err := yaml.Unmarshal(data, &values); err != nil {
+			return ManifestSource{}, fmt.Errorf("decode values file %s: %w", valuesFile, err)
+		}
+
+		helmOptions.Values = append(helmOptions.Values, values)
+	}
+
+	return ManifestSource{
+		Type:     HelmManifestSource,
+		Path:     bundleRelativePath(rootPath, chartPath),
+		Manifest: releaseName + ".yaml",
+		Helm:     &helmOptions,
+	}, nil
+}
+
+// kustomizationFileNames are the file names recognized as a kustomization.
+var kustomizationFileNames = []string{"kustomization.yaml", "kustomization.yml", "Kustomization"}
+
+func newKustomizeManifestSource(rootPath, kustomization string) (ManifestSource, error) {
+	dir, err := filepath.Abs(kustomization)
+	if err != nil {
+		return ManifestSource{}, err
+	}
+
+	fi, err := os.Stat(dir)
+	if err != nil {
+		return ManifestSource{}, fmt.Errorf("unable to open kustomization %s: %w", kustomization, err)
+	}
+
+	if !fi.IsDir() {
+		return ManifestSource{}, fmt.Errorf("%s is not a kustomization directory", kustomization)
+	}
+
+	found := false
+	for _, name := range kustomizationFileNames {
+		if _, err := os.Stat(filepath.Join(dir, name)); err == nil {
+			found = true
+			break
+		}
+	}
+
+	if !found {
+		return ManifestSource{}, fmt.Errorf("%s does not contain a kustomization file", kustomization)
+	}
+
+	// Kustomizations inside the bundle are named by their relative path, so
+	// overlays with the same directory name don't share a manifest.
+	sourcePath := bundleRelativePath(rootPath, dir)
+	manifestName := filepath.Base(dir)
+	if !filepath.IsAbs(sourcePath) && sourcePath != "." {
+		manifestName = strings.ReplaceAll(sourcePath, "/", "-")
+	}
+
+	return ManifestSource{
+		Type:     KustomizeManifestSource,
+		Path:     sourcePath,
+		Manifest: manifestName + ".yaml",
+	}, nil
+}
+
+// bundleRelativePath returns a path relative to the bundle root if the path is
+// inside of the bundle.
+func bundleRelativePath(rootPath, p string) string {
+	rel, err := filepath.Rel(rootPath, p)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return p
+	}
+
+	return filepath.ToSlash(rel)
+}
+
+// updateManifestSources stores a manifest source in a bundle config. The same
+// source added again is replaced. It returns an error if a different source
+// renders the same manifest.
+func updateManifestSources(config BundleConfig, source ManifestSource) (BundleConfig, error) {
+	var list []ManifestSource
+	for _, cur := range config.GetManifestSources() {
+		if cur.Manifest == source.Manifest {
+			if cur.Type != source.Type || cur.Path != source.Path {
+				return nil, fmt.Errorf("manifest %s is already rendered from %s source %s", source.Manifest, cur.Type, cur.Path)
+			}
+			continue
+		}
+		list = append(list, cur)
+	}
+
+	list = append(list, source)
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Manifest < list[j].Manifest
+	})
+
+	config.SetManifestSources(list)
+	return config, nil
+}
@@ -0,0 +1,171 @@
+/*
+ * Copyright 2020 Sheaf Authors
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package sheaf
+
+import (
+	"bytes"
+	"fmt"
+	"path"
+	"strings"
+	"text/template"
+
+	"github.com/pivotal/image-relocation/pkg/image"
+	"github.com/pivotal/image-relocation/pkg/pathmapping"
+)
+
+const (
+	// FlattenHashMappingStrategy flattens the repository path and adds a hash
+	// of the original name, e.g. prefix/knative-releases-serving-cmd-queue-<hash>.
+	FlattenHashMappingStrategy = "flatten-hash"
+	// PreservePathMappingStrategy keeps the original registry host and
+	// repository path, e.g. prefix/gcr.io/knative-releases/serving/cmd/queue.
+	PreservePathMappingStrategy = "preserve-path"
+	// BasenameOnlyMappingStrategy keeps the last element of the repository
+	// path, e.g. prefix/queue.
+	BasenameOnlyMappingStrategy = "basename-only"
+	// TemplateMappingStrategy creates the repository name from a template.
+	TemplateMappingStrategy = "template"
+)
+
+// MappingStrategyNames is a list of mapping strategy names.
+var MappingStrategyNames = []string{
+	FlattenHashMappingStrategy,
+	PreservePathMappingStrategy,
+	BasenameOnlyMappingStrategy,
+	TemplateMappingStrategy,
+}
+
+// MappingStrategy selects how the repository names of relocated images are
+// created. The zero value uses the flatten-hash strategy.
+type MappingStrategy struct {
+	// Name is the name of the strategy.
+	Name string `json:"name"`
+	// Template is the repository name template for the template strategy.
+	// See MappingTemplateData for the available fields.
+	Template string `json:"template,omitempty"`
+}
+
+// MappingTemplateData is the data available to a mapping strategy template.
+type MappingTemplateData struct {
+	// Prefix is the repository prefix images are relocated to.
+	Prefix string
+	// Host is the registry host of the original image, e.g. gcr.io.
+	Host string
+	// Path is the repository path of the original image, e.g. knative-releases/serving/cmd/queue.
+	Path string
+	// Basename is the last element of the repository path, e.g. queue.
+	Basename string
+}
+
+// IsZero returns true if no strategy is selected.
+func (ms MappingStrategy) IsZero() bool {
+	return ms.Name == "" && ms.Template == ""
+}
+
+// Validate validates a mapping strategy.
+func (ms MappingStrategy) Validate() error {
+	_, err := ms.PathMapping()
+	return err
+}
+
+// PathMapping returns the path mapping for the strategy. Tags and digests of
+// the original image are preserved.
+func (ms MappingStrategy) PathMapping() (pathmapping.PathMapping, error) {
+	if ms.Template != "" && ms.Name != TemplateMappingStrategy {
+		return nil, fmt.Errorf("template requires the %s mapping strategy", TemplateMappingStrategy)
+	}
+
+	switch ms.Name {
+	case "", FlattenHashMappingStrategy:
+		return pathmapping.FlattenRepoPathPreserveTagDigest, nil
+	case PreservePathMappingStrategy:
+		return repositoryPathMapping(func(prefix string, n image.Name) (string, error) {
+			return path.Join(prefix, n.Host(), n.Path()), nil
+		}), nil
+	case BasenameOnlyMappingStrategy:
+		return repositoryPathMapping(func(prefix string, n image.Name) (string, error) {
+			return path.Join(prefix, path.Base(n.Path())), nil
+		}), nil
+	case TemplateMappingStrategy:
+		if ms.Template == "" {
+			return nil, fmt.Errorf("%s mapping strategy requires a template", TemplateMappingStrategy)
+		}
+
+		t, err := template.New("mapping").Option("missingkey=error").Parse(ms.Template)
+		if err != nil {
+			return nil, fmt.Errorf("parse mapping template: %w", err)
+		}
+
+		return repositoryPathMapping(func(prefix string, n image.Name) (string, error) {
+			data := MappingTemplateData{
+				Prefix:   prefix,
+				Host:     n.Host(),
+				Path:     n.Path(),
+				Basename: path.Base(n.Path()),
+			}
+
+			var buf bytes.Buffer
+			if err := t.Execute(&buf, data); err != nil {
+				return "", fmt.Errorf("execute mapping template: %w", err)
+			}
+
+			return strings.TrimSpace(buf.String()), nil
+		}), nil
+	default:
+		return nil, fmt.Errorf("unknown mapping strategy %q (valid strategies are %s)",
+			ms.Name, strings.Join(MappingStrategyNames, ", "))
+	}
+}
+
+// ImageMapping returns an image mapping that relocates images to a
+// repository prefix using the strategy.
+func (ms MappingStrategy) ImageMapping(prefix string) (ImageMapping, error) {
+	pm, err := ms.PathMapping()
+	if err != nil {
+		return nil, err
+	}
+
+	return func(originalImage image.Name) (image.Name, error) {
+		return pm(prefix, originalImage)
+	}, nil
+}
+
+// repositoryPathMapping creates a path mapping from a function that returns
+// a repository name. The tag and digest of the original image are preserved.
+func repositoryPathMapping(fn func(prefix string, n image.Name) (string, error)) pathmapping.PathMapping {
+	return func(prefix string, originalImage image.Name) (image.Name, error) {
+		repository, err := fn(prefix, originalImage)
+		if err != nil {
+			return image.EmptyName, err
+		}
+
+		s := repository
+		if tag := originalImage.Tag(); tag != "" {
+			s += ":" + tag
+		}
+		if digest := originalImage.Digest(); digest != image.EmptyDigest {
+			s += "@" + digest.String()
+		}
+
+		n, err := image.NewName(s)
+		if err != nil {
+			return image.EmptyName, fmt.Errorf("relocated name for %s: %w", originalImage, err)
+		}
+
+		return n, nil
+	}
+}
+
+// resolveMappingStrategy returns the mapping strategy selected by options
+// or, if none is selected, by the bundle config.
+func resolveMappingStrategy(opts options, config BundleConfig) MappingStrategy {
+	if !opts.mappingStrategy.IsZero() {
+		return opts.mappingStrategy
+	}
+
+	return config.GetMappingStrategy()
+}
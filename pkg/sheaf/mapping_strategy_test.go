@@ -0,0 +1,123 @@
+/*
+ * Copyright 2020 Sheaf Authors
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package sheaf_test
+
+import (
+	"testing"
+
+	"github.com/pivotal/image-relocation/pkg/image"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bryanl/sheaf/pkg/sheaf"
+)
+
+func TestMappingStrategy_ImageMapping(t *testing.T) {
+	const prefix = "registry.example.com/mirror"
+
+	cases := []struct {
+		name     string
+		strategy sheaf.MappingStrategy
+		image    string
+		want     string
+		wantErr  bool
+	}{
+		{
+			name:  "default",
+			image: "gcr.io/knative-releases/serving/cmd/queue:v1",
+			want:  "registry.example.com/mirror/knative-releases-serving-cmd-queue-b513262c6b56c2cb8699d31a82dda68b:v1",
+		},
+		{
+			name:     "flatten hash",
+			strategy: sheaf.MappingStrategy{Name: sheaf.FlattenHashMappingStrategy},
+			image:    "gcr.io/knative-releases/serving/cmd/queue:v1",
+			want:     "registry.example.com/mirror/knative-releases-serving-cmd-queue-b513262c6b56c2cb8699d31a82dda68b:v1",
+		},
+		{
+			name:     "preserve path",
+			strategy: sheaf.MappingStrategy{Name: sheaf.PreservePathMappingStrategy},
+			image:    "gcr.io/knative-releases/serving/cmd/queue:v1",
+			want:     "registry.example.com/mirror/gcr.io/knative-releases/serving/cmd/queue:v1",
+		},
+		{
+			name:     "preserve path with digest",
+			strategy: sheaf.MappingStrategy{Name: sheaf.PreservePathMappingStrategy},
+			image:    "nginx:1.17@" + testDigest1,
+			want:     "registry.example.com/mirror/docker.io/library/nginx:1.17@" + testDigest1,
+		},
+		{
+			name:     "basename only",
+			strategy: sheaf.MappingStrategy{Name: sheaf.BasenameOnlyMappingStrategy},
+			image:    "gcr.io/knative-releases/serving/cmd/queue:v1",
+			want:     "registry.example.com/mirror/queue:v1",
+		},
+		{
+			name: "template",
+			strategy: sheaf.MappingStrategy{
+				Name:     sheaf.TemplateMappingStrategy,
+				Template: "{{.Prefix}}/{{.Host}}-{{.Basename}}",
+			},
+			image: "gcr.io/knative-releases/serving/cmd/queue:v1",
+			want:  "registry.example.com/mirror/gcr.io-queue:v1",
+		},
+		{
+			name:     "template without template",
+			strategy: sheaf.MappingStrategy{Name: sheaf.TemplateMappingStrategy},
+			image:    "nginx",
+			wantErr:  true,
+		},
+		{
+			name: "template with unknown field",
+			strategy: sheaf.MappingStrategy{
+				Name:     sheaf.TemplateMappingStrategy,
+				Template: "{{.Prefix}}/{{.Unknown}}",
+			},
+			image:   "nginx",
+			wantErr: true,
+		},
+		{
+			name: "template creates invalid name",
+			strategy: sheaf.MappingStrategy{
+				Name:     sheaf.TemplateMappingStrategy,
+				Template: "{{.Prefix}}/UPPER",
+			},
+			image:   "nginx",
+			wantErr: true,
+		},
+		{
+			name:     "template with another strategy",
+			strategy: sheaf.MappingStrategy{Name: sheaf.BasenameOnlyMappingStrategy, Template: "{{.Prefix}}"},
+			image:    "nginx",
+			wantErr:  true,
+		},
+		{
+			name:     "unknown strategy",
+			strategy: sheaf.MappingStrategy{Name: "unknown"},
+			image:    "nginx",
+			wantErr:  true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			n, err := image.NewName(tc.image)
+			require.NoError(t, err)
+
+			mapping, err := tc.strategy.ImageMapping(prefix)
+			if err == nil {
+				var got image.Name
+				got, err = mapping(n)
+				if err == nil {
+					require.False(t, tc.wantErr)
+					require.Equal(t, tc.want, got.String())
+					return
+				}
+			}
+
+			require.True(t, tc.wantErr, "unexpected error: %v", err)
+		})
+	}
+}
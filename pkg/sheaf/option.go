@@ -28,30 +28,50 @@ type options struct {
 	bundleConfigWriter  func() (BundleConfigWriter, error)
 	bundlePacker        func() (BundlePacker, error)
 
-	archiver Archiver
+	archiver      Archiver
+	workdir       string
+	keepExtracted bool
 
 	createBundle func(bc BundleConfig) error
 
 	repositoryPrefix string
+	mappingPath      string
+	mappingStrategy  MappingStrategy
 
 	imageReplacer  ImageReplacer
 	imageRelocator ImageRelocator
+	registryServer RegistryServer
+
+	archiveVerifier   ArchiveVerifier
+	signatureVerifier SignatureVerifier
+	deltaApplier      DeltaApplier
+	manifestDiffer    ManifestDiffer
 
 	userDefinedImage    UserDefinedImage
 	userDefinedImageKey UserDefinedImageKey
-
-	bundleImager BundleImager
-	imageReader  ImageReader
-	imageWriter  ImageWriter
-
-	filePaths   []string
-	images      []string
-	force       bool
-	reference   string
-	destination string
-	archive     string
+	catalogEntryNames   []string
+
+	bundleImager  BundleImager
+	imageReader   ImageReader
+	imageWriter   ImageWriter
+	imageResolver ImageResolver
+
+	filePaths     []string
+	helmChart     HelmChart
+	kustomization string
+	images        []string
+	exclusions    []ImageExclusion
+	force         bool
+	reference     string
+	destination   string
+	archive       string
+	newArchive    string
+	base          string
+	outputFormat  string
+	address       string
 
 	dryRun bool
+	locked bool
 
 	writer io.Writer
 }
@@ -88,6 +108,41 @@ func WithArchive(archive string) Option {
 	}
 }
 
+// WithNewArchive sets the archive compared with the archive.
+func WithNewArchive(archive string) Option {
+	return func(o *options) {
+		o.newArchive = archive
+	}
+}
+
+// WithOutputFormat sets the output format.
+func WithOutputFormat(format string) Option {
+	return func(o *options) {
+		o.outputFormat = format
+	}
+}
+
+// WithManifestDiffer sets the manifest differ.
+func WithManifestDiffer(md ManifestDiffer) Option {
+	return func(o *options) {
+		o.manifestDiffer = md
+	}
+}
+
+// WithBase sets the base archive of a delta archive.
+func WithBase(base string) Option {
+	return func(o *options) {
+		o.base = base
+	}
+}
+
+// WithDeltaApplier sets the delta applier.
+func WithDeltaApplier(da DeltaApplier) Option {
+	return func(o *options) {
+		o.deltaApplier = da
+	}
+}
+
 // WithArchiver sets archiver.
 func WithArchiver(archiver Archiver) Option {
 	return func(o *options) {
@@ -95,6 +150,22 @@ func WithArchiver(archiver Archiver) Option {
 	}
 }
 
+// WithWorkdir sets the directory where archives are extracted. The system
+// temporary directory is used if it is empty.
+func WithWorkdir(dir string) Option {
+	return func(o *options) {
+		o.workdir = dir
+	}
+}
+
+// WithKeepExtracted sets whether archives are extracted once and kept in the
+// work directory so later commands can reuse the extraction.
+func WithKeepExtracted(keepExtracted bool) Option {
+	return func(o *options) {
+		o.keepExtracted = keepExtracted
+	}
+}
+
 // WithBundleImager sets the bundle imager.
 func WithBundleImager(bi BundleImager) Option {
 	return func(o *options) {
@@ -174,6 +245,13 @@ func WithBundlePath(s string) Option {
 	}
 }
 
+// WithCatalogEntryNames sets catalog entry names.
+func WithCatalogEntryNames(names []string) Option {
+	return func(o *options) {
+		o.catalogEntryNames = names
+	}
+}
+
 // WithCodec sets the codec.
 func WithCodec(c Codec) Option {
 	return func(o *options) {
@@ -209,6 +287,35 @@ func WithForce(force bool) Option {
 	}
 }
 
+// WithHelmChart sets the Helm chart.
+func WithHelmChart(chart HelmChart) Option {
+	return func(o *options) {
+		o.helmChart = chart
+	}
+}
+
+// WithKustomization sets the kustomization directory.
+func WithKustomization(kustomization string) Option {
+	return func(o *options) {
+		o.kustomization = kustomization
+	}
+}
+
+// WithImageResolver sets the image resolver.
+func WithImageResolver(ir ImageResolver) Option {
+	return func(o *options) {
+		o.imageResolver = ir
+	}
+}
+
+// WithLocked sets locked mode. In locked mode, commands fail if the bundle
+// lock is stale.
+func WithLocked(locked bool) Option {
+	return func(o *options) {
+		o.locked = locked
+	}
+}
+
 // WithImages sets images.
 func WithImages(imageList []string) Option {
 	return func(o *options) {
@@ -216,6 +323,27 @@ func WithImages(imageList []string) Option {
 	}
 }
 
+// WithImageExclusions sets image exclusions.
+func WithImageExclusions(exclusions []ImageExclusion) Option {
+	return func(o *options) {
+		o.exclusions = exclusions
+	}
+}
+
+// WithMappingPath sets the relocation mapping path.
+func WithMappingPath(mappingPath string) Option {
+	return func(o *options) {
+		o.mappingPath = mappingPath
+	}
+}
+
+// WithMappingStrategy sets the mapping strategy.
+func WithMappingStrategy(mappingStrategy MappingStrategy) Option {
+	return func(o *options) {
+		o.mappingStrategy = mappingStrategy
+	}
+}
+
 // WithImageRelocator sets image relocator.
 func WithImageRelocator(ir ImageRelocator) Option {
 	return func(o *options) {
@@ -223,6 +351,34 @@ func WithImageRelocator(ir ImageRelocator) Option {
 	}
 }
 
+// WithRegistryServer sets registry server.
+func WithRegistryServer(rs RegistryServer) Option {
+	return func(o *options) {
+		o.registryServer = rs
+	}
+}
+
+// WithArchiveVerifier sets the archive verifier.
+func WithArchiveVerifier(av ArchiveVerifier) Option {
+	return func(o *options) {
+		o.archiveVerifier = av
+	}
+}
+
+// WithSignatureVerifier sets the archive signature verifier.
+func WithSignatureVerifier(sv SignatureVerifier) Option {
+	return func(o *options) {
+		o.signatureVerifier = sv
+	}
+}
+
+// WithAddress sets the address to listen on.
+func WithAddress(addr string) Option {
+	return func(o *options) {
+		o.address = addr
+	}
+}
+
 // WithImageReader sets image reader.
 func WithImageReader(ir ImageReader) Option {
 	return func(o *options) {
@@ -0,0 +1,134 @@
+/*
+ * Copyright 2020 Sheaf Authors
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package sheaf
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+
+	"github.com/pivotal/image-relocation/pkg/image"
+	"sigs.k8s.io/yaml"
+)
+
+// RelocationMapping maps original image references to relocated image references.
+type RelocationMapping struct {
+	// Images are the relocated images sorted by original reference.
+	Images []RelocatedImage `json:"images"`
+}
+
+// RelocatedImage is an image reference and the reference it was relocated to.
+type RelocatedImage struct {
+	// Original is the image reference in the bundle.
+	Original string `json:"original"`
+	// Relocated is the image reference the image was relocated to.
+	Relocated string `json:"relocated"`
+	// Digest is the digest of the image.
+	Digest string `json:"digest"`
+}
+
+// NewRelocationMapping creates a relocation mapping from relocated images.
+func NewRelocationMapping(images []RelocatedImage) RelocationMapping {
+	rm := RelocationMapping{
+		Images: append([]RelocatedImage(nil), images...),
+	}
+
+	sort.Slice(rm.Images, func(i, j int) bool {
+		return rm.Images[i].Original < rm.Images[j].Original
+	})
+
+	return rm
+}
+
+// IsEmpty returns true if the mapping contains no images.
+func (rm RelocationMapping) IsEmpty() bool {
+	return len(rm.Images) == 0
+}
+
+// Relocate returns the relocated name for an image. It returns false if the
+// image is not in the mapping. An image with a digest matches an original
+// reference without one and the other way around.
+func (rm RelocationMapping) Relocate(n image.Name) (image.Name, bool, error) {
+	for _, ri := range rm.Images {
+		original, err := image.NewName(ri.Original)
+		if err != nil {
+			return image.EmptyName, false, fmt.Errorf("parse original image name %q: %w", ri.Original, err)
+		}
+
+		matches := original.String() == n.String()
+		if !matches && (original.Digest() == image.EmptyDigest || n.Digest() == image.EmptyDigest) {
+			matches = original.WithoutDigest().String() == n.WithoutDigest().String()
+		}
+
+		if !matches {
+			continue
+		}
+
+		relocated, err := image.NewName(ri.Relocated)
+		if err != nil {
+			return image.EmptyName, false, fmt.Errorf("parse relocated image name %q: %w", ri.Relocated, err)
+		}
+
+		return relocated, true, nil
+	}
+
+	return n, false, nil
+}
+
+// ImageMapping returns an image mapping that relocates images with the
+// relocation mapping. Images missing from the mapping are an error.
+func (rm RelocationMapping) ImageMapping() ImageMapping {
+	return func(originalImage image.Name) (image.Name, error) {
+		relocated, ok, err := rm.Relocate(originalImage)
+		if err != nil {
+			return image.EmptyName, err
+		}
+
+		if !ok {
+			return image.EmptyName, fmt.Errorf("%s is not in the relocation mapping", originalImage)
+		}
+
+		return relocated, nil
+	}
+}
+
+// ReadRelocationMapping reads a relocation mapping from a JSON or YAML file.
+func ReadRelocationMapping(path string) (RelocationMapping, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return RelocationMapping{}, err
+	}
+
+	var rm RelocationMapping
+	if err := yaml.Unmarshal(data, &rm); err != nil {
+		return RelocationMapping{}, fmt.Errorf("decode relocation mapping %s: %w", path, err)
+	}
+
+	return rm, nil
+}
+
+// WriteRelocationMapping writes a relocation mapping to a file. Files with a
+// .yaml or .yml extension are written as YAML; other files are written as JSON.
+func WriteRelocationMapping(path string, rm RelocationMapping) error {
+	var data []byte
+	var err error
+
+	switch filepath.Ext(path) {
+	case ".yaml", ".yml":
+		data, err = yaml.Marshal(rm)
+	default:
+		data, err = json.MarshalIndent(rm, "", "  ")
+		data = append(data, '\n')
+	}
+	if err != nil {
+		return fmt.Errorf("encode relocation mapping: %w", err)
+	}
+
+	return ioutil.WriteFile(path, data, 0644)
+}
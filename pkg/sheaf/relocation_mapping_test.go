@@ -0,0 +1,141 @@
+/*
+ * Copyright 2020 Sheaf Authors
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package sheaf_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pivotal/image-relocation/pkg/image"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bryanl/sheaf/pkg/sheaf"
+)
+
+func TestRelocationMapping_Relocate(t *testing.T) {
+	rm := sheaf.NewRelocationMapping([]sheaf.RelocatedImage{
+		{
+			Original:  "docker.io/library/redis:5@" + testDigest2,
+			Relocated: "registry.example.com/mirror/redis:5@" + testDigest2,
+			Digest:    testDigest2,
+		},
+		{
+			Original:  "docker.io/library/nginx:1.17",
+			Relocated: "registry.example.com/mirror/nginx:1.17",
+			Digest:    testDigest1,
+		},
+	})
+
+	require.Equal(t, "docker.io/library/nginx:1.17", rm.Images[0].Original)
+
+	cases := []struct {
+		name   string
+		image  string
+		want   string
+		wantOK bool
+	}{
+		{
+			name:   "exact reference",
+			image:  "nginx:1.17",
+			want:   "registry.example.com/mirror/nginx:1.17",
+			wantOK: true,
+		},
+		{
+			name:   "pinned image with unpinned original",
+			image:  "nginx:1.17@" + testDigest1,
+			want:   "registry.example.com/mirror/nginx:1.17",
+			wantOK: true,
+		},
+		{
+			name:   "unpinned image with pinned original",
+			image:  "redis:5",
+			want:   "registry.example.com/mirror/redis:5@" + testDigest2,
+			wantOK: true,
+		},
+		{
+			name:  "missing image",
+			image: "nginx:1.18",
+			want:  "docker.io/library/nginx:1.18",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			n, err := image.NewName(tc.image)
+			require.NoError(t, err)
+
+			got, ok, err := rm.Relocate(n)
+			require.NoError(t, err)
+			require.Equal(t, tc.wantOK, ok)
+			require.Equal(t, tc.want, got.String())
+		})
+	}
+}
+
+func TestWriteRelocationMapping(t *testing.T) {
+	rm := sheaf.NewRelocationMapping([]sheaf.RelocatedImage{
+		{
+			Original:  "docker.io/library/nginx:1.17",
+			Relocated: "registry.example.com/mirror/nginx:1.17",
+			Digest:    testDigest1,
+		},
+	})
+
+	cases := []struct {
+		name     string
+		filename string
+		want     string
+	}{
+		{
+			name:     "json",
+			filename: "mapping.json",
+			want: `{
+  "images": [
+    {
+      "original": "docker.io/library/nginx:1.17",
+      "relocated": "registry.example.com/mirror/nginx:1.17",
+      "digest": "` + testDigest1 + `"
+    }
+  ]
+}
+`,
+		},
+		{
+			name:     "yaml",
+			filename: "mapping.yaml",
+			want: `images:
+- digest: ` + testDigest1 + `
+  original: docker.io/library/nginx:1.17
+  relocated: registry.example.com/mirror/nginx:1.17
+`,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			dir, err := ioutil.TempDir("", "sheaf-test")
+			require.NoError(t, err)
+
+			defer func() {
+				require.NoError(t, os.RemoveAll(dir))
+			}()
+
+			path := filepath.Join(dir, tc.filename)
+			require.NoError(t, sheaf.WriteRelocationMapping(path, rm))
+
+			data, err := ioutil.ReadFile(path)
+			require.NoError(t, err)
+			require.Equal(t, tc.want, string(data))
+
+			got, err := sheaf.ReadRelocationMapping(path)
+			require.NoError(t, err)
+			require.Equal(t, rm, got)
+		})
+	}
+}
@@ -0,0 +1,15 @@
+/*
+ * Copyright 2020 Sheaf Authors
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package sheaf
+
+//go:generate mockgen -destination=../mocks/mock_signature_verifier.go -package mocks github.com/bryanl/sheaf/pkg/sheaf SignatureVerifier
+
+// SignatureVerifier verifies archive signatures.
+type SignatureVerifier interface {
+	// VerifySignature verifies the signature of an exploded archive.
+	VerifySignature(b Bundle) error
+}
@@ -9,6 +9,7 @@ package sheaf
 import (
 	"fmt"
 	"sort"
+	"strings"
 
 	"go.uber.org/multierr"
 	"k8s.io/client-go/util/jsonpath"
@@ -33,11 +34,23 @@ type UserDefinedImage struct {
 	APIVersion string `json:"apiVersion"`
 	Kind       string `json:"kind"`
 	JSONPath   string `json:"jsonPath"`
+	// HubJSONPaths locate the hub of image names that don't contain a '/',
+	// e.g. Istio's pilot image. The image is <hub>/<name>:<tag>. Paths
+	// starting with '@' are relative to the object containing the image
+	// name. The first path with a value is used, and names without a hub are
+	// skipped.
+	HubJSONPaths []string `json:"hubJSONPaths,omitempty"`
+	// TagJSONPaths locate the tag of image names combined with a hub. The
+	// first path with a value is used. Without HubJSONPaths, they locate the
+	// tag of image repositories that keep it in a separate field, e.g.
+	// cert-manager's image.tag. The image is <repository>:<tag>, and
+	// repositories without a tag are skipped.
+	TagJSONPaths []string `json:"tagJSONPaths,omitempty"`
 }
 
 // Validate validates a user defined image.
 func (udi UserDefinedImage) Validate() error {
-	var apiVersionErr, kindErr, jsonPathErr error
+	var apiVersionErr, kindErr, jsonPathErr, partsErr error
 
 	if udi.APIVersion == "" {
 		apiVersionErr = fmt.Errorf("api version is blank")
@@ -56,7 +69,14 @@ func (udi UserDefinedImage) Validate() error {
 		}
 	}
 
-	return multierr.Combine(apiVersionErr, kindErr, jsonPathErr)
+	for _, jsonPath := range append(udi.HubJSONPaths, udi.TagJSONPaths...) {
+		j := jsonpath.New("parser")
+		if err := j.Parse(strings.TrimPrefix(jsonPath, "@")); err != nil {
+			partsErr = multierr.Append(partsErr, fmt.Errorf("unable to parse json path %q: %w", jsonPath, err))
+		}
+	}
+
+	return multierr.Combine(apiVersionErr, kindErr, jsonPathErr, partsErr)
 }
 
 // UserDefinedImageKey is a key describing a UserDefinedImage.